@@ -0,0 +1,86 @@
+// ═══════════════════════════════════════════════════════════
+// CHAT
+// Room and lobby chat, history replay and muting
+// ═══════════════════════════════════════════════════════════
+
+const mutedUsers = new Set();
+const knownNames = {}; // userId -> username, for the muted list
+
+function sendChat(channel) {
+  const input = document.getElementById(channel + '-chat-input');
+  const text  = input.value.trim();
+  if (!text) return;
+  wsSend('chat_message', { channel, text });
+  input.value = '';
+}
+
+function onChatMessage(msg) {
+  if (mutedUsers.has(msg.user_id)) return;
+  appendChatLine(msg.channel === 'lobby' ? 'lobby' : 'room', msg);
+}
+
+function onChatHistory(payload) {
+  const log = document.getElementById('room-chat-log');
+  if (log) log.innerHTML = '';
+  (payload?.messages || []).forEach(onChatMessage);
+}
+
+function onMutedUsers(payload) {
+  mutedUsers.clear();
+  (payload?.user_ids || []).forEach(id => mutedUsers.add(id));
+  renderMutedUsers();
+}
+
+function clearRoomChat() {
+  const log = document.getElementById('room-chat-log');
+  if (log) log.innerHTML = '';
+}
+
+// Built with textContent — chat is user input
+function appendChatLine(channel, msg) {
+  const log = document.getElementById(channel + '-chat-log');
+  if (!log) return;
+  knownNames[msg.user_id] = msg.username;
+
+  const line = document.createElement('div');
+  line.className = 'chat-line';
+
+  const name = document.createElement('span');
+  name.className   = 'chat-name';
+  name.textContent = msg.username;
+  name.title       = 'Mute ' + msg.username;
+  name.onclick     = () => muteUser(msg.user_id, msg.username);
+  line.appendChild(name);
+  line.appendChild(document.createTextNode(': ' + msg.text));
+
+  log.appendChild(line);
+  log.scrollTop = log.scrollHeight;
+}
+
+function muteUser(userId, username) {
+  if (username === State.myUsername) return;
+  if (!confirm('Mute ' + username + '? You won\'t see their messages.')) return;
+  wsSend('mute_user', { user_id: userId });
+}
+
+function unmuteUser(userId) {
+  wsSend('unmute_user', { user_id: userId });
+}
+
+function renderMutedUsers() {
+  ['lobby', 'room'].forEach(channel => {
+    const el = document.getElementById(channel + '-chat-muted');
+    if (!el) return;
+    el.innerHTML = '';
+    if (!mutedUsers.size) return;
+
+    el.appendChild(document.createTextNode('Muted: '));
+    mutedUsers.forEach(id => {
+      const chip = document.createElement('span');
+      chip.textContent = (knownNames[id] || 'user ' + id) + ' ×';
+      chip.title       = 'Unmute';
+      chip.onclick     = () => unmuteUser(id);
+      el.appendChild(chip);
+    });
+  });
+}
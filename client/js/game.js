@@ -29,10 +29,31 @@ function enterGameScreen(roomName) {
   State.oppReady    = false;
   State.gameStarted = false;
 
+  clearRoomChat();
   updateReadyUI();
   showScreen('game');
 }
 
+// ── Spectating ───────────────────────────────────────────────
+
+function onSpectating(payload) {
+  State.currentRoom = payload;
+  State.spectating  = true;
+  enterGameScreen(payload.room_name);
+  document.getElementById('ready-section').style.display = 'none';
+  document.getElementById('opp-name').textContent = 'Spectating';
+  updateSpectatorCount(payload.spectator_count);
+
+  const delay = payload.delay_seconds ? ` (board ${payload.delay_seconds}s behind)` : '';
+  showToast('Watching ' + payload.room_name + delay, 'success');
+}
+
+function updateSpectatorCount(count) {
+  const name = State.currentRoom?.room_name || '';
+  document.getElementById('game-room-name').textContent =
+    count ? `${name} · 👁 ${count}` : name;
+}
+
 function updatePlayerColors() {
   const myClass  = State.playerIndex === 0 ? 'p1' : 'p2';
   const oppClass = State.playerIndex === 0 ? 'p2' : 'p1';
@@ -52,16 +73,20 @@ function updatePlayerColors() {
 // ── Player joined / left ────────────────────────────────────
 
 function onPlayerJoined(payload) {
-  if (payload.playerId === State.myClientId) return;  // skip if it's yourself
+  if (payload.user_id === State.myUserId) return;  // skip if it's yourself, in any tab
 
-  const name = payload.username || ('Player ' + payload.userId);
+  const name = payload.username || ('Player ' + payload.user_id);
   document.getElementById('opp-name').textContent   = name;
   document.getElementById('opp-avatar').textContent = name[0]?.toUpperCase() || '?';
   showToast(name + ' joined the room', 'success');
 }
 
 function onPlayerLeft(payload) {
-  if (payload.playerId === State.myClientId) return;
+  if (payload.user_id === State.myUserId) {
+    // We left from another tab — follow it back to the lobby
+    if (payload.player_id !== State.myClientId) resetToLobby();
+    return;
+  }
 
   document.getElementById('opp-name').textContent   = 'Waiting...';
   document.getElementById('opp-avatar').textContent = '?';
@@ -88,7 +113,15 @@ function handleReady() {
 }
 
 function onPlayerReady(payload) {
-  if (payload.playerId === State.myClientId) return;
+  if (payload.user_id === State.myUserId) {
+    // Keep tabs mirroring the same seat in sync
+    State.myReady = payload.ready;
+    const btn = document.getElementById('ready-btn');
+    btn.textContent = State.myReady ? 'Cancel Ready' : 'Mark Ready';
+    btn.className   = State.myReady ? 'btn btn-outline' : 'btn btn-ready';
+    updateReadyUI();
+    return;
+  }
 
   State.oppReady = payload.ready;
   updateReadyUI();
@@ -148,11 +181,37 @@ function stopTurnTimerDisplay() {
   if (timerEl) timerEl.style.display = 'none';
 }
 
+// onGameResumed puts us back in a game after a dropped connection or a
+// reload; game_state and turn_timer follow.
+function onGameResumed(payload) {
+  if (!State.currentRoom) {
+    State.currentRoom = { room_id: payload.room_id, room_name: payload.room_name };
+    enterGameScreen(payload.room_name);
+  }
+
+  State.playerIndex  = payload.player_index;
+  State.gridTemplate = {
+    rowCriteria: payload.row_criteria,
+    colCriteria: payload.col_criteria,
+  };
+  updatePlayerColors();
+  renderGridHeaders();
+
+  if (!State.gameStarted) {
+    State.gameStarted = true;
+    document.getElementById('waiting-state').style.display = 'none';
+    document.getElementById('grid-wrap').style.display     = 'flex';
+    document.getElementById('ready-section').style.display = 'none';
+    buildGrid();
+  }
+  showToast('Reconnected — back in the game', 'success');
+}
+
 function onGameState(payload) {
-  // Check for game over first
+  // Check for game over first — game_ended follows with the summary and
+  // shows the win screen
   if (payload?.game?.status === 'completed' && payload?.game?.winner_id) {
     if (payload?.grid) updateGridFromState(payload.grid);
-    setTimeout(() => showWinScreen(payload.game.winner_id), 500);
     return;
   }
 
@@ -223,6 +282,13 @@ function showTurnNotification() {
 }
 
 function updateTurnBar(currentTurn) {
+  if (State.spectating) {
+    const player = State.players?.[currentTurn];
+    document.getElementById('turn-text').textContent =
+      (player?.username || 'Player ' + (currentTurn + 1)) + "'s turn";
+    return;
+  }
+
   const wasMyTurn = State.myTurn;
   State.myTurn = currentTurn === State.playerIndex;
   const text = State.myTurn ? 'Your turn' : "Opponent's turn";
@@ -266,7 +332,13 @@ function updateGridFromState(grid) {
 
 function handleLeaveRoom() {
   wsSend('leave_room', {});
+  resetToLobby();
+}
+
+// resetToLobby forgets the current room and shows the lobby.
+function resetToLobby() {
   State.currentRoom  = null;
+  State.spectating   = false;
   State.gameStarted  = false;
   State.myReady      = false;
   State.oppReady     = false;
@@ -356,6 +428,7 @@ function onCellClick(idx) {
 
   // Show history first
   showCellHistory(idx);
+  if (State.spectating) return;
 
   if (!State.myTurn) {
     showToast("It's not your turn", 'error');
@@ -568,9 +641,10 @@ function selectPlayer(encoded) {
   try { player = JSON.parse(decodeURIComponent(encoded)); }
   catch { return; }
 
-  console.log('sending make_move with room_id:', State.currentRoom?.room_id);  // add this
+  // A double-tap mustn't play twice; the first move is still on its way
+  if (hasPendingRequest('make_move')) { closeSearchModal(); return; }
 
-  wsSend('make_move', {
+  wsRequest('make_move', {
     room_id:         State.currentRoom?.room_id,
     row:             Math.floor(selectedCell / 3),
     col:             selectedCell % 3,
@@ -586,10 +660,15 @@ function selectPlayer(encoded) {
 // ═══════════════════════════════════════════════════════════
 // Win Screen
 // ═══════════════════════════════════════════════════════════
-function showWinScreen(winnerId) {
+function showWinScreen(winnerId, summary) {
   const isWinner = State.players?.[State.playerIndex]?.user_id === winnerId;
-  const message  = isWinner ? '🏆 You Win!' : '😔 You Lose!';
-  const color    = isWinner ? 'var(--green)' : 'var(--red)';
+  let message    = isWinner ? '🏆 You Win!' : '😔 You Lose!';
+  let color      = isWinner ? 'var(--green)' : 'var(--red)';
+  if (State.spectating) {
+    const winner = State.players?.find(p => p.user_id === winnerId);
+    message = '🏆 ' + (winner?.username || 'Player') + ' wins!';
+    color   = 'var(--green)';
+  }
 
   const overlay = document.createElement('div');
   overlay.id = 'win-overlay';
@@ -603,8 +682,9 @@ function showWinScreen(winnerId) {
       ${message}
     </div>
     <div style="font-size:16px;color:var(--text2);">Game over</div>
+    <div id="win-summary"></div>
     <div style="display:flex;gap:12px;">
-      <button class="btn btn-green" style="width:160px;" onclick="handleRematch()">
+      <button class="btn btn-green" style="width:160px;${State.spectating ? 'display:none;' : ''}" onclick="handleRematch()">
         Rematch
       </button>
       <button class="btn btn-primary" style="width:160px;" onclick="handleLeaveRoom()">
@@ -612,9 +692,43 @@ function showWinScreen(winnerId) {
       </button>
     </div>
   `;
+  renderAnswerSummary(overlay.querySelector('#win-summary'), summary);
   document.body.appendChild(overlay);
 }
 
+// renderAnswerSummary lists why each answer on the board was correct.
+// Built with textContent since summaries carry player names and labels.
+function renderAnswerSummary(container, summary) {
+  if (!container || !summary?.length) return;
+  container.style.cssText = `
+    max-height: 40vh; overflow-y: auto; width: min(560px, 90vw);
+    background: var(--surface); border-radius: var(--radius); padding: 12px 16px;
+  `;
+
+  const title = document.createElement('div');
+  title.textContent = 'Why these answers';
+  title.style.cssText = 'font-weight:600;margin-bottom:8px;';
+  container.appendChild(title);
+
+  summary.forEach(cell => {
+    const item = document.createElement('div');
+    item.style.cssText = 'margin-bottom:8px;font-size:13px;';
+
+    const name = document.createElement('div');
+    name.textContent = cell.player_name;
+    name.style.fontWeight = '600';
+    item.appendChild(name);
+
+    (cell.explanations || []).forEach(ex => {
+      const line = document.createElement('div');
+      line.textContent = ex.label + ' — ' + ex.summary;
+      line.style.color = 'var(--text2)';
+      item.appendChild(line);
+    });
+    container.appendChild(item);
+  });
+}
+
 // ═══════════════════════════════════════════════════════════
 // GAME END & REMATCH
 // ═══════════════════════════════════════════════════════════
@@ -630,7 +744,7 @@ function onGameEnded(payload) {
         if (payload?.is_draw) {
             showDrawScreen();
         } else {
-            showWinScreen(payload?.winner_id);
+            showWinScreen(payload?.winner_id, payload?.summary);
         }
     }, 500);
 }
@@ -42,10 +42,22 @@ function renderRoomList(rooms) {
           <div class="room-card-meta">
             <span class="room-status status-${room.status}">${room.status}</span>
             <span class="badge ${difficultyClass}">${difficultyLabel}</span>
+            ${room.custom_grid ? '<span class="badge">Custom grid</span>' : ''}
+            ${room.filters?.league ? `<span class="badge">${room.filters.league} only</span>` : ''}
+            ${room.filters?.division ? `<span class="badge">${room.filters.division} only</span>` : ''}
+            ${room.filters?.stat_group ? `<span class="badge">${room.filters.stat_group} only</span>` : ''}
+            ${room.filters?.active_only ? '<span class="badge">Active franchises</span>' : ''}
+            ${room.filters?.min_year ? `<span class="badge">${room.filters.min_year}+</span>` : ''}
             <div class="players-pip">${pips}</div>
             <span>${room.player_count}/${room.max_players}</span>
+            ${room.spectator_count ? `<span>👁 ${room.spectator_count}</span>` : ''}
           </div>
         </div>
+        ${room.allow_spectators ? `
+        <button class="btn btn-outline btn-sm" style="width:auto;"
+                onclick="event.stopPropagation(); handleSpectateClick('${room.id}', '${room.name}', ${room.has_password})">
+          Watch
+        </button>` : ''}
       </div>`;
   }).join('');
 }
@@ -60,32 +72,46 @@ function handleCreateRoom() {
   const roomName = document.getElementById('new-room-name').value.trim();
   const password = document.getElementById('new-room-pass').value.trim();
   const difficulty = document.getElementById('create-room-difficulty').value; // ADD THIS
+  const filters = {
+    league:     document.getElementById('create-room-league').value,
+    division:   document.getElementById('create-room-division').value,
+    stat_group: document.getElementById('create-room-stat-group').value,
+    active_only: document.getElementById('create-room-active').checked,
+    min_year:   document.getElementById('create-room-modern').checked ? 1990 : 0,
+  };
  
   if (!roomName) {
     showToast('Room name is required', 'error');
     return;
   }
  
-  wsSend('create_room', {
+  if (hasPendingRequest('create_room')) return;
+
+  wsRequest('create_room', {
     room_name: roomName,
     password:  password,
     max_players: 2,
     difficulty: difficulty, // ADD THIS
+    filters:    filters,
   });
 }
 
 // ── Join room ───────────────────────────────────────────────
 
-function handleJoinClick(id, name, hasPassword) {
+function handleJoinClick(id, name, hasPassword, spectate) {
   if (hasPassword) {
-    pendingJoinRoom = { id, name };
+    pendingJoinRoom = { id, name, spectate };
     document.getElementById('modal-password').value = '';
     document.getElementById('password-modal').classList.add('show');
   } else {
-    wsSend('join_room', { room_id: id, room_name: name });
+    wsSend(spectate ? 'spectate_room' : 'join_room', { room_id: id, room_name: name });
   }
 }
 
+function handleSpectateClick(id, name, hasPassword) {
+  handleJoinClick(id, name, hasPassword, true);
+}
+
 function closePasswordModal() {
   document.getElementById('password-modal').classList.remove('show');
   pendingJoinRoom = null;
@@ -94,7 +120,7 @@ function closePasswordModal() {
 function submitPasswordJoin() {
   if (!pendingJoinRoom) return;
   const pass = document.getElementById('modal-password').value;
-  wsSend('join_room', {
+  wsSend(pendingJoinRoom.spectate ? 'spectate_room' : 'join_room', {
     room_id:   pendingJoinRoom.id,
     room_name: pendingJoinRoom.name,
     password:  pass,
@@ -6,8 +6,10 @@ const State = {
   token: null,
   myUsername: null,
   myClientId: null,
+  myUserId: null,
   currentRoom: null,
   isCreator: false,
+  spectating: false,
   myReady: false,
   oppReady: false,
   gameStarted: false,
@@ -33,26 +33,49 @@ async function loadCurrentProfile() {
     // Pre-fill username field
     document.getElementById('settings-username').value = user.username || '';
 
-    // Show current favorite team
-    updateFavTeamDisplay(user.favorite_team_id, user.favorite_team_name);
+    // Show current favorite teams
+    favoriteTeams = (user.favorite_teams || []).map(t => ({
+      team_id: t.team_id, team_name: t.team_name,
+    }));
+    updateFavTeamDisplay();
   } catch {
     // non-fatal
   }
 }
 
-function updateFavTeamDisplay(teamID, teamName) {
+// Ranked favorites, best first — mirrors user_favorite_teams
+const MAX_FAVORITE_TEAMS = 3;
+let favoriteTeams = [];
+
+function updateFavTeamDisplay() {
   const nameEl = document.getElementById('fav-team-name');
   const logoEl = document.getElementById('fav-team-logo');
 
-  if (teamID && teamName) {
-    nameEl.textContent = teamName;
-    logoEl.innerHTML = `
-      <img src="https://www.mlbstatic.com/team-logos/${teamID}.svg"
-           style="width:32px;height:32px;object-fit:contain;margin-right:10px;"
-           onerror="this.style.display='none'">`;
-  } else {
-    nameEl.textContent = 'No favorite team set';
-    logoEl.innerHTML = '';
+  logoEl.innerHTML = '';
+  if (favoriteTeams.length === 0) {
+    nameEl.textContent = 'No favorite teams set';
+    return;
+  }
+
+  nameEl.innerHTML = favoriteTeams.map((t, i) => `
+    <div class="fav-team-row">
+      <span>${i + 1}.</span>
+      <img src="https://www.mlbstatic.com/team-logos/${t.team_id}.svg"
+           style="width:32px;height:32px;object-fit:contain;margin:0 10px;"
+           onerror="this.style.display='none'">
+      <span>${t.team_name}</span>
+      <button class="btn btn-outline" style="margin-left:auto;" onclick="removeFavoriteTeam(${i})">Remove</button>
+    </div>
+  `).join('');
+}
+
+async function saveFavoriteTeams(teams) {
+  const resp = await authFetch('/api/profile/teams', {
+    method: 'PUT',
+    body:   JSON.stringify({ teams }),
+  });
+  if (!resp.ok) {
+    throw new Error((await resp.text()) || 'Failed to update favorite teams');
   }
 }
 
@@ -231,24 +254,41 @@ async function handleTeamSearch(query) {
 }
 
 async function selectFavoriteTeam(teamID, teamName) {
+  if (favoriteTeams.some(t => t.team_id === teamID)) {
+    showToast(`${teamName} is already a favorite`, 'error');
+    return;
+  }
+  if (favoriteTeams.length >= MAX_FAVORITE_TEAMS) {
+    showToast(`You can pick up to ${MAX_FAVORITE_TEAMS} teams — remove one first`, 'error');
+    return;
+  }
+
+  const updated = [...favoriteTeams, { team_id: teamID, team_name: teamName }];
   try {
-    const resp = await authFetch('/api/profile/team', {
-      method: 'PUT',
-      body:   JSON.stringify({ team_id: teamID, team_name: teamName }),
-    });
+    await saveFavoriteTeams(updated);
+  } catch (err) {
+    showToast(err.message, 'error');
+    return;
+  }
 
-    if (!resp.ok) {
-      showToast('Failed to update favorite team', 'error');
-      return;
-    }
+  favoriteTeams = updated;
+  updateFavTeamDisplay();
+  document.getElementById('team-search-input').value = '';
+  document.getElementById('team-search-results').innerHTML = '';
+  showToast(`${teamName} added to your favorite teams!`, 'success');
+}
 
-    updateFavTeamDisplay(teamID, teamName);
-    document.getElementById('team-search-input').value = '';
-    document.getElementById('team-search-results').innerHTML = '';
-    showToast(`${teamName} set as your favorite team!`, 'success');
-  } catch {
-    showToast('Network error', 'error');
+async function removeFavoriteTeam(index) {
+  const updated = favoriteTeams.filter((_, i) => i !== index);
+  try {
+    await saveFavoriteTeams(updated);
+  } catch (err) {
+    showToast(err.message, 'error');
+    return;
   }
+
+  favoriteTeams = updated;
+  updateFavTeamDisplay();
 }
 
 // ── Game history ──────────────────────────────────────────────
@@ -383,13 +423,10 @@ async function handleOnboardingTeamSave() {
   }
 
   try {
-    await authFetch('/api/profile/team', {
-      method: 'PUT',
-      body:   JSON.stringify({
-        team_id:   onboardingSelectedTeam.teamID,
-        team_name: onboardingSelectedTeam.teamName,
-      }),
-    });
+    await saveFavoriteTeams([{
+      team_id:   onboardingSelectedTeam.teamID,
+      team_name: onboardingSelectedTeam.teamName,
+    }]);
   } catch {
     // non-fatal — just skip to lobby
   }
@@ -4,6 +4,11 @@
 // to the appropriate handler in lobby.js or game.js
 // ═══════════════════════════════════════════════════════════
 
+// Must match ProtocolVersion in server/websocket/protocol.go
+const PROTOCOL_VERSION = 2;
+
+let reconnectDelay = 1000;
+
 function connectWebSocket() {
   const proto = location.protocol === 'https:' ? 'wss:' : 'ws:';
   const port  = location.protocol === 'https:' ? '' : ':8080';
@@ -11,8 +16,26 @@ function connectWebSocket() {
 
   window.ws = new WebSocket(addr);
 
-  window.ws.onopen  = () => { requestRoomList(); };
-  window.ws.onclose = () => { showToast('Disconnected from server', 'error'); };
+  window.ws.onopen  = () => { reconnectDelay = 1000; requestRoomList(); resendPendingRequests(); };
+  window.ws.onclose = (e) => {
+    if (e.code === 1008) {
+      // Closed for breaking the rate limits — don't come straight back
+      showToast('Disconnected for sending too many messages', 'error');
+      return;
+    }
+    if (e.code === 4001) {
+      // Another tab took over this session — let it have the seat
+      showToast('Opened in another tab', 'error');
+      return;
+    }
+    // 1001: the server is restarting, and saved any game in progress
+    showToast(e.code === 1001 ? 'Server restarting' : 'Disconnected from server', 'error');
+    // Mid-game the server holds our seat for a while — get back in
+    if (State.gameStarted && State.token) {
+      setTimeout(connectWebSocket, reconnectDelay);
+      reconnectDelay = Math.min(reconnectDelay * 2, 10000);
+    }
+  };
   window.ws.onerror = () => { showToast('Connection error', 'error'); };
 
   window.ws.onmessage = (e) => {
@@ -33,6 +56,37 @@ function wsSend(type, payload) {
   window.ws.send(JSON.stringify({ type, payload: payload || {} }));
 }
 
+// ── Requests ───────────────────────────────────────────────
+// Commands sent with a request_id wait here for the ack or error that
+// answers them. make_move and create_room are resent after a reconnect —
+// the server answers a retry without running it twice.
+const pendingRequests = new Map();
+const RETRIED_TYPES   = ['make_move', 'create_room'];
+
+function wsRequest(type, payload) {
+  const id = crypto.randomUUID?.() || `${Date.now()}-${Math.random().toString(36).slice(2)}`;
+  pendingRequests.set(id, { type, payload: payload || {} });
+  sendRequest(id);
+  return id;
+}
+
+function hasPendingRequest(type) {
+  return [...pendingRequests.values()].some(r => r.type === type);
+}
+
+function sendRequest(id) {
+  const req = pendingRequests.get(id);
+  if (!req || !window.ws || window.ws.readyState !== WebSocket.OPEN) return;
+  window.ws.send(JSON.stringify({ type: req.type, request_id: id, payload: req.payload }));
+}
+
+function resendPendingRequests() {
+  pendingRequests.forEach((req, id) => {
+    if (RETRIED_TYPES.includes(req.type)) sendRequest(id);
+    else pendingRequests.delete(id);
+  });
+}
+
 // ═══════════════════════════════════════════════════════════
 // MESSAGE ROUTER
 // Each case delegates to the relevant module
@@ -42,7 +96,12 @@ function handleServerMessage(msg) {
 
     // ── Connection ──────────────────────────────────────────
     case 'connected':
-      State.myClientId = msg.payload?.clientId;
+      State.myClientId = msg.payload?.client_id;
+      State.myUserId   = msg.payload?.user_id;
+      if (msg.payload?.protocol_version !== PROTOCOL_VERSION) {
+        console.warn('Server speaks protocol v' + msg.payload?.protocol_version +
+            ', this client v' + PROTOCOL_VERSION + ' — reload to update');
+      }
       break;
 
     // ── Lobby ───────────────────────────────────────────────
@@ -66,11 +125,45 @@ function handleServerMessage(msg) {
       }
       break;
 
+    case 'spectating':
+      onSpectating(msg.payload);
+      break;
+
+    case 'spectators_changed':
+      updateSpectatorCount(msg.payload?.spectator_count);
+      break;
+
     // ── In-room events ───────────────────────────────────────
     case 'player_joined': onPlayerJoined(msg.payload);  break;
     case 'player_left':   onPlayerLeft(msg.payload);    break;
     case 'player_ready':  onPlayerReady(msg.payload);   break;
 
+    case 'player_disconnected':
+      showToast(
+          (msg.payload?.username || 'Opponent') + ' lost connection — holding their seat for ' +
+          msg.payload?.grace_seconds + 's',
+          'error'
+      );
+      break;
+
+    case 'server_shutdown':
+      showToast('Server restarting in ' + msg.payload?.seconds + 's' +
+          (State.gameStarted ? ' — your game will be saved' : ''), 'error');
+      break;
+
+    case 'room_closed':
+      if (msg.payload?.room_id === State.currentRoom || !msg.payload?.room_id) {
+        showToast('The room was closed', 'error');
+        resetToLobby();
+      }
+      break;
+
+    case 'player_reconnected':
+      if (msg.payload?.user_id !== State.myUserId) {
+        showToast((msg.payload?.username || 'Opponent') + ' is back!', 'success');
+      }
+      break;
+
     case 'room_ready':
         if (State.isCreator) {
             document.getElementById('start-btn').disabled = false;
@@ -108,16 +201,25 @@ function handleServerMessage(msg) {
         break;
     }
     // ── Game ─────────────────────────────────────────────────
+    case 'grid_generating':
+      showGridLoading();
+      break;
+
     case 'game_started':
-      if (msg.payload?.playerIndex !== undefined) {
-          State.playerIndex    = msg.payload.playerIndex;
+      if (msg.payload?.player_index !== undefined || msg.payload?.spectator) {
+          State.playerIndex    = msg.payload.player_index ?? 0;
           State.gridTemplate   = {
-              rowCriteria: msg.payload.rowCriteria,
-              colCriteria: msg.payload.colCriteria,
+              rowCriteria: msg.payload.row_criteria,
+              colCriteria: msg.payload.col_criteria,
           };
           updatePlayerColors();
           renderGridHeaders(); 
       }
+      if (State.spectating) {
+          // Spectators stay through rematches — clear the last game
+          document.getElementById('win-overlay')?.remove();
+          State.gameStarted = false;
+      }
       if (!State.gameStarted) {
           State.gameStarted = true;
           document.getElementById('waiting-state').style.display = 'none';
@@ -127,6 +229,10 @@ function handleServerMessage(msg) {
       }
       break;
 
+    case 'game_resumed':
+      onGameResumed(msg.payload);
+      break;
+
     case 'game_state':
       if (msg.payload?.players) {
         State.players = msg.payload.players;
@@ -147,7 +253,8 @@ function handleServerMessage(msg) {
     
     case 'cell_overtaken':
       showToast(
-          msg.payload?.newPlayer + ' overtook ' + msg.payload?.oldPlayer + '!',
+          msg.payload?.new_player + ' overtook ' + msg.payload?.old_player + '!' +
+          (msg.payload?.explanations || []).map(ex => ' ' + ex.summary + '.').join(''),
           'success'
       );
       break;
@@ -155,8 +262,8 @@ function handleServerMessage(msg) {
     case 'overtake_failed':
       showToast(
           'Not rare enough to overtake! (yours: ' +
-          (msg.payload?.yourRarity * 100).toFixed(1) + '% vs existing: ' +
-          (msg.payload?.existingRarity * 100).toFixed(1) + '%)',
+          (msg.payload?.your_rarity * 100).toFixed(1) + '% vs existing: ' +
+          (msg.payload?.existing_rarity * 100).toFixed(1) + '%)',
           'error'
       );
       break;
@@ -168,12 +275,42 @@ function handleServerMessage(msg) {
     case 'turn_timeout':
       showToast('Time expired — turn skipped!', 'error');
       break;
+    // ── Chat ─────────────────────────────────────────────────
+    case 'chat_message':
+      onChatMessage(msg.payload);
+      break;
+
+    case 'chat_history':
+      onChatHistory(msg.payload);
+      break;
+
+    case 'muted_users':
+      onMutedUsers(msg.payload);
+      break;
+
     // ── Errors ─────────────────────────────────────────
+    case 'ack':
+      pendingRequests.delete(msg.payload?.request_id);
+      break;
+
     case 'error':
-      showToast(msg.message || 'An error occurred', 'error');
+      if (msg.payload?.request_id) pendingRequests.delete(msg.payload.request_id);
+      onServerError(msg.payload || {});
       break;
 
     default:
       console.log('Unhandled message type:', msg.type, msg);
   }
 }
+
+// Errors carry a stable code (see /api/protocol/schema); the message is
+// for display only
+function onServerError(payload) {
+  if (payload.code === 'SHORT_CELLS') {
+    const cells = (payload.short_cells || [])
+      .map(c => `R${c.row + 1}C${c.col + 1} (${c.answers})`).join(', ');
+    showToast(`Custom grid rejected — too few answers in ${cells}`, 'error');
+    return;
+  }
+  showToast(payload.message || 'An error occurred', 'error');
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"trivia-server/criteria"
+	"trivia-server/grid"
+	"trivia-server/ingest"
+	"trivia-server/integrity"
+	"trivia-server/rarity"
+	"trivia-server/websocket"
+)
+
+// runCommand runs a one-off admin subcommand instead of the server, e.g.
+//
+//	./main fetch -out data/mlb -from 1969 -to 2024 && ./main ingest -dir data/mlb
+//	./main import-lahman -dir data/lahman -register data/chadwick/people.csv
+//	./main rebuild-rarity -batch 50
+//	./main check -fix
+//	./main import-awards -dir data/awards
+//	./main build-teammates -anchors 40
+//	./main import-people -dir data/people && ./main evaluate-criteria -types position,bio
+//	./main import-player-stats && ./main evaluate-criteria -types era,season
+//	./main protocol-schema -out ../client/protocol.schema.json
+//
+// Returns false if args don't name a subcommand.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "ingest":
+		fs := flag.NewFlagSet("ingest", flag.ExitOnError)
+		dir := fs.String("dir", "data/mlb", "directory holding people/ and awards/ dumps")
+		prune := fs.Bool("prune", false, "let career, team and bio criteria lose links the dumps don't support")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := ingest.Ingest(db, *dir, ingest.Options{Prune: *prune})
+		if err != nil {
+			log.Fatal("Ingest failed: ", err)
+		}
+		for _, s := range result.Skipped {
+			fmt.Printf("  skipped %s\n", s)
+		}
+		fmt.Printf("Ingested %d players (%d season lines), %d award links; evaluated %d criteria (+%d / -%d)\n",
+			result.People.Players, result.People.Seasons, result.Awards.Links,
+			result.Criteria, result.Added, result.Removed)
+		rebuildAfterEvaluation(db, result.EvaluationResult)
+		refreshDataVersion(db)
+
+	case "fetch":
+		fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+		out := fs.String("out", "data/mlb", "directory to write people/ and awards/ dumps to")
+		from := fs.Int("from", 1969, "first season")
+		to := fs.Int("to", time.Now().Year()-1, "last season")
+		awards := fs.String("awards", "", "comma-separated award IDs (default: every award criteria in the database)")
+		fs.Parse(args[1:])
+
+		var awardIDs []string
+		if *awards != "" {
+			awardIDs = strings.Split(*awards, ",")
+		} else {
+			db := openCommandDB()
+			ids, err := ingest.AwardIDs(db)
+			db.Close()
+			if err != nil {
+				log.Fatal("Failed to load award IDs: ", err)
+			}
+			awardIDs = ids
+		}
+
+		result, err := ingest.Fetch(*out, ingest.FetchOptions{FromSeason: *from, ToSeason: *to, AwardIDs: awardIDs})
+		if err != nil {
+			log.Fatal("Fetch failed: ", err)
+		}
+		fmt.Printf("Fetched %d players in %d files, %d award files\n", result.Players, result.PeopleFiles, result.AwardFiles)
+
+	case "import-lahman":
+		fs := flag.NewFlagSet("import-lahman", flag.ExitOnError)
+		dir := fs.String("dir", "data/lahman", "directory of Lahman CSVs (People.csv, Batting.csv, ...)")
+		register := fs.String("register", "", "optional Chadwick register people.csv for the mlb_id crosswalk")
+		prune := fs.Bool("prune", false, "let career, team and bio criteria lose links the files don't support")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := ingest.ImportLahman(db, *dir, ingest.LahmanOptions{RegisterPath: *register, Prune: *prune})
+		if err != nil {
+			log.Fatal("Lahman import failed: ", err)
+		}
+		for _, s := range result.Skipped {
+			fmt.Printf("  skipped %s\n", s)
+		}
+		fmt.Printf("Imported %d players (%d matched to MLB IDs), %d team stints, %d stat lines, %d awards\n",
+			result.Players, result.Crosswalked, result.TeamStints, result.StatLines, result.Awards)
+		fmt.Printf("Wrote %d season lines and %d MLB awards; evaluated %d criteria (+%d / -%d)\n",
+			result.Seasons, result.MlbAwards, result.Criteria, result.Added, result.Removed)
+		rebuildAfterEvaluation(db, result.EvaluationResult)
+		refreshDataVersion(db)
+
+	case "import-awards":
+		fs := flag.NewFlagSet("import-awards", flag.ExitOnError)
+		dir := fs.String("dir", "data/awards", "directory of /awards/{id}/recipients JSON dumps")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := ingest.ImportAwards(db, *dir)
+		if err != nil {
+			log.Fatal("Award import failed: ", err)
+		}
+		for awardID, n := range result.PerAward {
+			fmt.Printf("  %-8s %d recipients\n", awardID, n)
+		}
+		fmt.Printf("Imported %d files, %d players, %d new links\n", result.Files, result.Players, result.Links)
+		rebuildAfterEvaluation(db, ingest.EvaluationResult{Added: result.Links})
+		refreshDataVersion(db)
+
+	case "import-people":
+		fs := flag.NewFlagSet("import-people", flag.ExitOnError)
+		dir := fs.String("dir", "data/people", "directory of /people JSON dumps")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := ingest.ImportPeople(db, *dir)
+		if err != nil {
+			log.Fatal("People import failed: ", err)
+		}
+		fmt.Printf("Imported %d files, %d players\n", result.Files, result.Players)
+
+	case "import-player-stats":
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := ingest.ImportPlayerStats(db)
+		if err != nil {
+			log.Fatal("Player stats import failed: ", err)
+		}
+		fmt.Printf("Copied %d of %d player_stats lines to mlb_player_seasons\n", result.Seasons, result.Lines)
+
+	case "evaluate-criteria":
+		fs := flag.NewFlagSet("evaluate-criteria", flag.ExitOnError)
+		id := fs.Int("id", 0, "evaluate a single criteria by ID")
+		types := fs.String("types", "era,season", "comma-separated criteria types to evaluate")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := evaluateCriteria(db, *id, strings.Split(*types, ","))
+		if err != nil {
+			log.Fatal("Criteria evaluation failed: ", err)
+		}
+		rebuildAfterEvaluation(db, result)
+		refreshDataVersion(db)
+
+	case "rebuild-rarity":
+		fs := flag.NewFlagSet("rebuild-rarity", flag.ExitOnError)
+		batch := fs.Int("batch", rarity.DefaultBatchSize, "grid templates rebuilt per transaction")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := rarity.Rebuild(db, *batch)
+		if err != nil {
+			log.Fatal("Rarity rebuild failed: ", err)
+		}
+		fmt.Printf("Scored %d players; rebuilt %d templates with %d answers\n", result.Players, result.Templates, result.Answers)
+		if len(result.Degraded) > 0 {
+			fmt.Printf("%d templates have a cell with too few answers: %v\n", len(result.Degraded), result.Degraded)
+		}
+
+	case "check":
+		fs := flag.NewFlagSet("check", flag.ExitOnError)
+		fix := fs.Bool("fix", false, "repair what the check finds, in one transaction")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		report, err := integrity.Check(db, *fix)
+		if err != nil {
+			log.Fatal("Integrity check failed: ", err)
+		}
+		printIntegrityReport(report)
+		if !*fix && !report.Clean() {
+			os.Exit(1)
+		}
+
+	case "build-teammates":
+		fs := flag.NewFlagSet("build-teammates", flag.ExitOnError)
+		anchors := fs.Int("anchors", 40, "maximum number of teammate anchors")
+		fs.Parse(args[1:])
+
+		db := openCommandDB()
+		defer db.Close()
+
+		result, err := buildTeammateCriteria(db, *anchors)
+		if err != nil {
+			log.Fatal("Teammate criteria build failed: ", err)
+		}
+		rebuildAfterEvaluation(db, result)
+		refreshDataVersion(db)
+
+	case "protocol-schema":
+		fs := flag.NewFlagSet("protocol-schema", flag.ExitOnError)
+		out := fs.String("out", "", "file to write the schema to (default: stdout)")
+		fs.Parse(args[1:])
+
+		if err := writeProtocolSchema(*out); err != nil {
+			log.Fatal("Protocol schema failed: ", err)
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// writeProtocolSchema writes the websocket protocol's JSON Schema to path,
+// or to stdout if path is empty.
+func writeProtocolSchema(path string) error {
+	data, err := json.MarshalIndent(websocket.Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("Wrote protocol v%d schema to %s\n", websocket.ProtocolVersion, path)
+	return nil
+}
+
+// evaluateCriteria recomputes player_criteria for one criteria, or for
+// every criteria of the given types, in a single transaction.
+func evaluateCriteria(db *sql.DB, id int, types []string) (ingest.EvaluationResult, error) {
+	var result ingest.EvaluationResult
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	var defs []criteria.Definition
+	if id > 0 {
+		def, err := criteria.LoadDefinition(tx, id)
+		if err != nil {
+			return result, err
+		}
+		defs = append(defs, *def)
+	} else {
+		all, err := criteria.LoadDefinitions(tx)
+		if err != nil {
+			return result, err
+		}
+		wanted := make(map[string]bool)
+		for _, t := range types {
+			wanted[strings.TrimSpace(t)] = true
+		}
+		for _, def := range all {
+			if wanted[def.Type] {
+				defs = append(defs, def)
+			}
+		}
+	}
+
+	eval := criteria.NewEvaluator(tx)
+	for _, def := range defs {
+		ids, err := eval.Evaluate(def)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", def.Label, err)
+		}
+		added, removed, err := eval.Apply(def, ids)
+		if err != nil {
+			return result, err
+		}
+		fmt.Printf("  %-32s %5d players (+%d / -%d)\n", def.Label, len(ids), added, removed)
+		result.Criteria++
+		result.Added += added
+		result.Removed += removed
+	}
+
+	return result, tx.Commit()
+}
+
+// buildTeammateCriteria picks well-known anchors from mlb_player_seasons
+// and creates or refreshes a "Teammate of X" criteria for each.
+func buildTeammateCriteria(db *sql.DB, limit int) (ingest.EvaluationResult, error) {
+	var result ingest.EvaluationResult
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	anchors, err := criteria.SelectTeammateAnchors(tx, limit)
+	if err != nil {
+		return result, err
+	}
+
+	eval := criteria.NewEvaluator(tx)
+	for _, a := range anchors {
+		def, err := criteria.UpsertTeammateCriteria(tx, a)
+		if err != nil {
+			return result, err
+		}
+		ids, err := eval.Evaluate(*def)
+		if err != nil {
+			return result, err
+		}
+		added, removed, err := eval.Apply(*def, ids)
+		if err != nil {
+			return result, err
+		}
+		fmt.Printf("  %-32s %5d players (+%d / -%d)\n", def.Label, a.Teammates, added, removed)
+		result.Criteria++
+		result.Added += added
+		result.Removed += removed
+	}
+
+	return result, tx.Commit()
+}
+
+// rebuildAfterEvaluation rebuilds rarity and every template's
+// cell_answers if a command changed any player_criteria links, so games
+// validate against the new links.
+func rebuildAfterEvaluation(db *sql.DB, eval ingest.EvaluationResult) {
+	if eval.Added == 0 && eval.Removed == 0 {
+		return
+	}
+	result, err := rarity.Rebuild(db, rarity.DefaultBatchSize)
+	if err != nil {
+		log.Fatal("Links changed but rebuilding cell answers failed (run rebuild-rarity): ", err)
+	}
+	fmt.Printf("Rebuilt %d templates with %d answers\n", result.Templates, result.Answers)
+}
+
+// refreshDataVersion stores the new data version after a command changed
+// criteria links, so games started from now on are tagged with it.
+func refreshDataVersion(db *sql.DB) {
+	if _, err := grid.RefreshDataVersion(db); err != nil {
+		log.Fatal("Failed to refresh the data version: ", err)
+	}
+}
+
+// printIntegrityReport lists each problem found by the check subcommand.
+func printIntegrityReport(r *integrity.Report) {
+	for _, t := range r.UnlinkedTeams {
+		resolved := "unresolved"
+		if t.MlbTeamID != nil {
+			resolved = fmt.Sprintf("-> %d", *t.MlbTeamID)
+		}
+		fmt.Printf("  team criteria %d %q has no mlb_team_id (%s)\n", t.CriteriaID, t.Label, resolved)
+	}
+	for _, v := range r.StatViolations {
+		fmt.Printf("  player %d doesn't meet %q\n", v.MlbID, v.Label)
+	}
+	for _, a := range r.StaleAnswers {
+		fmt.Printf("  template %d cell (%d,%d): player %d no longer qualifies\n", a.TemplateID, a.Row, a.Col, a.MlbID)
+	}
+	for _, c := range r.ShortCells {
+		fmt.Printf("  template %d cell (%d,%d): only %d answers\n", c.TemplateID, c.Row, c.Col, c.Answers)
+	}
+	for _, s := range r.Skipped {
+		fmt.Printf("  skipped %s\n", s)
+	}
+
+	fmt.Printf("%d unlinked teams, %d stat violations, %d stale answers, %d short cells\n",
+		len(r.UnlinkedTeams), len(r.StatViolations), len(r.StaleAnswers), len(r.ShortCells))
+	if f := r.Fixed; f != nil {
+		fmt.Printf("Fixed: %d teams linked, %d links removed, %d answers deleted, %d templates deactivated\n",
+			f.LinkedTeams, f.RemovedLinks, f.DeletedAnswers, f.DeactivatedTemplates)
+	}
+}
+
+// openCommandDB opens the same database the server uses.
+func openCommandDB() *sql.DB {
+	db, err := sql.Open("mysql", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := db.Ping(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	return db
+}
@@ -0,0 +1,73 @@
+package criteria
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx, so evaluation can
+// run on its own or inside an import transaction.
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Definition is one row of the criteria table — everything needed to
+// work out which players satisfy it.
+type Definition struct {
+	ID        int
+	Type      string // "team" | "stat" | "award" | "era" | "season" | "teammate" | "position" | "bio"
+	Label     string
+	MlbTeamID *int
+	StatField *string
+	StatValue *float64
+	StatGroup *string
+	AwardID   *string
+	StartYear *int
+	EndYear   *int
+	AnchorID  *int // mlb_id of the anchor player for teammate criteria
+	BioField  *string
+	BioValue  *string
+}
+
+const definitionColumns = `
+	id, type, label, mlb_team_id, stat_field, stat_value, stat_group,
+	award_id, start_year, end_year, anchor_mlb_id, bio_field, bio_value`
+
+func scanDefinition(scan func(dest ...interface{}) error) (Definition, error) {
+	var d Definition
+	err := scan(
+		&d.ID, &d.Type, &d.Label, &d.MlbTeamID, &d.StatField, &d.StatValue, &d.StatGroup,
+		&d.AwardID, &d.StartYear, &d.EndYear, &d.AnchorID, &d.BioField, &d.BioValue,
+	)
+	return d, err
+}
+
+// LoadDefinitions returns every criteria definition, ordered by ID.
+func LoadDefinitions(q Querier) ([]Definition, error) {
+	rows, err := q.Query(`SELECT ` + definitionColumns + ` FROM criteria ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load criteria definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var defs []Definition
+	for rows.Next() {
+		d, err := scanDefinition(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan criteria definition: %w", err)
+		}
+		defs = append(defs, d)
+	}
+	return defs, rows.Err()
+}
+
+// LoadDefinition returns a single criteria definition.
+func LoadDefinition(q Querier, id int) (*Definition, error) {
+	d, err := scanDefinition(q.QueryRow(`SELECT `+definitionColumns+` FROM criteria WHERE id = ?`, id).Scan)
+	if err != nil {
+		return nil, fmt.Errorf("criteria %d not found: %w", id, err)
+	}
+	return &d, nil
+}
@@ -0,0 +1,354 @@
+package criteria
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedType is returned for criteria types the evaluator
+	// can't compute (their links come from somewhere else).
+	ErrUnsupportedType = errors.New("criteria type can't be evaluated")
+	// ErrNoSeasonData is returned when a criteria needs season lines but
+	// mlb_player_seasons is empty — evaluating it would wipe good links.
+	ErrNoSeasonData = errors.New("no season data loaded")
+	// ErrNoBioData is the same guard for biography columns on mlb_players.
+	ErrNoBioData = errors.New("no biography data loaded")
+	// ErrNoAwardData is the same guard for mlb_player_awards.
+	ErrNoAwardData = errors.New("no award data loaded")
+)
+
+// statExpr describes how a criteria stat_field is computed from
+// mlb_player_seasons. Rate stats carry a minimum sample so a 1-for-2
+// career doesn't count as a .500 hitter.
+type statExpr struct {
+	sum           string // aggregate over the grouped season rows
+	careerMin     string // extra HAVING clause for career totals
+	seasonMin     string // extra HAVING clause for a single season
+	lowerIsBetter bool
+}
+
+// Keyed by the MLB Stats API stat names stored in criteria.stat_field.
+var statExprs = map[string]statExpr{
+	"homeRuns":    {sum: "SUM(home_runs)"},
+	"hits":        {sum: "SUM(hits)"},
+	"rbi":         {sum: "SUM(rbi)"},
+	"stolenBases": {sum: "SUM(stolen_bases)"},
+	"wins":        {sum: "SUM(wins)"},
+	"saves":       {sum: "SUM(saves)"},
+	"strikeOuts":  {sum: "SUM(strikeouts)"},
+	"avg": {
+		sum:       "SUM(hits) / NULLIF(SUM(at_bats), 0)",
+		careerMin: "SUM(at_bats) >= 3000",
+		seasonMin: "SUM(at_bats) >= 450",
+	},
+	"obp": {
+		sum:       "(SUM(hits) + SUM(walks) + SUM(hit_by_pitch)) / NULLIF(SUM(at_bats) + SUM(walks) + SUM(hit_by_pitch) + SUM(sac_flies), 0)",
+		careerMin: "SUM(at_bats) >= 3000",
+		seasonMin: "SUM(at_bats) >= 450",
+	},
+	"era": {
+		sum:           "SUM(earned_runs) * 27 / NULLIF(SUM(outs_pitched), 0)",
+		careerMin:     "SUM(outs_pitched) >= 3000", // 1000 IP
+		seasonMin:     "SUM(outs_pitched) >= 486",  // 162 IP
+		lowerIsBetter: true,
+	},
+}
+
+// bioField describes how a criteria bio_field matches mlb_players.
+type bioField struct {
+	column string // must have data before the criteria can be evaluated
+	where  string // takes bio_value as its only argument
+}
+
+var bioFields = map[string]bioField{
+	"position":          {column: "position", where: "position = ?"},
+	"bats":              {column: "bats", where: "bats = ?"},
+	"throws":            {column: "throws", where: "throws = ?"},
+	"birth_country":     {column: "birth_country", where: "birth_country = ?"},
+	"birth_country_not": {column: "birth_country", where: "birth_country <> ?"},
+	"birth_state":       {column: "birth_state", where: "birth_country = 'USA' AND birth_state = ?"},
+	"draft_round":       {column: "draft_round", where: "draft_round = ?"},
+	"draft_pick_max":    {column: "draft_pick", where: "draft_pick <= ?"},
+}
+
+// Evaluator turns criteria definitions into the set of players who
+// satisfy them, reading mlb_player_seasons, mlb_player_awards and the
+// biography columns on mlb_players.
+type Evaluator struct {
+	q Querier
+}
+
+func NewEvaluator(q Querier) *Evaluator {
+	return &Evaluator{q: q}
+}
+
+// Evaluate returns the mlb_ids of every player who satisfies def, in
+// ascending order.
+func (e *Evaluator) Evaluate(def Definition) ([]int, error) {
+	switch def.Type {
+	case "team":
+		if def.MlbTeamID == nil {
+			return nil, fmt.Errorf("team criteria %d has no mlb_team_id", def.ID)
+		}
+		return e.seasonQuery(`
+			SELECT DISTINCT mlb_id FROM mlb_player_seasons
+			WHERE mlb_team_id = ?
+			ORDER BY mlb_id
+		`, *def.MlbTeamID)
+
+	case "era":
+		where, args := yearRange(def)
+		if def.MlbTeamID != nil {
+			where = append(where, "mlb_team_id = ?")
+			args = append(args, *def.MlbTeamID)
+		}
+		return e.seasonQuery(`
+			SELECT DISTINCT mlb_id FROM mlb_player_seasons
+			WHERE `+strings.Join(where, " AND ")+`
+			ORDER BY mlb_id
+		`, args...)
+
+	case "stat":
+		expr, err := lookupStat(def)
+		if err != nil {
+			return nil, err
+		}
+		having := []string{expr.sum + comparison(expr)}
+		if expr.careerMin != "" {
+			having = append(having, expr.careerMin)
+		}
+		return e.seasonQuery(`
+			SELECT mlb_id FROM mlb_player_seasons
+			GROUP BY mlb_id
+			HAVING `+strings.Join(having, " AND ")+`
+			ORDER BY mlb_id
+		`, *def.StatValue)
+
+	case "season":
+		expr, err := lookupStat(def)
+		if err != nil {
+			return nil, err
+		}
+		where, args := yearRange(def)
+		having := []string{expr.sum + comparison(expr)}
+		if expr.seasonMin != "" {
+			having = append(having, expr.seasonMin)
+		}
+		// A season spread over two teams after a trade still counts as
+		// one season, so group by season rather than by stint
+		return e.seasonQuery(`
+			SELECT DISTINCT mlb_id FROM (
+				SELECT mlb_id FROM mlb_player_seasons
+				WHERE `+strings.Join(where, " AND ")+`
+				GROUP BY mlb_id, season
+				HAVING `+strings.Join(having, " AND ")+`
+			) qualifying
+			ORDER BY mlb_id
+		`, append(args, *def.StatValue)...)
+
+	case "teammate":
+		if def.AnchorID == nil {
+			return nil, fmt.Errorf("teammate criteria %d has no anchor_mlb_id", def.ID)
+		}
+		return e.seasonQuery(`
+			SELECT DISTINCT mate.mlb_id
+			FROM mlb_player_seasons anchor
+			JOIN mlb_player_seasons mate
+			  ON mate.mlb_team_id = anchor.mlb_team_id
+			 AND mate.season = anchor.season
+			 AND mate.mlb_id <> anchor.mlb_id
+			WHERE anchor.mlb_id = ?
+			ORDER BY mate.mlb_id
+		`, *def.AnchorID)
+
+	case "position", "bio":
+		if def.BioField == nil || def.BioValue == nil {
+			return nil, fmt.Errorf("%s criteria %d has no bio_field/bio_value", def.Type, def.ID)
+		}
+		field, ok := bioFields[*def.BioField]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown bio_field %q", ErrUnsupportedType, *def.BioField)
+		}
+		where := []string{field.where}
+		if def.StatGroup != nil {
+			// "Left-Handed Pitcher" shouldn't match a lefty outfielder
+			if *def.StatGroup == "pitching" {
+				where = append(where, "position IN ('P', 'TWP')")
+			} else {
+				where = append(where, "position <> 'P'")
+			}
+		}
+		return e.bioQuery(field.column, `
+			SELECT mlb_id FROM mlb_players
+			WHERE `+strings.Join(where, " AND ")+`
+			ORDER BY mlb_id
+		`, *def.BioValue)
+
+	case "award":
+		if def.AwardID == nil {
+			return nil, fmt.Errorf("award criteria %d has no award_id", def.ID)
+		}
+		return e.awardQuery(`
+			SELECT DISTINCT mlb_id FROM mlb_player_awards
+			WHERE award_id = ?
+			ORDER BY mlb_id
+		`, *def.AwardID)
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedType, def.Type)
+}
+
+// Apply makes def's player_criteria rows match ids exactly. It returns
+// how many links were added and removed.
+func (e *Evaluator) Apply(def Definition, ids []int) (added, removed int, err error) {
+	return e.apply(def, ids, true)
+}
+
+// Extend links def to every player in ids who isn't linked yet and
+// leaves the other links alone, for when ids may be missing players
+// because their data isn't loaded. It returns how many links were added.
+func (e *Evaluator) Extend(def Definition, ids []int) (added int, err error) {
+	added, _, err = e.apply(def, ids, false)
+	return added, err
+}
+
+func (e *Evaluator) apply(def Definition, ids []int, prune bool) (added, removed int, err error) {
+	existing, err := e.ids(`SELECT mlb_id FROM player_criteria WHERE criteria_id = ?`, def.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	link, unlink := Diff(existing, ids)
+	if prune {
+		for _, id := range unlink {
+			if _, err := e.q.Exec(`DELETE FROM player_criteria WHERE criteria_id = ? AND mlb_id = ?`, def.ID, id); err != nil {
+				return added, removed, fmt.Errorf("failed to unlink player %d from criteria %d: %w", id, def.ID, err)
+			}
+			removed++
+		}
+	}
+	for _, id := range link {
+		if _, err := e.q.Exec(`INSERT IGNORE INTO player_criteria (mlb_id, criteria_id) VALUES (?, ?)`, id, def.ID); err != nil {
+			return added, removed, fmt.Errorf("failed to link player %d to criteria %d: %w", id, def.ID, err)
+		}
+		added++
+	}
+	return added, removed, nil
+}
+
+// Diff compares the players linked to a criteria with the ones who
+// should be: link are in want but not have, unlink in have but not want.
+// Both keep the order of their input.
+func Diff(have, want []int) (link, unlink []int) {
+	wanted := make(map[int]bool, len(want))
+	for _, id := range want {
+		wanted[id] = true
+	}
+	had := make(map[int]bool, len(have))
+	for _, id := range have {
+		had[id] = true
+		if !wanted[id] {
+			unlink = append(unlink, id)
+		}
+	}
+	for _, id := range want {
+		if !had[id] {
+			link = append(link, id)
+			had[id] = true
+		}
+	}
+	return link, unlink
+}
+
+// seasonQuery runs an ID query against mlb_player_seasons, refusing to
+// answer when no season data has been loaded at all.
+func (e *Evaluator) seasonQuery(query string, args ...interface{}) ([]int, error) {
+	var found int
+	if err := e.q.QueryRow(`SELECT COUNT(*) FROM (SELECT 1 FROM mlb_player_seasons LIMIT 1) s`).Scan(&found); err != nil {
+		return nil, fmt.Errorf("failed to check season data: %w", err)
+	}
+	if found == 0 {
+		return nil, ErrNoSeasonData
+	}
+	return e.ids(query, args...)
+}
+
+// bioQuery is seasonQuery for biography criteria: it refuses to answer
+// until column has been filled for at least one player.
+func (e *Evaluator) bioQuery(column, query string, args ...interface{}) ([]int, error) {
+	var found int
+	if err := e.q.QueryRow(`SELECT COUNT(*) FROM (SELECT 1 FROM mlb_players WHERE ` + column + ` IS NOT NULL LIMIT 1) p`).Scan(&found); err != nil {
+		return nil, fmt.Errorf("failed to check %s data: %w", column, err)
+	}
+	if found == 0 {
+		return nil, ErrNoBioData
+	}
+	return e.ids(query, args...)
+}
+
+// awardQuery is seasonQuery for award criteria, whose links may have
+// come from populate.py before mlb_player_awards existed.
+func (e *Evaluator) awardQuery(query string, args ...interface{}) ([]int, error) {
+	var found int
+	if err := e.q.QueryRow(`SELECT COUNT(*) FROM (SELECT 1 FROM mlb_player_awards LIMIT 1) a`).Scan(&found); err != nil {
+		return nil, fmt.Errorf("failed to check award data: %w", err)
+	}
+	if found == 0 {
+		return nil, ErrNoAwardData
+	}
+	return e.ids(query, args...)
+}
+
+func (e *Evaluator) ids(query string, args ...interface{}) ([]int, error) {
+	rows, err := e.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("criteria query failed: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan mlb_id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func lookupStat(def Definition) (statExpr, error) {
+	if def.StatField == nil || def.StatValue == nil {
+		return statExpr{}, fmt.Errorf("%s criteria %d has no stat_field/stat_value", def.Type, def.ID)
+	}
+	expr, ok := statExprs[*def.StatField]
+	if !ok {
+		return statExpr{}, fmt.Errorf("%w: unknown stat_field %q", ErrUnsupportedType, *def.StatField)
+	}
+	return expr, nil
+}
+
+func comparison(expr statExpr) string {
+	if expr.lowerIsBetter {
+		return " <= ?"
+	}
+	return " >= ?"
+}
+
+// yearRange turns start_year/end_year into WHERE clauses on season.
+// Always returns at least one clause so callers can join with AND.
+func yearRange(def Definition) ([]string, []interface{}) {
+	where := []string{"1 = 1"}
+	var args []interface{}
+	if def.StartYear != nil {
+		where = append(where, "season >= ?")
+		args = append(args, *def.StartYear)
+	}
+	if def.EndYear != nil {
+		where = append(where, "season <= ?")
+		args = append(args, *def.EndYear)
+	}
+	return where, args
+}
@@ -0,0 +1,26 @@
+package criteria
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		have, want   []int
+		link, unlink []int
+	}{
+		{"empty", nil, nil, nil, nil},
+		{"all new", nil, []int{3, 1}, []int{3, 1}, nil},
+		{"all gone", []int{1, 2}, nil, nil, []int{1, 2}},
+		{"unchanged", []int{1, 2}, []int{2, 1}, nil, nil},
+		{"both", []int{1, 2, 3}, []int{2, 4, 4}, []int{4}, []int{1, 3}},
+	}
+	for _, tt := range tests {
+		link, unlink := Diff(tt.have, tt.want)
+		if !reflect.DeepEqual(link, tt.link) || !reflect.DeepEqual(unlink, tt.unlink) {
+			t.Errorf("%s: Diff(%v, %v) = %v, %v; want %v, %v", tt.name, tt.have, tt.want, link, unlink, tt.link, tt.unlink)
+		}
+	}
+}
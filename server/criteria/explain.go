@@ -0,0 +1,228 @@
+package criteria
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Explanation is the evidence that a player satisfies one criteria —
+// what "why is this correct?" shows after a cell is taken.
+type Explanation struct {
+	CriteriaID int    `json:"criteria_id"`
+	Label      string `json:"label"`
+	Type       string `json:"type"`
+	Satisfied  bool   `json:"satisfied"` // the player is linked in player_criteria
+	Summary    string `json:"summary"`   // one human-readable line
+
+	Seasons    []int    `json:"seasons,omitempty"`     // team, era, season and teammate criteria
+	Value      *float64 `json:"value,omitempty"`       // career total for stat criteria
+	Threshold  *float64 `json:"threshold,omitempty"`   // stat and season criteria
+	AwardYears []int    `json:"award_years,omitempty"` // 0 = no season (Hall of Fame)
+	BioValue   string   `json:"bio_value,omitempty"`   // position and bio criteria
+}
+
+// ErrPlayerNotFound is returned when there is no player to explain.
+var ErrPlayerNotFound = errors.New("player not found")
+
+// Explain gathers the evidence for mlbID against criteria criteriaID.
+// Links that came from somewhere other than the data the evaluator reads
+// (populate.py rosters, say) are still Satisfied, with a summary saying
+// the details aren't loaded.
+func Explain(q Querier, mlbID, criteriaID int) (*Explanation, error) {
+	def, err := LoadDefinition(q, criteriaID)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists int
+	err = q.QueryRow(`SELECT 1 FROM mlb_players WHERE mlb_id = ?`, mlbID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrPlayerNotFound, mlbID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up player %d: %w", mlbID, err)
+	}
+
+	ex := &Explanation{CriteriaID: def.ID, Label: def.Label, Type: def.Type}
+	var linked int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM player_criteria WHERE mlb_id = ? AND criteria_id = ?`, mlbID, def.ID).Scan(&linked); err != nil {
+		return nil, fmt.Errorf("failed to check link for player %d: %w", mlbID, err)
+	}
+	ex.Satisfied = linked > 0
+
+	e := NewEvaluator(q)
+	switch def.Type {
+	case "team", "era":
+		where, args := yearRange(*def)
+		if def.MlbTeamID != nil {
+			where = append(where, "mlb_team_id = ?")
+			args = append(args, *def.MlbTeamID)
+		}
+		if ex.Seasons, err = e.ids(`
+			SELECT DISTINCT season FROM mlb_player_seasons
+			WHERE mlb_id = ? AND `+strings.Join(where, " AND ")+`
+			ORDER BY season
+		`, append([]interface{}{mlbID}, args...)...); err != nil {
+			return nil, err
+		}
+		ex.Summary = seasonsSummary("Played", ex.Seasons)
+
+	case "stat":
+		expr, err := lookupStat(*def)
+		if err != nil {
+			return nil, err
+		}
+		var value *float64
+		if err := q.QueryRow(`SELECT `+expr.sum+` FROM mlb_player_seasons WHERE mlb_id = ?`, mlbID).Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to total %s for player %d: %w", *def.StatField, mlbID, err)
+		}
+		ex.Value, ex.Threshold = value, def.StatValue
+		if value != nil {
+			ex.Summary = fmt.Sprintf("Career total: %s (needs %s)",
+				formatStat(*def.StatField, *value), thresholdText(*def, expr))
+		}
+
+	case "season":
+		expr, err := lookupStat(*def)
+		if err != nil {
+			return nil, err
+		}
+		where, args := yearRange(*def)
+		having := []string{expr.sum + comparison(expr)}
+		if expr.seasonMin != "" {
+			having = append(having, expr.seasonMin)
+		}
+		args = append([]interface{}{mlbID}, args...)
+		if ex.Seasons, err = e.ids(`
+			SELECT season FROM mlb_player_seasons
+			WHERE mlb_id = ? AND `+strings.Join(where, " AND ")+`
+			GROUP BY season
+			HAVING `+strings.Join(having, " AND ")+`
+			ORDER BY season
+		`, append(args, *def.StatValue)...); err != nil {
+			return nil, err
+		}
+		ex.Threshold = def.StatValue
+		ex.Summary = yearsSummary(def.Label, ex.Seasons)
+
+	case "teammate":
+		if def.AnchorID == nil {
+			return nil, fmt.Errorf("teammate criteria %d has no anchor_mlb_id", def.ID)
+		}
+		if ex.Seasons, err = e.ids(`
+			SELECT DISTINCT mate.season
+			FROM mlb_player_seasons anchor
+			JOIN mlb_player_seasons mate
+			  ON mate.mlb_team_id = anchor.mlb_team_id AND mate.season = anchor.season
+			WHERE anchor.mlb_id = ? AND mate.mlb_id = ?
+			ORDER BY mate.season
+		`, *def.AnchorID, mlbID); err != nil {
+			return nil, err
+		}
+		ex.Summary = seasonsSummary("Teammates", ex.Seasons)
+
+	case "award":
+		if def.AwardID == nil {
+			return nil, fmt.Errorf("award criteria %d has no award_id", def.ID)
+		}
+		if ex.AwardYears, err = e.ids(`
+			SELECT season FROM mlb_player_awards
+			WHERE mlb_id = ? AND award_id = ?
+			ORDER BY season
+		`, mlbID, *def.AwardID); err != nil {
+			return nil, err
+		}
+		ex.Summary = yearsSummary(def.Label, ex.AwardYears)
+
+	case "position", "bio":
+		field, ok := bioFields[derefString(def.BioField)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown bio_field %q", ErrUnsupportedType, derefString(def.BioField))
+		}
+		var value *string
+		if err := q.QueryRow(`SELECT `+field.column+` FROM mlb_players WHERE mlb_id = ?`, mlbID).Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to load %s for player %d: %w", field.column, mlbID, err)
+		}
+		if value != nil {
+			ex.BioValue = *value
+			ex.Summary = fmt.Sprintf("%s: %s", strings.ReplaceAll(field.column, "_", " "), *value)
+		}
+	}
+
+	if ex.Summary == "" {
+		if ex.Satisfied {
+			ex.Summary = "Linked to " + def.Label + " (details not loaded)"
+		} else {
+			ex.Summary = "Doesn't satisfy " + def.Label
+		}
+	}
+	return ex, nil
+}
+
+// seasonsSummary renders "Played 7 seasons: 2009–2015" or "... in 2004,
+// 2006", or "" when there are none.
+func seasonsSummary(prefix string, seasons []int) string {
+	switch len(seasons) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("%s in %d", prefix, seasons[0])
+	}
+	first, last := seasons[0], seasons[len(seasons)-1]
+	if last-first+1 == len(seasons) {
+		return fmt.Sprintf("%s %d seasons: %d–%d", prefix, len(seasons), first, last)
+	}
+	years := make([]string, len(seasons))
+	for i, s := range seasons {
+		years[i] = strconv.Itoa(s)
+	}
+	return fmt.Sprintf("%s %d seasons: %s", prefix, len(seasons), strings.Join(years, ", "))
+}
+
+// yearsSummary renders "MVP: 1991, 1993" or "40+ HR Season: 2001", or
+// just the label for an award with no season (Hall of Fame).
+func yearsSummary(label string, years []int) string {
+	if len(years) == 0 {
+		return ""
+	}
+	if len(years) == 1 && years[0] == 0 {
+		return label
+	}
+	parts := make([]string, 0, len(years))
+	for _, y := range years {
+		if y > 0 {
+			parts = append(parts, strconv.Itoa(y))
+		}
+	}
+	return fmt.Sprintf("%s: %s", label, strings.Join(parts, ", "))
+}
+
+// thresholdText renders "500+" or "≤ 3.00".
+func thresholdText(def Definition, expr statExpr) string {
+	v := formatStat(*def.StatField, *def.StatValue)
+	if expr.lowerIsBetter {
+		return "≤ " + v
+	}
+	return v + "+"
+}
+
+// formatStat prints rate stats the way a box score does.
+func formatStat(field string, v float64) string {
+	switch field {
+	case "avg", "obp":
+		return strings.TrimPrefix(strconv.FormatFloat(v, 'f', 3, 64), "0")
+	case "era":
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,128 @@
+package criteria
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"trivia-server/internal/fakesql"
+)
+
+// positionCriteria is the criteria row for "Played SS".
+var positionCriteria = fakesql.Result{
+	Columns: []string{"id", "type", "label", "mlb_team_id", "stat_field", "stat_value", "stat_group",
+		"award_id", "start_year", "end_year", "anchor_mlb_id", "bio_field", "bio_value"},
+	Rows: [][]driver.Value{{
+		int64(7), "position", "Played SS", nil, nil, nil, nil,
+		nil, nil, nil, nil, "position", "SS",
+	}},
+}
+
+// playerExists answers the check that the player is known.
+var playerExists = fakesql.Result{Columns: []string{"1"}, Rows: [][]driver.Value{{int64(1)}}}
+
+// seasonCriteria is the criteria row for "40+ HR Season".
+var seasonCriteria = fakesql.Result{
+	Columns: positionCriteria.Columns,
+	Rows: [][]driver.Value{{
+		int64(8), "season", "40+ HR Season", nil, "homeRuns", 40.0, "hitting",
+		nil, nil, nil, nil, nil, nil,
+	}},
+}
+
+// teamCriteria is the criteria row for "Yankees".
+var teamCriteria = fakesql.Result{
+	Columns: positionCriteria.Columns,
+	Rows: [][]driver.Value{{
+		int64(9), "team", "Yankees", int64(147), nil, nil, nil,
+		nil, nil, nil, nil, nil, nil,
+	}},
+}
+
+func TestExplainBio(t *testing.T) {
+	db, _ := fakesql.Open(t,
+		"FROM criteria", positionCriteria,
+		"SELECT 1 FROM mlb_players", playerExists,
+		"FROM player_criteria", fakesql.Result{Columns: []string{"n"}, Rows: [][]driver.Value{{int64(1)}}},
+		"FROM mlb_players", fakesql.Result{Columns: []string{"position"}, Rows: [][]driver.Value{{"SS"}}},
+	)
+
+	ex, err := Explain(db, 121222, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ex.Satisfied || ex.BioValue != "SS" || ex.Summary != "position: SS" {
+		t.Errorf("Explain = %+v, want a satisfied position: SS", ex)
+	}
+}
+
+func TestExplainSeason(t *testing.T) {
+	tests := []struct {
+		seasons []driver.Value
+		want    string
+	}{
+		{[]driver.Value{int64(2001)}, "40+ HR Season: 2001"},
+		{[]driver.Value{int64(1998), int64(1999), int64(2001)}, "40+ HR Season: 1998, 1999, 2001"},
+	}
+	for _, tt := range tests {
+		rows := make([][]driver.Value, len(tt.seasons))
+		for i, season := range tt.seasons {
+			rows[i] = []driver.Value{season}
+		}
+		db, _ := fakesql.Open(t,
+			"FROM criteria", seasonCriteria,
+			"SELECT 1 FROM mlb_players", playerExists,
+			"FROM player_criteria", fakesql.Result{Columns: []string{"n"}, Rows: [][]driver.Value{{int64(1)}}},
+			"FROM mlb_player_seasons", fakesql.Result{Columns: []string{"season"}, Rows: rows},
+		)
+
+		ex, err := Explain(db, 115135, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ex.Summary != tt.want || len(ex.Seasons) != len(tt.seasons) {
+			t.Errorf("Explain = %q over %v, want %q", ex.Summary, ex.Seasons, tt.want)
+		}
+	}
+}
+
+func TestExplainUnknownPlayer(t *testing.T) {
+	// Every type, not just the ones that read mlb_players
+	for _, def := range []fakesql.Result{positionCriteria, teamCriteria, seasonCriteria} {
+		db, _ := fakesql.Open(t,
+			"FROM criteria", def,
+			"FROM player_criteria", fakesql.Result{Columns: []string{"n"}, Rows: [][]driver.Value{{int64(0)}}},
+		)
+
+		_, err := Explain(db, 999999, int(def.Rows[0][0].(int64)))
+		if !errors.Is(err, ErrPlayerNotFound) {
+			t.Errorf("Explain of an unknown player against %s = %v, want ErrPlayerNotFound", def.Rows[0][2], err)
+		}
+	}
+}
+
+func TestExplainUnknownCriteria(t *testing.T) {
+	db, _ := fakesql.Open(t)
+
+	_, err := Explain(db, 121222, 7)
+	if !errors.Is(err, sql.ErrNoRows) || errors.Is(err, ErrPlayerNotFound) {
+		t.Errorf("Explain of an unknown criteria = %v, want criteria not found", err)
+	}
+}
+
+func TestSeasonsSummary(t *testing.T) {
+	tests := []struct {
+		seasons []int
+		want    string
+	}{
+		{nil, ""},
+		{[]int{2004}, "Played in 2004"},
+		{[]int{2009, 2010, 2011}, "Played 3 seasons: 2009–2011"},
+		{[]int{2004, 2006}, "Played 2 seasons: 2004, 2006"},
+	}
+	for _, tt := range tests {
+		if got := seasonsSummary("Played", tt.seasons); got != tt.want {
+			t.Errorf("seasonsSummary(%v) = %q, want %q", tt.seasons, got, tt.want)
+		}
+	}
+}
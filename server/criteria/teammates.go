@@ -0,0 +1,145 @@
+package criteria
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Limits that keep teammate anchors to players people have heard of, and
+// keep their criteria big enough to cross with a team or stat header.
+const (
+	minAnchorSeasons   = 10  // distinct seasons in mlb_player_seasons
+	minAnchorAccolades = 3   // rows in mlb_player_awards (All-Star selections count)
+	minAnchorTeammates = 150 // distinct teammates across the whole career
+)
+
+// TeammateAnchor is a player chosen to head a "Teammate of X" criteria.
+type TeammateAnchor struct {
+	MlbID     int
+	FullName  string
+	Seasons   int
+	Accolades int
+	Teammates int
+}
+
+// SelectTeammateAnchors picks up to limit well-known players to anchor
+// teammate criteria, most decorated first. A player qualifies with enough
+// seasons, enough award rows and a large enough teammate pool.
+func SelectTeammateAnchors(q Querier, limit int) ([]TeammateAnchor, error) {
+	rows, err := q.Query(`
+		SELECT p.mlb_id, p.full_name, s.seasons, a.accolades
+		FROM mlb_players p
+		JOIN (
+			SELECT mlb_id, COUNT(DISTINCT season) AS seasons
+			FROM mlb_player_seasons GROUP BY mlb_id
+		) s ON s.mlb_id = p.mlb_id
+		JOIN (
+			SELECT mlb_id, COUNT(*) AS accolades
+			FROM mlb_player_awards GROUP BY mlb_id
+		) a ON a.mlb_id = p.mlb_id
+		WHERE s.seasons >= ? AND a.accolades >= ?
+		ORDER BY a.accolades DESC, s.seasons DESC, p.mlb_id
+	`, minAnchorSeasons, minAnchorAccolades)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load anchor candidates: %w", err)
+	}
+	var candidates []TeammateAnchor
+	for rows.Next() {
+		var a TeammateAnchor
+		if err := rows.Scan(&a.MlbID, &a.FullName, &a.Seasons, &a.Accolades); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan anchor candidate: %w", err)
+		}
+		candidates = append(candidates, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Counted one candidate at a time, after the candidate rows are
+	// closed, since a transaction can't run a query while another's
+	// rows are still open
+	var anchors []TeammateAnchor
+	for _, a := range candidates {
+		if len(anchors) == limit {
+			break
+		}
+		if a.Teammates, err = countTeammates(q, a.MlbID); err != nil {
+			return nil, err
+		}
+		if a.Teammates >= minAnchorTeammates {
+			anchors = append(anchors, a)
+		}
+	}
+	return anchors, nil
+}
+
+// countTeammates is the number of distinct players who shared a roster
+// with mlbID, the same self-join the evaluator links teammates with.
+func countTeammates(q Querier, mlbID int) (int, error) {
+	var n int
+	err := q.QueryRow(`
+		SELECT COUNT(DISTINCT mate.mlb_id)
+		FROM mlb_player_seasons anchor
+		JOIN mlb_player_seasons mate
+		  ON mate.mlb_team_id = anchor.mlb_team_id
+		 AND mate.season = anchor.season
+		 AND mate.mlb_id <> anchor.mlb_id
+		WHERE anchor.mlb_id = ?
+	`, mlbID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count teammates of %d: %w", mlbID, err)
+	}
+	return n, nil
+}
+
+// UpsertTeammateCriteria creates (or renames) the teammate criteria for
+// an anchor and returns its definition.
+func UpsertTeammateCriteria(q Querier, a TeammateAnchor) (*Definition, error) {
+	label := "Teammate of " + a.FullName
+	short := "w/ " + lastName(a.FullName)
+	if len(short) > 50 {
+		short = short[:50]
+	}
+
+	var id int
+	err := q.QueryRow(`SELECT id FROM criteria WHERE type = 'teammate' AND anchor_mlb_id = ?`, a.MlbID).Scan(&id)
+	switch {
+	case err == nil:
+		if _, err := q.Exec(`UPDATE criteria SET label = ?, short_label = ? WHERE id = ?`, label, short, id); err != nil {
+			return nil, fmt.Errorf("failed to update teammate criteria %d: %w", id, err)
+		}
+		return LoadDefinition(q, id)
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to look up teammate criteria for %d: %w", a.MlbID, err)
+	}
+
+	res, err := q.Exec(`
+		INSERT INTO criteria (type, label, short_label, description, anchor_mlb_id)
+		VALUES ('teammate', ?, ?, ?, ?)
+	`, label, short, fmt.Sprintf("Played on the same team in the same season as %s", a.FullName), a.MlbID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create teammate criteria for %d: %w", a.MlbID, err)
+	}
+	newID, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return LoadDefinition(q, int(newID))
+}
+
+func lastName(fullName string) string {
+	parts := strings.Fields(fullName)
+	if len(parts) == 0 {
+		return fullName
+	}
+	last := parts[len(parts)-1]
+	// "Ken Griffey Jr." should read "w/ Griffey Jr."
+	if len(parts) > 2 && (last == "Jr." || last == "Sr." || last == "II" || last == "III") {
+		return parts[len(parts)-2] + " " + last
+	}
+	return last
+}
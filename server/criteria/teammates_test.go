@@ -0,0 +1,99 @@
+package criteria
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"trivia-server/internal/fakesql"
+)
+
+var anchorCandidates = fakesql.Result{
+	Columns: []string{"mlb_id", "full_name", "seasons", "accolades"},
+	Rows: [][]driver.Value{
+		{int64(121578), "Derek Jeter", int64(20), int64(30)},
+		{int64(110849), "Ken Griffey Jr.", int64(22), int64(25)},
+		{int64(121222), "Cal Ripken Jr.", int64(21), int64(24)},
+	},
+}
+
+func teammateCount(n int64) fakesql.Result {
+	return fakesql.Result{Columns: []string{"n"}, Rows: [][]driver.Value{{n}}}
+}
+
+func TestSelectTeammateAnchors(t *testing.T) {
+	db, _ := fakesql.Open(t,
+		"COUNT(DISTINCT mate.mlb_id)", teammateCount(minAnchorTeammates),
+		"FROM mlb_players p", anchorCandidates,
+	)
+
+	anchors, err := SelectTeammateAnchors(db, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anchors) != 2 || anchors[0].FullName != "Derek Jeter" || anchors[1].FullName != "Ken Griffey Jr." {
+		t.Fatalf("anchors = %+v, want the two most decorated", anchors)
+	}
+	if anchors[0].Teammates != minAnchorTeammates || anchors[0].Seasons != 20 || anchors[0].Accolades != 30 {
+		t.Errorf("anchor = %+v", anchors[0])
+	}
+}
+
+func TestSelectTeammateAnchorsSmallPool(t *testing.T) {
+	db, _ := fakesql.Open(t,
+		"COUNT(DISTINCT mate.mlb_id)", teammateCount(minAnchorTeammates-1),
+		"FROM mlb_players p", anchorCandidates,
+	)
+
+	anchors, err := SelectTeammateAnchors(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anchors) != 0 {
+		t.Errorf("anchors = %+v, want none with too few teammates", anchors)
+	}
+}
+
+// A failed lookup is returned, not taken as "no criteria yet".
+func TestUpsertTeammateCriteriaLookupError(t *testing.T) {
+	lost := errors.New("connection lost")
+	db, fake := fakesql.Open(t,
+		"type = 'teammate' AND anchor_mlb_id", fakesql.Result{Err: lost},
+	)
+
+	_, err := UpsertTeammateCriteria(db, TeammateAnchor{MlbID: 121578, FullName: "Derek Jeter"})
+	if !errors.Is(err, lost) {
+		t.Errorf("UpsertTeammateCriteria = %v, want the lookup error", err)
+	}
+	if execs := fake.Executed(""); len(execs) != 0 {
+		t.Errorf("a failed lookup went on to write %v", execs)
+	}
+}
+
+func TestUpsertTeammateCriteriaRenames(t *testing.T) {
+	db, fake := fakesql.Open(t,
+		"type = 'teammate' AND anchor_mlb_id", fakesql.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(42)}}},
+	)
+
+	// The definition isn't scripted, so only the writes are checked
+	UpsertTeammateCriteria(db, TeammateAnchor{MlbID: 121578, FullName: "Derek Jeter"})
+	if got := fake.Executed("UPDATE criteria"); len(got) != 1 {
+		t.Errorf("updates = %v, want one rename", got)
+	}
+	if got := fake.Executed("INSERT"); len(got) != 0 {
+		t.Errorf("an existing criteria was inserted again: %v", got)
+	}
+}
+
+func TestLastName(t *testing.T) {
+	tests := map[string]string{
+		"Derek Jeter":     "Jeter",
+		"Ken Griffey Jr.": "Griffey Jr.",
+		"Ichiro":          "Ichiro",
+		"":                "",
+	}
+	for in, want := range tests {
+		if got := lastName(in); got != want {
+			t.Errorf("lastName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+-- migrations/003_admin_grid_curation.sql
+
+-- Admin flag for the grid curation endpoints
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN DEFAULT FALSE;
+
+-- Where a template came from: built by the generator, or hand-picked by an admin
+ALTER TABLE grid_templates ADD COLUMN source ENUM('generated', 'curated') DEFAULT 'generated';
+
+-- Templates pinned to a specific date (daily puzzle)
+CREATE TABLE IF NOT EXISTS grid_schedule (
+    scheduled_date   DATE PRIMARY KEY,
+    grid_template_id INT NOT NULL,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (grid_template_id) REFERENCES grid_templates(id)
+);
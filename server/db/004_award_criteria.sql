@@ -0,0 +1,30 @@
+-- migrations/004_award_criteria.sql
+
+-- Award wins per player and season, loaded by `server import-awards`.
+-- player_criteria only says "won it"; the seasons are kept here.
+CREATE TABLE IF NOT EXISTS mlb_player_awards (
+    mlb_id   INT NOT NULL,
+    award_id VARCHAR(50) NOT NULL,     -- matches criteria.award_id
+    season   INT NOT NULL DEFAULT 0,   -- 0 when the award has no season (Hall of Fame)
+    PRIMARY KEY (mlb_id, award_id, season),
+    FOREIGN KEY (mlb_id) REFERENCES mlb_players(mlb_id),
+    INDEX idx_award_season (award_id, season)
+);
+
+-- Award criteria, same rows populate.py inserts
+INSERT IGNORE INTO criteria (type, label, short_label, award_id) VALUES
+('award', 'Hall of Fame',          'Hall of Fame',      'MLBHOF'),
+('award', 'World Series Champion', 'WS Champion',       'WSCHAMP'),
+('award', 'World Series MVP',      'WS MVP',            'WSMVP'),
+('award', 'AL MVP',                'AL MVP',            'ALMVP'),
+('award', 'NL MVP',                'NL MVP',            'NLMVP'),
+('award', 'AL Cy Young',           'AL Cy Young',       'ALCY'),
+('award', 'NL Cy Young',           'NL Cy Young',       'NLCY'),
+('award', 'AL Rookie of the Year', 'AL Rookie of Year', 'ALROY'),
+('award', 'NL Rookie of the Year', 'NL Rookie of Year', 'NLROY'),
+('award', 'AL All-Star',           'AL All-Star',       'ALAS'),
+('award', 'NL All-Star',           'NL All-Star',       'NLAS'),
+('award', 'AL Gold Glove',         'AL Gold Glove',     'ALGG'),
+('award', 'NL Gold Glove',         'NL Gold Glove',     'NLGG'),
+('award', 'AL Silver Slugger',     'AL Silver Slugger', 'ALSS'),
+('award', 'NL Silver Slugger',     'NL Silver Slugger', 'NLSS');
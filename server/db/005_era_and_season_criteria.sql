@@ -0,0 +1,67 @@
+-- migrations/005_era_and_season_criteria.sql
+
+-- New criteria kinds:
+--   era    — played in a year range, optionally for one team
+--            ("Played in the 1990s", "Yankees before 2000")
+--   season — a single-season stat threshold ("40+ HR Season")
+ALTER TABLE criteria MODIFY COLUMN type ENUM('team', 'stat', 'award', 'position', 'era', 'season') NOT NULL;
+ALTER TABLE criteria ADD COLUMN start_year INT DEFAULT NULL; -- inclusive, NULL = open
+ALTER TABLE criteria ADD COLUMN end_year   INT DEFAULT NULL; -- inclusive, NULL = open
+
+-- Season-by-season lines keyed by MLB id, one row per player/season/team
+-- stint. Same shape as the legacy player_stats table plus the inputs
+-- needed for OBP and ERA.
+CREATE TABLE IF NOT EXISTS mlb_player_seasons (
+    mlb_id       INT NOT NULL,
+    season       INT NOT NULL,
+    mlb_team_id  INT NOT NULL,
+    games        INT DEFAULT 0,
+    at_bats      INT DEFAULT 0,
+    hits         INT DEFAULT 0,
+    doubles      INT DEFAULT 0,
+    triples      INT DEFAULT 0,
+    home_runs    INT DEFAULT 0,
+    rbi          INT DEFAULT 0,
+    stolen_bases INT DEFAULT 0,
+    walks        INT DEFAULT 0,
+    hit_by_pitch INT DEFAULT 0,
+    sac_flies    INT DEFAULT 0,
+    -- Pitching
+    wins         INT DEFAULT 0,
+    losses       INT DEFAULT 0,
+    saves        INT DEFAULT 0,
+    strikeouts   INT DEFAULT 0,
+    outs_pitched INT DEFAULT 0,        -- innings pitched * 3
+    earned_runs  INT DEFAULT 0,
+    PRIMARY KEY (mlb_id, season, mlb_team_id),
+    FOREIGN KEY (mlb_id) REFERENCES mlb_players(mlb_id),
+    INDEX idx_team_season (mlb_team_id, season),
+    INDEX idx_season (season)
+);
+
+-- Decades
+INSERT IGNORE INTO criteria (type, label, short_label, start_year, end_year) VALUES
+('era', 'Played in the 1970s', '1970s', 1970, 1979),
+('era', 'Played in the 1980s', '1980s', 1980, 1989),
+('era', 'Played in the 1990s', '1990s', 1990, 1999),
+('era', 'Played in the 2000s', '2000s', 2000, 2009),
+('era', 'Played in the 2010s', '2010s', 2010, 2019),
+('era', 'Played in the 2020s', '2020s', 2020, NULL);
+
+-- Single-season thresholds
+INSERT IGNORE INTO criteria (type, label, short_label, stat_field, stat_value, stat_group) VALUES
+('season', '40+ HR Season',       '40 HR Season',   'homeRuns',    40,   'hitting'),
+('season', '200+ Hit Season',     '200 H Season',   'hits',        200,  'hitting'),
+('season', '50+ SB Season',       '50 SB Season',   'stolenBases', 50,   'hitting'),
+('season', '.330+ AVG Season',    '.330 Season',    'avg',         0.330, 'hitting'),
+('season', '20+ Win Season',      '20 W Season',    'wins',        20,   'pitching'),
+('season', '40+ Save Season',     '40 SV Season',   'saves',       40,   'pitching'),
+('season', '250+ K Season',       '250 K Season',   'strikeOuts',  250,  'pitching'),
+('season', 'Sub-2.50 ERA Season', 'ERA < 2.50 Szn', 'era',         2.50, 'pitching');
+
+-- "<Team> before 2000" for every team criteria. Team criteria are loaded
+-- by populate.py, so rerun this statement after the first populate.
+INSERT IGNORE INTO criteria (type, label, short_label, mlb_team_id, end_year)
+SELECT 'era', CONCAT(label, ' before 2000'), CONCAT(short_label, ' pre-2000'), mlb_team_id, 1999
+FROM criteria
+WHERE type = 'team' AND mlb_team_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- migrations/006_teammate_criteria.sql
+
+-- "Teammate of X" criteria: players who shared a team and season with
+-- the anchor player. Rows are created by `server build-teammates`.
+ALTER TABLE criteria MODIFY COLUMN type ENUM('team', 'stat', 'award', 'position', 'era', 'season', 'teammate') NOT NULL;
+ALTER TABLE criteria ADD COLUMN anchor_mlb_id INT DEFAULT NULL;
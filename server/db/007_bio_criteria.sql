@@ -0,0 +1,36 @@
+-- migrations/007_bio_criteria.sql
+
+-- Biography columns on mlb_players, filled from the MLB /people endpoint
+-- (batSide, pitchHand, birthCountry, birthStateProvince, birthDate).
+ALTER TABLE mlb_players ADD COLUMN bats          CHAR(1)     DEFAULT NULL; -- L, R, S
+ALTER TABLE mlb_players ADD COLUMN throws        CHAR(1)     DEFAULT NULL; -- L, R
+ALTER TABLE mlb_players ADD COLUMN birth_country VARCHAR(50) DEFAULT NULL;
+ALTER TABLE mlb_players ADD COLUMN birth_state   VARCHAR(50) DEFAULT NULL;
+ALTER TABLE mlb_players ADD COLUMN birth_date    DATE        DEFAULT NULL;
+
+-- New criteria kind:
+--   bio — a biography match ("Left-Handed Pitcher", "Born in California")
+-- position criteria reuse the same two columns with bio_field = 'position'.
+-- stat_group on these rows means "only hitters" / "only pitchers", which
+-- the generator uses to keep contradictory headers apart.
+ALTER TABLE criteria MODIFY COLUMN type ENUM('team', 'stat', 'award', 'position', 'era', 'season', 'teammate', 'bio') NOT NULL;
+ALTER TABLE criteria ADD COLUMN bio_field VARCHAR(30) DEFAULT NULL; -- position, bats, throws, birth_country, birth_country_not, birth_state
+ALTER TABLE criteria ADD COLUMN bio_value VARCHAR(50) DEFAULT NULL;
+
+-- Primary positions (MLB abbreviations)
+INSERT IGNORE INTO criteria (type, label, short_label, bio_field, bio_value, stat_group) VALUES
+('position', 'Catcher',        'C',  'position', 'C',  'hitting'),
+('position', 'First Baseman',  '1B', 'position', '1B', 'hitting'),
+('position', 'Second Baseman', '2B', 'position', '2B', 'hitting'),
+('position', 'Third Baseman',  '3B', 'position', '3B', 'hitting'),
+('position', 'Shortstop',      'SS', 'position', 'SS', 'hitting'),
+('position', 'Center Fielder', 'CF', 'position', 'CF', 'hitting');
+
+-- Handedness and birthplace
+INSERT IGNORE INTO criteria (type, label, short_label, bio_field, bio_value, stat_group) VALUES
+('bio', 'Left-Handed Pitcher',          'LHP',         'throws',            'L',                  'pitching'),
+('bio', 'Switch Hitter',                'Switch',      'bats',              'S',                  NULL),
+('bio', 'Born Outside the USA',         'Intl Born',   'birth_country_not', 'USA',                NULL),
+('bio', 'Born in California',           'Born in CA',  'birth_state',       'CA',                 NULL),
+('bio', 'Born in the Dominican Republic', 'Born in DR', 'birth_country',    'Dominican Republic', NULL),
+('bio', 'Born in Venezuela',            'Born in VEN', 'birth_country',     'Venezuela',          NULL);
@@ -0,0 +1,8 @@
+-- migrations/008_grid_seeds.sql
+
+-- Generated grids remember the seed and criteria data version they were
+-- built from, so the same grid can be rebuilt for a bug report, a daily
+-- puzzle or a tournament round.
+ALTER TABLE grid_templates ADD COLUMN seed         BIGINT      DEFAULT NULL;
+ALTER TABLE grid_templates ADD COLUMN data_version VARCHAR(16) DEFAULT NULL;
+ALTER TABLE grid_templates ADD INDEX idx_seed (seed, data_version);
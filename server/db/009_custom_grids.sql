@@ -0,0 +1,5 @@
+-- migrations/009_custom_grids.sql
+
+-- Grids a room host builds from the criteria catalog. Saved inactive so
+-- they're only played in the room that made them.
+ALTER TABLE grid_templates MODIFY COLUMN source ENUM('generated', 'curated', 'custom') DEFAULT 'generated';
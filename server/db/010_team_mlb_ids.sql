@@ -0,0 +1,18 @@
+-- migrations/010_team_mlb_ids.sql
+
+-- Links teams to the MLB Stats API team IDs that criteria.mlb_team_id
+-- uses, so room filters can narrow team criteria by league and division.
+ALTER TABLE teams ADD COLUMN mlb_team_id INT DEFAULT NULL;
+ALTER TABLE teams ADD INDEX idx_mlb_team_id (mlb_team_id);
+
+UPDATE teams SET mlb_team_id = CASE abbreviation
+    WHEN 'LAA' THEN 108 WHEN 'ARI' THEN 109 WHEN 'BAL' THEN 110 WHEN 'BOS' THEN 111
+    WHEN 'CHC' THEN 112 WHEN 'CIN' THEN 113 WHEN 'CLE' THEN 114 WHEN 'COL' THEN 115
+    WHEN 'DET' THEN 116 WHEN 'HOU' THEN 117 WHEN 'KC'  THEN 118 WHEN 'LAD' THEN 119
+    WHEN 'WSH' THEN 120 WHEN 'NYM' THEN 121 WHEN 'OAK' THEN 133 WHEN 'PIT' THEN 134
+    WHEN 'SD'  THEN 135 WHEN 'SEA' THEN 136 WHEN 'SF'  THEN 137 WHEN 'STL' THEN 138
+    WHEN 'TB'  THEN 139 WHEN 'TEX' THEN 140 WHEN 'TOR' THEN 141 WHEN 'MIN' THEN 142
+    WHEN 'PHI' THEN 143 WHEN 'ATL' THEN 144 WHEN 'CWS' THEN 145 WHEN 'MIA' THEN 146
+    WHEN 'NYY' THEN 147 WHEN 'MIL' THEN 158
+END
+WHERE is_active = TRUE;
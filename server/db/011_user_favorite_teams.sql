@@ -0,0 +1,20 @@
+-- migrations/011_user_favorite_teams.sql
+
+-- Up to three ranked favorite teams per user (MLB Stats API team IDs).
+-- users.favorite_team_id/name are kept in step with rank 1 for older
+-- clients.
+CREATE TABLE IF NOT EXISTS user_favorite_teams (
+    user_id     INT NOT NULL,
+    team_rank   TINYINT NOT NULL,          -- 1 = favorite
+    mlb_team_id INT NOT NULL,
+    team_name   VARCHAR(100) NOT NULL,
+    PRIMARY KEY (user_id, team_rank),
+    UNIQUE KEY unique_user_team (user_id, mlb_team_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (team_rank BETWEEN 1 AND 3)
+);
+
+INSERT IGNORE INTO user_favorite_teams (user_id, team_rank, mlb_team_id, team_name)
+SELECT id, 1, favorite_team_id, COALESCE(favorite_team_name, '')
+FROM users
+WHERE favorite_team_id IS NOT NULL;
@@ -0,0 +1,13 @@
+-- migrations/012_lahman_crosswalk.sql
+
+-- Rows loaded by `server import-lahman` carry their Lahman playerID so
+-- reimports update in place, and the mlb_id they were matched to (via
+-- the Chadwick register or name + birth date) so their seasons and
+-- awards can feed the criteria evaluator.
+ALTER TABLE players ADD COLUMN lahman_id VARCHAR(10) DEFAULT NULL;
+ALTER TABLE players ADD COLUMN mlb_id INT DEFAULT NULL;
+ALTER TABLE players ADD UNIQUE KEY unique_lahman_id (lahman_id);
+ALTER TABLE players ADD INDEX idx_mlb_id (mlb_id);
+
+-- One row per Lahman award name and league
+ALTER TABLE awards ADD UNIQUE KEY unique_award_league (name, league);
@@ -0,0 +1,21 @@
+-- migrations/013_player_rarity.sql
+
+-- One rarity score per player, computed by `server rebuild-rarity` (see
+-- the rarity package for the formula). Generation copies it into
+-- cell_answers and validation reads it directly, so every grid agrees
+-- on how rare a player is. 0.0 = very rare, 1.0 = very common.
+CREATE TABLE IF NOT EXISTS player_rarity (
+    mlb_id               INT NOT NULL PRIMARY KEY,
+    rarity_score         FLOAT NOT NULL,
+    accomplishment_score INT NOT NULL DEFAULT 0,   -- weighted criteria, see rarity.Weight
+    criteria_count       INT NOT NULL DEFAULT 0,
+    use_count            INT NOT NULL DEFAULT 0,   -- answer_frequency at compute time
+    updated_at           TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (mlb_id) REFERENCES mlb_players(mlb_id),
+    INDEX idx_rarity (rarity_score)
+);
+
+-- Seed from the scores rebuild_cell_answers.py left in cell_answers so
+-- nothing reads the 0.5 default before the first rebuild
+INSERT IGNORE INTO player_rarity (mlb_id, rarity_score)
+SELECT mlb_id, MIN(rarity_score) FROM cell_answers GROUP BY mlb_id;
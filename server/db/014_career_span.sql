@@ -0,0 +1,9 @@
+-- migrations/014_career_span.sql
+
+-- First and last MLB season of each player, from the /people endpoint's
+-- mlbDebutDate and lastPlayedDate or Lahman's debut and finalGame. The
+-- integrity check only judges stat links for players whose season lines
+-- span their whole career, so a player with only some seasons loaded
+-- isn't mistaken for one who falls short.
+ALTER TABLE mlb_players ADD COLUMN debut_year INT DEFAULT NULL;
+ALTER TABLE mlb_players ADD COLUMN final_year INT DEFAULT NULL;
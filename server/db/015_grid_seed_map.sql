@@ -0,0 +1,26 @@
+-- migrations/015_grid_seed_map.sql
+
+-- Every seed that produced a template, not just the first: generation
+-- that lands on an existing template records its seed here too, so
+-- RebuildGrid finds the template by seed even after the data changes.
+CREATE TABLE IF NOT EXISTS grid_seeds (
+    seed             BIGINT      NOT NULL,
+    data_version     VARCHAR(16) NOT NULL,
+    grid_template_id INT         NOT NULL,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (seed, data_version, grid_template_id),
+    FOREIGN KEY (grid_template_id) REFERENCES grid_templates(id)
+);
+
+INSERT IGNORE INTO grid_seeds (seed, data_version, grid_template_id)
+SELECT seed, data_version, id FROM grid_templates
+WHERE seed IS NOT NULL AND data_version IS NOT NULL;
+
+-- The current data version (see grid.RefreshDataVersion), stored so
+-- starting a game reads one row instead of aggregating player_criteria.
+-- The ingest, evaluate and integrity fix commands refresh it.
+CREATE TABLE IF NOT EXISTS grid_data_version (
+    id         TINYINT     NOT NULL PRIMARY KEY DEFAULT 1,
+    version    VARCHAR(16) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -0,0 +1,16 @@
+-- migrations/016_draft_criteria.sql
+
+-- Draft columns on mlb_players, filled from the MLB /people endpoint
+-- hydrated with draft. A player drafted more than once (who didn't sign
+-- the first time) keeps their last draft, the one they signed from.
+ALTER TABLE mlb_players ADD COLUMN draft_year  SMALLINT   DEFAULT NULL;
+ALTER TABLE mlb_players ADD COLUMN draft_round VARCHAR(5) DEFAULT NULL; -- "1", "2", ... or "1C", "C-1" for supplemental picks
+ALTER TABLE mlb_players ADD COLUMN draft_pick  SMALLINT   DEFAULT NULL; -- overall pick number
+
+-- Draft criteria are bio criteria:
+--   draft_round     — drafted in round bio_value
+--   draft_pick_max  — drafted with overall pick bio_value or earlier
+INSERT IGNORE INTO criteria (type, label, short_label, bio_field, bio_value, stat_group) VALUES
+('bio', 'First-Round Pick',  '1st Rd Pick', 'draft_round',    '1',  NULL),
+('bio', 'Top-10 Draft Pick', 'Top 10 Pick', 'draft_pick_max', '10', NULL),
+('bio', '#1 Overall Pick',   '#1 Pick',     'draft_pick_max', '1',  NULL);
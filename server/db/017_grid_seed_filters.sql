@@ -0,0 +1,7 @@
+-- migrations/017_grid_seed_filters.sql
+
+-- The room filters a seed was drawn with (grid.GridFilters.key, empty
+-- for none), so rebuilding a filtered seed finds its own template. Seeds
+-- recorded before this are taken as unfiltered.
+ALTER TABLE grid_seeds ADD COLUMN filters VARCHAR(128) NOT NULL DEFAULT '' AFTER data_version;
+ALTER TABLE grid_seeds DROP PRIMARY KEY, ADD PRIMARY KEY (seed, data_version, filters, grid_template_id);
@@ -6,19 +6,25 @@ import (
 	"trivia-server/models"
 )
 
+var (
+	ErrGameNotActive   = errors.New("game is not active")
+	ErrInvalidPosition = errors.New("invalid grid position")
+	ErrNotYourTurn     = errors.New("not your turn")
+)
+
 func MakeMove(state *models.GameState, userID, row, col int, answer string) (*models.GameMove, int, error) {
 	if state.Game.Status != models.GameStatusActive {
-		return nil, state.Game.CurrentTurn, errors.New("game is not active")
+		return nil, state.Game.CurrentTurn, ErrGameNotActive
 	}
 
 	if row < 0 || row >= 3 || col < 0 || col >= 3 {
-		return nil, state.Game.CurrentTurn, errors.New("invalid grid position")
+		return nil, state.Game.CurrentTurn, ErrInvalidPosition
 	}
 
 	// Check if it's the player's turn
 	playerIdx := state.Game.CurrentTurn % len(state.Players)
 	if state.Players[playerIdx].UserID != userID {
-		return nil, state.Game.CurrentTurn, errors.New("not your turn")
+		return nil, state.Game.CurrentTurn, ErrNotYourTurn
 	}
 
 	move := &models.GameMove{
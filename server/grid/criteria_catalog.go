@@ -0,0 +1,54 @@
+package grid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CatalogEntry is one criteria in the browsable catalog, with how many
+// players satisfy it so hosts can judge how hard a header is.
+type CatalogEntry struct {
+	Criteria
+	PlayerCount int `json:"player_count"`
+}
+
+// ListCriteria returns the criteria catalog, optionally narrowed to one
+// type and/or a label search, ordered by type then label.
+func (s *Service) ListCriteria(cType, search string) ([]CatalogEntry, error) {
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+	if cType != "" {
+		where = append(where, "c.type = ?")
+		args = append(args, cType)
+	}
+	if search != "" {
+		where = append(where, "(c.label LIKE ? OR c.short_label LIKE ?)")
+		like := "%" + search + "%"
+		args = append(args, like, like)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT c.id, c.type, c.label, COALESCE(c.short_label, c.label),
+		       c.mlb_team_id, c.award_id, COUNT(pc.mlb_id)
+		FROM criteria c
+		LEFT JOIN player_criteria pc ON pc.criteria_id = c.id
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY c.id, c.type, c.label, c.short_label, c.mlb_team_id, c.award_id
+		ORDER BY c.type, c.label
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list criteria: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]CatalogEntry, 0)
+	for rows.Next() {
+		var e CatalogEntry
+		if err := rows.Scan(&e.ID, &e.Type, &e.Label, &e.ShortLabel, &e.MlbTeamID, &e.AwardID, &e.PlayerCount); err != nil {
+			return nil, fmt.Errorf("failed to scan criteria: %w", err)
+		}
+		applyCriteriaWording(&e.Criteria)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,84 @@
+package grid
+
+// criteriaTraits is the part of a criteria row the generator needs to
+// tell whether two headers could ever share a player.
+type criteriaTraits struct {
+	cType     string
+	statGroup string // "hitting", "pitching" or "" for either
+	bioField  string
+	bioValue  string
+}
+
+func (t criteriaTraits) isBio() bool {
+	return t.cType == "position" || t.cType == "bio"
+}
+
+// conflictsWith reports whether no player can satisfy both t and o, e.g.
+// Catcher × 300 Saves or Born in California × Born Outside the USA.
+// Stat-vs-stat pairs aren't judged here — two-way players exist, and
+// collectCellAnswers catches the empty cells anyway.
+func (t criteriaTraits) conflictsWith(o criteriaTraits) bool {
+	if !t.isBio() && !o.isBio() {
+		return false
+	}
+	if t.statGroup != "" && o.statGroup != "" && t.statGroup != o.statGroup {
+		return true
+	}
+	if !t.isBio() || !o.isBio() {
+		return false
+	}
+
+	switch {
+	case t.bioField == o.bioField && (t.bioField == "position" || t.bioField == "bats" || t.bioField == "throws" || t.bioField == "draft_round"):
+		return t.bioValue != o.bioValue
+	case t.isBirthplace() && o.isBirthplace():
+		return birthplacesConflict(t, o)
+	}
+	return false
+}
+
+func (t criteriaTraits) isBirthplace() bool {
+	return t.bioField == "birth_country" || t.bioField == "birth_country_not" || t.bioField == "birth_state"
+}
+
+func birthplacesConflict(a, b criteriaTraits) bool {
+	if a.bioField == "birth_state" && b.bioField == "birth_state" {
+		return a.bioValue != b.bioValue
+	}
+	country := func(t criteriaTraits) string {
+		switch t.bioField {
+		case "birth_state":
+			return "USA"
+		case "birth_country":
+			return t.bioValue
+		}
+		return ""
+	}
+	notCountry := func(t criteriaTraits) string {
+		if t.bioField == "birth_country_not" {
+			return t.bioValue
+		}
+		return ""
+	}
+
+	ca, cb := country(a), country(b)
+	if ca != "" && cb != "" {
+		return ca != cb
+	}
+	return (ca != "" && ca == notCountry(b)) || (cb != "" && cb == notCountry(a))
+}
+
+// findConflict returns the first row/col pair that can never share a
+// player. Criteria missing from traits are assumed compatible.
+func findConflict(rowIDs, colIDs [3]int, traits map[int]criteriaTraits) (rowID, colID int, found bool) {
+	for _, r := range rowIDs {
+		for _, c := range colIDs {
+			rt, okR := traits[r]
+			ct, okC := traits[c]
+			if okR && okC && rt.conflictsWith(ct) {
+				return r, c, true
+			}
+		}
+	}
+	return 0, 0, false
+}
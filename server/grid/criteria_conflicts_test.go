@@ -0,0 +1,71 @@
+package grid
+
+import "testing"
+
+func TestConflictsWith(t *testing.T) {
+	var (
+		catcher     = criteriaTraits{cType: "position", statGroup: "hitting", bioField: "position", bioValue: "C"}
+		shortstop   = criteriaTraits{cType: "position", statGroup: "hitting", bioField: "position", bioValue: "SS"}
+		lefty       = criteriaTraits{cType: "bio", statGroup: "pitching", bioField: "throws", bioValue: "L"}
+		switchHit   = criteriaTraits{cType: "bio", bioField: "bats", bioValue: "S"}
+		saves       = criteriaTraits{cType: "stat", statGroup: "pitching"}
+		homers      = criteriaTraits{cType: "stat", statGroup: "hitting"}
+		yankees     = criteriaTraits{cType: "team"}
+		california  = criteriaTraits{cType: "bio", bioField: "birth_state", bioValue: "CA"}
+		texas       = criteriaTraits{cType: "bio", bioField: "birth_state", bioValue: "TX"}
+		intl        = criteriaTraits{cType: "bio", bioField: "birth_country_not", bioValue: "USA"}
+		dominican   = criteriaTraits{cType: "bio", bioField: "birth_country", bioValue: "Dominican Republic"}
+		venezuela   = criteriaTraits{cType: "bio", bioField: "birth_country", bioValue: "Venezuela"}
+		firstRound  = criteriaTraits{cType: "bio", bioField: "draft_round", bioValue: "1"}
+		secondRound = criteriaTraits{cType: "bio", bioField: "draft_round", bioValue: "2"}
+		topTen      = criteriaTraits{cType: "bio", bioField: "draft_pick_max", bioValue: "10"}
+	)
+
+	tests := []struct {
+		name string
+		a, b criteriaTraits
+		want bool
+	}{
+		{"catcher × saves", catcher, saves, true},
+		{"lefty pitcher × homers", lefty, homers, true},
+		{"catcher × shortstop", catcher, shortstop, true},
+		{"catcher × homers", catcher, homers, false},
+		{"catcher × team", catcher, yankees, false},
+		{"stat × stat", saves, homers, false}, // two-way players exist
+		{"switch hitter × lefty pitcher", switchHit, lefty, false},
+		{"California × Texas", california, texas, true},
+		{"California × born outside the USA", california, intl, true},
+		{"Dominican × born outside the USA", dominican, intl, false},
+		{"Dominican × Venezuela", dominican, venezuela, true},
+		{"first round × second round", firstRound, secondRound, true},
+		{"first round × top ten", firstRound, topTen, false},
+		{"first round × Dominican", firstRound, dominican, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.conflictsWith(tt.b); got != tt.want {
+			t.Errorf("%s: conflictsWith = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := tt.b.conflictsWith(tt.a); got != tt.want {
+			t.Errorf("%s (swapped): conflictsWith = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindConflict(t *testing.T) {
+	traits := map[int]criteriaTraits{
+		1: {cType: "position", statGroup: "hitting", bioField: "position", bioValue: "C"},
+		2: {cType: "stat", statGroup: "pitching"},
+		3: {cType: "team"},
+	}
+
+	if r, c, found := findConflict([3]int{3, 1, 3}, [3]int{3, 3, 2}, traits); !found || r != 1 || c != 2 {
+		t.Errorf("findConflict = %d, %d, %v; want 1, 2, true", r, c, found)
+	}
+	if _, _, found := findConflict([3]int{1, 3, 3}, [3]int{3, 3, 3}, traits); found {
+		t.Error("findConflict found a conflict in a compatible grid")
+	}
+	// Criteria without traits are assumed compatible
+	if _, _, found := findConflict([3]int{1, 1, 1}, [3]int{99, 99, 99}, traits); found {
+		t.Error("findConflict judged criteria it has no traits for")
+	}
+}
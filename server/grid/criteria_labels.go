@@ -0,0 +1,33 @@
+package grid
+
+// awardWording replaces the stored award names ("AL MVP") with labels
+// that read as a grid header ("Won AL MVP"). Keyed by criteria.award_id.
+var awardWording = map[string]struct{ label, short string }{
+	"MLBHOF":  {"Hall of Famer", "Hall of Fame"},
+	"WSCHAMP": {"Won the World Series", "WS Champ"},
+	"WSMVP":   {"World Series MVP", "WS MVP"},
+	"ALMVP":   {"Won AL MVP", "AL MVP"},
+	"NLMVP":   {"Won NL MVP", "NL MVP"},
+	"ALCY":    {"Won AL Cy Young", "AL Cy Young"},
+	"NLCY":    {"Won NL Cy Young", "NL Cy Young"},
+	"ALROY":   {"AL Rookie of the Year", "AL ROY"},
+	"NLROY":   {"NL Rookie of the Year", "NL ROY"},
+	"ALAS":    {"AL All-Star Selection", "AL All-Star"},
+	"NLAS":    {"NL All-Star Selection", "NL All-Star"},
+	"ALGG":    {"Won AL Gold Glove", "AL Gold Glove"},
+	"NLGG":    {"Won NL Gold Glove", "NL Gold Glove"},
+	"ALSS":    {"Won AL Silver Slugger", "AL Silver Slugger"},
+	"NLSS":    {"Won NL Silver Slugger", "NL Silver Slugger"},
+}
+
+// applyCriteriaWording rewrites a criteria's labels for display. Only
+// award criteria are reworded; everything else keeps its stored label.
+func applyCriteriaWording(c *Criteria) {
+	if c.Type != "award" || c.AwardID == nil {
+		return
+	}
+	if w, ok := awardWording[*c.AwardID]; ok {
+		c.Label = w.label
+		c.ShortLabel = w.short
+	}
+}
@@ -0,0 +1,26 @@
+package grid
+
+import "testing"
+
+func TestApplyCriteriaWording(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		in        Criteria
+		label     string
+		shortText string
+	}{
+		{Criteria{Type: "award", Label: "AL MVP", ShortLabel: "ALMVP", AwardID: str("ALMVP")}, "Won AL MVP", "AL MVP"},
+		{Criteria{Type: "award", Label: "Hall of Fame", AwardID: str("MLBHOF")}, "Hall of Famer", "Hall of Fame"},
+		// Awards without wording, and other criteria, keep their labels
+		{Criteria{Type: "award", Label: "Comeback Player", ShortLabel: "Comeback", AwardID: str("ALCPOY")}, "Comeback Player", "Comeback"},
+		{Criteria{Type: "award", Label: "Unlinked award", ShortLabel: "Award"}, "Unlinked award", "Award"},
+		{Criteria{Type: "team", Label: "New York Yankees", ShortLabel: "NYY", AwardID: str("ALMVP")}, "New York Yankees", "NYY"},
+	}
+	for _, tt := range tests {
+		c := tt.in
+		applyCriteriaWording(&c)
+		if c.Label != tt.label || c.ShortLabel != tt.shortText {
+			t.Errorf("wording of %q = %q / %q, want %q / %q", tt.in.Label, c.Label, c.ShortLabel, tt.label, tt.shortText)
+		}
+	}
+}
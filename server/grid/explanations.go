@@ -0,0 +1,39 @@
+package grid
+
+import (
+	"fmt"
+	"trivia-server/criteria"
+)
+
+// ExplainAnswer returns the evidence that mlbID satisfies a criteria.
+func (s *Service) ExplainAnswer(mlbID, criteriaID int) (*criteria.Explanation, error) {
+	return criteria.Explain(s.db, mlbID, criteriaID)
+}
+
+// ExplainCell returns the evidence for mlbID in one cell of a template:
+// the row criteria's explanation followed by the column's.
+func (s *Service) ExplainCell(templateID, row, col, mlbID int) ([]*criteria.Explanation, error) {
+	if row < 0 || row > 2 || col < 0 || col > 2 {
+		return nil, fmt.Errorf("cell (%d,%d) is outside the grid", row, col)
+	}
+
+	var rowIDs, colIDs [3]int
+	err := s.db.QueryRow(`
+		SELECT row_criteria_1, row_criteria_2, row_criteria_3,
+		       col_criteria_1, col_criteria_2, col_criteria_3
+		FROM grid_templates WHERE id = ?
+	`, templateID).Scan(&rowIDs[0], &rowIDs[1], &rowIDs[2], &colIDs[0], &colIDs[1], &colIDs[2])
+	if err != nil {
+		return nil, fmt.Errorf("grid template %d not found: %w", templateID, err)
+	}
+
+	explanations := make([]*criteria.Explanation, 0, 2)
+	for _, id := range []int{rowIDs[row], colIDs[col]} {
+		ex, err := criteria.Explain(s.db, mlbID, id)
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, ex)
+	}
+	return explanations, nil
+}
@@ -0,0 +1,36 @@
+package grid
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestResolveFavoriteOrRandomTeam(t *testing.T) {
+	random := func() (int, error) { return 999, nil }
+
+	// Rank 1 of 3 is drawn three times as often as rank 3
+	counts := map[int]int{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 6000; i++ {
+		id, err := resolveFavoriteOrRandomTeam(rng, []int{101, 102, 103}, map[int]bool{}, random)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[id]++
+	}
+	if counts[101] < 2500 || counts[102] < 1500 || counts[103] < 700 || counts[103] > 1300 || counts[999] != 0 {
+		t.Errorf("draws = %v, want about 3000/2000/1000", counts)
+	}
+
+	// Used favorites are skipped and the pick is marked used
+	used := map[int]bool{101: true, 102: true}
+	if id, _ := resolveFavoriteOrRandomTeam(rng, []int{101, 102, 103}, used, random); id != 103 || !used[103] {
+		t.Errorf("with 101 and 102 used got %d (used: %v), want 103", id, used)
+	}
+	if id, _ := resolveFavoriteOrRandomTeam(rng, []int{101, 102, 103}, used, random); id != 999 {
+		t.Errorf("with every favorite used got %d, want a random team", id)
+	}
+	if id, _ := resolveFavoriteOrRandomTeam(rng, nil, map[int]bool{}, random); id != 999 {
+		t.Errorf("without favorites got %d, want a random team", id)
+	}
+}
@@ -0,0 +1,111 @@
+package grid
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidFilters is returned for filter values that don't exist.
+	ErrInvalidFilters = errors.New("invalid grid filters")
+	// ErrFiltersTooStrict means the filtered criteria pools can't produce
+	// a grid where every cell has enough answers.
+	ErrFiltersTooStrict = errors.New("grid filters are too strict to build a grid")
+)
+
+// GridFilters narrows the criteria pools a room's grids draw from. The
+// zero value means no filtering.
+//
+// Filters choose criteria, not answers: a template's cell_answers are
+// shared by every room that plays it. So MinYear drops the criteria that
+// end before it (decades, "before 2000" teams, teammates of players who
+// retired), but a career stat or award cell still takes a player from
+// any era.
+type GridFilters struct {
+	League     string `json:"league,omitempty"`      // "AL" | "NL"
+	Division   string `json:"division,omitempty"`    // "East" | "Central" | "West"
+	ActiveOnly bool   `json:"active_only,omitempty"` // team criteria only for franchises still playing
+	StatGroup  string `json:"stat_group,omitempty"`  // "hitting" | "pitching"
+	MinYear    int    `json:"min_year,omitempty"`    // drop criteria that end before this season
+}
+
+// IsZero reports whether no filter is set.
+func (f GridFilters) IsZero() bool {
+	return f == GridFilters{}
+}
+
+// key is how grid_seeds records the filters a seed was drawn with:
+// empty when none are set.
+func (f GridFilters) key() string {
+	if f.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("league=%s;division=%s;active=%t;stats=%s;from=%d",
+		f.League, f.Division, f.ActiveOnly, f.StatGroup, f.MinYear)
+}
+
+// Normalize canonicalizes casing and checks every value, returning an
+// error wrapping ErrInvalidFilters for anything unknown.
+func (f GridFilters) Normalize() (GridFilters, error) {
+	f.League = strings.ToUpper(strings.TrimSpace(f.League))
+	switch f.League {
+	case "", "AL", "NL":
+	default:
+		return f, fmt.Errorf("%w: unknown league %q", ErrInvalidFilters, f.League)
+	}
+
+	f.Division = strings.ToLower(strings.TrimSpace(f.Division))
+	if f.Division != "" {
+		f.Division = strings.ToUpper(f.Division[:1]) + f.Division[1:]
+	}
+	switch f.Division {
+	case "", "East", "Central", "West":
+	default:
+		return f, fmt.Errorf("%w: unknown division %q", ErrInvalidFilters, f.Division)
+	}
+
+	f.StatGroup = strings.ToLower(strings.TrimSpace(f.StatGroup))
+	switch f.StatGroup {
+	case "", "hitting", "pitching":
+	default:
+		return f, fmt.Errorf("%w: unknown stat group %q", ErrInvalidFilters, f.StatGroup)
+	}
+
+	if f.MinYear < 0 {
+		return f, fmt.Errorf("%w: min_year must be positive", ErrInvalidFilters)
+	}
+	return f, nil
+}
+
+// where turns the filters into clauses over criteria c LEFT JOIN teams t.
+// Criteria that aren't tied to a team (or a stat group, or a year) are
+// never filtered out by that dimension. Only active teams rows carry an
+// mlb_team_id, so a team criteria that joins none is a franchise that
+// stopped playing, or one not linked yet.
+func (f GridFilters) where() ([]string, []interface{}) {
+	where := []string{"1 = 1"}
+	var args []interface{}
+	if f.League != "" {
+		where = append(where, "(c.mlb_team_id IS NULL OR t.league = ?)")
+		args = append(args, f.League)
+	}
+	if f.Division != "" {
+		where = append(where, "(c.mlb_team_id IS NULL OR t.division = ?)")
+		args = append(args, f.Division)
+	}
+	if f.ActiveOnly {
+		where = append(where, "(c.type <> 'team' OR t.is_active = TRUE)")
+	}
+	if f.StatGroup != "" {
+		where = append(where, "(c.stat_group IS NULL OR c.stat_group = ?)")
+		args = append(args, f.StatGroup)
+	}
+	if f.MinYear > 0 {
+		where = append(where,
+			"(c.end_year IS NULL OR c.end_year >= ?)",
+			"NOT EXISTS (SELECT 1 FROM mlb_players anchor WHERE anchor.mlb_id = c.anchor_mlb_id AND anchor.final_year < ?)")
+		args = append(args, f.MinYear, f.MinYear)
+	}
+	return where, args
+}
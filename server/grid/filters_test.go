@@ -0,0 +1,83 @@
+package grid
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeFilters(t *testing.T) {
+	tests := []struct {
+		in      GridFilters
+		want    GridFilters
+		wantErr bool
+	}{
+		{GridFilters{}, GridFilters{}, false},
+		{
+			GridFilters{League: " al ", Division: "CENTRAL", StatGroup: "Pitching"},
+			GridFilters{League: "AL", Division: "Central", StatGroup: "pitching"},
+			false,
+		},
+		{GridFilters{Division: "west"}, GridFilters{Division: "West"}, false},
+		{GridFilters{League: "PCL"}, GridFilters{}, true},
+		{GridFilters{Division: "North"}, GridFilters{}, true},
+		{GridFilters{StatGroup: "fielding"}, GridFilters{}, true},
+		{GridFilters{ActiveOnly: true, MinYear: 1990}, GridFilters{ActiveOnly: true, MinYear: 1990}, false},
+		{GridFilters{MinYear: -1}, GridFilters{}, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.in.Normalize()
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidFilters) {
+				t.Errorf("Normalize(%+v) error = %v, want ErrInvalidFilters", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%+v) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestFiltersWhere(t *testing.T) {
+	where, args := GridFilters{}.where()
+	if !reflect.DeepEqual(where, []string{"1 = 1"}) || len(args) != 0 {
+		t.Errorf("zero filters = %v %v, want no clauses", where, args)
+	}
+
+	where, args = GridFilters{League: "NL", Division: "West", StatGroup: "hitting"}.where()
+	if len(where) != 4 {
+		t.Errorf("got %d clauses, want 4: %v", len(where), where)
+	}
+	if want := []interface{}{"NL", "West", "hitting"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	where, args = GridFilters{ActiveOnly: true, MinYear: 1990}.where()
+	if len(where) != 4 {
+		t.Errorf("got %d clauses, want 4: %v", len(where), where)
+	}
+	if want := []interface{}{1990, 1990}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+func TestFiltersKey(t *testing.T) {
+	if k := (GridFilters{}).key(); k != "" {
+		t.Errorf("zero filters key = %q, want empty", k)
+	}
+	seen := map[string]GridFilters{}
+	for _, f := range []GridFilters{
+		{League: "AL"},
+		{League: "AL", Division: "West"},
+		{ActiveOnly: true},
+		{StatGroup: "hitting"},
+		{MinYear: 1990},
+	} {
+		k := f.key()
+		if prev, dup := seen[k]; dup {
+			t.Errorf("%+v and %+v share the key %q", prev, f, k)
+		}
+		seen[k] = f
+	}
+}
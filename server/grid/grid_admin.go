@@ -0,0 +1,346 @@
+package grid
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════
+// ADMIN CURATION
+// ═══════════════════════════════════════════════════════════
+
+var (
+	ErrTemplateNotFound = errors.New("grid template not found")
+	ErrInvalidGrid      = errors.New("invalid grid")
+)
+
+// TemplateFilter narrows ListTemplates. Zero values mean "don't filter".
+type TemplateFilter struct {
+	Difficulty string // "easy" | "regular"/"medium" | "hard"
+	CriteriaID int    // templates using this criteria on any row or column
+	Active     *bool
+	Limit      int
+	Offset     int
+}
+
+type TemplateSummary struct {
+	GridTemplate
+	Active     bool      `json:"active"`
+	MinAnswers int       `json:"min_answers"`
+	Source     string    `json:"source"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CellPreview struct {
+	Row         int         `json:"row"`
+	Col         int         `json:"col"`
+	AnswerCount int         `json:"answer_count"`
+	Rarest      *CellAnswer `json:"rarest,omitempty"`
+}
+
+type TemplatePreview struct {
+	Template TemplateSummary `json:"template"`
+	Cells    []CellPreview   `json:"cells"`
+}
+
+type ScheduledGrid struct {
+	Date           string `json:"date"` // YYYY-MM-DD
+	GridTemplateID int    `json:"grid_template_id"`
+}
+
+// ListTemplates returns grid templates matching the filter, newest first.
+func (s *Service) ListTemplates(f TemplateFilter) ([]TemplateSummary, error) {
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+
+	if f.Difficulty != "" {
+		where = append(where, "difficulty = ?")
+		args = append(args, dbDifficulty(f.Difficulty))
+	}
+	if f.CriteriaID > 0 {
+		where = append(where, `? IN (row_criteria_1, row_criteria_2, row_criteria_3,
+		                             col_criteria_1, col_criteria_2, col_criteria_3)`)
+		args = append(args, f.CriteriaID)
+	}
+	if f.Active != nil {
+		where = append(where, "active = ?")
+		args = append(args, *f.Active)
+	}
+
+	limit := f.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	args = append(args, limit, f.Offset)
+
+	rows, err := s.db.Query(`
+		SELECT id, row_criteria_1, row_criteria_2, row_criteria_3,
+		       col_criteria_1, col_criteria_2, col_criteria_3,
+		       difficulty, active, min_answers, COALESCE(source, 'generated'), created_at
+		FROM grid_templates
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grid templates: %w", err)
+	}
+	defer rows.Close()
+
+	type listedTemplate struct {
+		summary        TemplateSummary
+		rowIDs, colIDs [3]int
+	}
+	var listed []listedTemplate
+	for rows.Next() {
+		var lt listedTemplate
+		if err := rows.Scan(
+			&lt.summary.ID,
+			&lt.rowIDs[0], &lt.rowIDs[1], &lt.rowIDs[2],
+			&lt.colIDs[0], &lt.colIDs[1], &lt.colIDs[2],
+			&lt.summary.Difficulty, &lt.summary.Active, &lt.summary.MinAnswers,
+			&lt.summary.Source, &lt.summary.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan grid template: %w", err)
+		}
+		listed = append(listed, lt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating grid templates: %w", err)
+	}
+
+	// Templates share a small set of criteria — look each one up once
+	cache := make(map[int]*Criteria)
+	lookup := func(id int) (Criteria, error) {
+		if c, ok := cache[id]; ok {
+			return *c, nil
+		}
+		c, err := s.getCriteria(id)
+		if err != nil {
+			return Criteria{}, err
+		}
+		cache[id] = c
+		return *c, nil
+	}
+
+	summaries := make([]TemplateSummary, 0, len(listed))
+	for _, lt := range listed {
+		lt.summary.RowCriteria = make([]Criteria, 3)
+		lt.summary.ColCriteria = make([]Criteria, 3)
+		for i := 0; i < 3; i++ {
+			if lt.summary.RowCriteria[i], err = lookup(lt.rowIDs[i]); err != nil {
+				return nil, err
+			}
+			if lt.summary.ColCriteria[i], err = lookup(lt.colIDs[i]); err != nil {
+				return nil, err
+			}
+		}
+		summaries = append(summaries, lt.summary)
+	}
+	return summaries, nil
+}
+
+// GetTemplate loads a single template with its criteria, whether or not
+// it is active.
+func (s *Service) GetTemplate(id int) (*TemplateSummary, error) {
+	var t TemplateSummary
+	var rowIDs, colIDs [3]int
+	err := s.db.QueryRow(`
+		SELECT id, row_criteria_1, row_criteria_2, row_criteria_3,
+		       col_criteria_1, col_criteria_2, col_criteria_3,
+		       difficulty, active, min_answers, COALESCE(source, 'generated'), created_at
+		FROM grid_templates WHERE id = ?
+	`, id).Scan(
+		&t.ID,
+		&rowIDs[0], &rowIDs[1], &rowIDs[2],
+		&colIDs[0], &colIDs[1], &colIDs[2],
+		&t.Difficulty, &t.Active, &t.MinAnswers, &t.Source, &t.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch grid template %d: %w", id, err)
+	}
+
+	t.RowCriteria = make([]Criteria, 3)
+	t.ColCriteria = make([]Criteria, 3)
+	for i := 0; i < 3; i++ {
+		rc, err := s.getCriteria(rowIDs[i])
+		if err != nil {
+			return nil, err
+		}
+		t.RowCriteria[i] = *rc
+
+		cc, err := s.getCriteria(colIDs[i])
+		if err != nil {
+			return nil, err
+		}
+		t.ColCriteria[i] = *cc
+	}
+	return &t, nil
+}
+
+// PreviewTemplate returns a template along with the answer count and the
+// rarest answer for each of its nine cells.
+func (s *Service) PreviewTemplate(id int) (*TemplatePreview, error) {
+	t, err := s.GetTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &TemplatePreview{Template: *t, Cells: make([]CellPreview, 0, 9)}
+	for ri := 0; ri < 3; ri++ {
+		for ci := 0; ci < 3; ci++ {
+			answers, err := s.GetCellAnswers(id, ri, ci)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load answers for cell %d,%d: %w", ri, ci, err)
+			}
+			cell := CellPreview{Row: ri, Col: ci, AnswerCount: len(answers)}
+			if len(answers) > 0 {
+				// GetCellAnswers orders by rarity_score ASC — lowest is rarest
+				rarest := answers[0]
+				cell.Rarest = &rarest
+			}
+			preview.Cells = append(preview.Cells, cell)
+		}
+	}
+	return preview, nil
+}
+
+// CreateTemplate builds a curated template from hand-picked criteria.
+// All six criteria must exist and be distinct, and every cell must have
+// at least MinAnswersPerCell answers. Errors caused by the input wrap
+// ErrInvalidGrid.
+func (s *Service) CreateTemplate(difficulty string, rowIDs, colIDs [3]int) (*GridTemplate, error) {
+	return s.createTemplate(difficulty, rowIDs, colIDs, sourceCurated)
+}
+
+// CreateCustomTemplate is CreateTemplate for a grid a room host picked.
+// The template is saved inactive, so it's only ever played in that room.
+// When cells are short of answers the error is a *ShortCellsError.
+func (s *Service) CreateCustomTemplate(difficulty string, rowIDs, colIDs [3]int) (*GridTemplate, error) {
+	return s.createTemplate(difficulty, rowIDs, colIDs, sourceCustom)
+}
+
+// ShortCellsError lists every cell of a hand-built grid that doesn't
+// have enough answers. It wraps ErrInvalidGrid.
+type ShortCellsError struct {
+	Cells []ShortCell
+}
+
+func (e *ShortCellsError) Error() string {
+	cells := make([]string, len(e.Cells))
+	for i, c := range e.Cells {
+		cells[i] = fmt.Sprintf("row %d col %d has %d", c.Row+1, c.Col+1, c.Answers)
+	}
+	return fmt.Sprintf("%v: every cell needs at least %d valid answers (%s)",
+		ErrInvalidGrid, MinAnswersPerCell, strings.Join(cells, ", "))
+}
+
+func (e *ShortCellsError) Unwrap() error { return ErrInvalidGrid }
+
+func (s *Service) createTemplate(difficulty string, rowIDs, colIDs [3]int, source string) (*GridTemplate, error) {
+	switch difficulty {
+	case "easy", "regular", "medium", "hard":
+	default:
+		return nil, fmt.Errorf("%w: unknown difficulty %q", ErrInvalidGrid, difficulty)
+	}
+
+	seen := make(map[int]bool, 6)
+	for _, id := range append(rowIDs[:], colIDs[:]...) {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: criteria %d is used more than once", ErrInvalidGrid, id)
+		}
+		seen[id] = true
+		if _, err := s.getCriteria(id); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGrid, err)
+		}
+	}
+
+	_, traits, err := s.loadCriteriaPools(GridFilters{})
+	if err != nil {
+		return nil, err
+	}
+	if r, c, bad := findConflict(rowIDs, colIDs, traits); bad {
+		return nil, fmt.Errorf("%w: criteria %d and %d can never share a player", ErrInvalidGrid, r, c)
+	}
+
+	cellData, totalAnswers, short := s.collectCellAnswers(rowIDs, colIDs, true)
+	if len(short) > 0 {
+		return nil, &ShortCellsError{Cells: short}
+	}
+
+	return s.persistGeneratedGrid(rowIDs, colIDs, difficulty, source, totalAnswers, cellData)
+}
+
+// SetTemplateActive activates or deactivates a template. Inactive
+// templates are never picked by GetRandomGrid.
+func (s *Service) SetTemplateActive(id int, active bool) error {
+	res, err := s.db.Exec(`UPDATE grid_templates SET active = ? WHERE id = ?`, active, id)
+	if err != nil {
+		return fmt.Errorf("failed to update grid template %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// MySQL reports 0 affected rows when the value didn't change too,
+		// so confirm the template actually exists
+		if _, err := s.GetTemplate(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScheduleTemplate pins a template to a date, replacing whatever was
+// scheduled for that date before.
+func (s *Service) ScheduleTemplate(id int, date time.Time) error {
+	if _, err := s.GetTemplate(id); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO grid_schedule (scheduled_date, grid_template_id)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE grid_template_id = VALUES(grid_template_id)
+	`, date.Format("2006-01-02"), id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule grid template %d: %w", id, err)
+	}
+	return nil
+}
+
+// UnscheduleDate removes whatever template is scheduled for a date.
+func (s *Service) UnscheduleDate(date time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM grid_schedule WHERE scheduled_date = ?`, date.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("failed to clear schedule: %w", err)
+	}
+	return nil
+}
+
+// ListSchedule returns the scheduled templates between from and to
+// (inclusive), in date order.
+func (s *Service) ListSchedule(from, to time.Time) ([]ScheduledGrid, error) {
+	rows, err := s.db.Query(`
+		SELECT DATE_FORMAT(scheduled_date, '%Y-%m-%d'), grid_template_id
+		FROM grid_schedule
+		WHERE scheduled_date BETWEEN ? AND ?
+		ORDER BY scheduled_date
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule: %w", err)
+	}
+	defer rows.Close()
+
+	schedule := make([]ScheduledGrid, 0)
+	for rows.Next() {
+		var sg ScheduledGrid
+		if err := rows.Scan(&sg.Date, &sg.GridTemplateID); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedule = append(schedule, sg)
+	}
+	return schedule, rows.Err()
+}
@@ -0,0 +1,27 @@
+package grid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestShortCellsError(t *testing.T) {
+	err := error(&ShortCellsError{Cells: []ShortCell{{Row: 0, Col: 2, Answers: 1}, {Row: 2, Col: 0, Answers: 0}}})
+	if !errors.Is(err, ErrInvalidGrid) {
+		t.Error("ShortCellsError doesn't wrap ErrInvalidGrid")
+	}
+	for _, want := range []string{"row 1 col 3 has 1", "row 3 col 1 has 0", "at least 3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestCreateTemplateUnknownDifficulty(t *testing.T) {
+	// Checked before anything is looked up, so no database is needed
+	_, err := NewService(nil).CreateTemplate("impossible", [3]int{1, 2, 3}, [3]int{4, 5, 6})
+	if !errors.Is(err, ErrInvalidGrid) {
+		t.Errorf("CreateTemplate with an unknown difficulty = %v, want ErrInvalidGrid", err)
+	}
+}
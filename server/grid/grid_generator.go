@@ -2,110 +2,283 @@ package grid
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
 )
 
-const minAnswersPerCell = 3
+// MinAnswersPerCell is the fewest valid answers a playable cell may have.
+const MinAnswersPerCell = 3
+
 const maxGenerationAttempts = 8
 
-// GetFavoriteTeamCriteriaID resolves a user's favorite_team_id (an MLB Stats
-// API team id, e.g. 147 for the Yankees) to a row in the criteria table.
-// Returns nil if the user has no favorite team set or it doesn't match
-// a known team criteria.
-func GetFavoriteTeamCriteriaID(db *sql.DB, userID int) (*int, error) {
-	var mlbTeamID sql.NullInt64
-	err := db.QueryRow(`SELECT favorite_team_id FROM users WHERE id = ?`, userID).Scan(&mlbTeamID)
+// ErrGenerationFailed means no attempt produced a grid where every cell
+// had enough answers — usually too little data for a favorite-team combo.
+var ErrGenerationFailed = errors.New("could not generate a valid grid")
+
+// Non-team slots come from one of the special pools (awards, eras,
+// single seasons, teammates) one time in specialShare, otherwise from the career
+// stat pool. Award winner lists and single-season clubs are short, so a
+// grid with three of them rarely has enough answers — each special kind
+// is capped per grid by specialHeaderCaps. Two teammate headers would
+// almost never share enough players, so teammates get one slot.
+const specialShare = 3
+
+// specialKinds is ordered so picks don't depend on map iteration order.
+var specialKinds = []string{"award", "era", "season", "teammate"}
+
+var specialHeaderCaps = map[string]int{
+	"award":    2,
+	"era":      1,
+	"season":   2,
+	"teammate": 1,
+}
+
+// Biography headers (positions, handedness, birthplace) come from their
+// own pool and are mixed in by difficulty: easy grids may get a single
+// position, regular grids one of anything, hard grids up to two.
+const bioShare = 4
+
+var bioKinds = map[string][]string{
+	"easy":    {"position"},
+	"regular": {"position", "bio"},
+	"hard":    {"position", "bio"},
+}
+
+var bioHeaderCaps = map[string]int{
+	"easy":    1,
+	"regular": 1,
+	"hard":    2,
+}
+
+// grid_templates.source values
+const (
+	sourceGenerated = "generated"
+	sourceCurated   = "curated"
+	sourceCustom    = "custom" // built by a room host, never served to other rooms
+)
+
+// GetFavoriteTeamCriteriaIDs resolves a user's ranked favorite teams (MLB
+// Stats API team ids, e.g. 147 for the Yankees) to rows in the criteria
+// table, best first. Teams without a team criteria are skipped, so the
+// result may be empty.
+func GetFavoriteTeamCriteriaIDs(db *sql.DB, userID int) ([]int, error) {
+	rows, err := db.Query(`
+		SELECT c.id
+		FROM user_favorite_teams f
+		JOIN criteria c ON c.type = 'team' AND c.mlb_team_id = f.mlb_team_id
+		WHERE f.user_id = ?
+		ORDER BY f.team_rank
+	`, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load favorite team for user %d: %w", userID, err)
-	}
-	if !mlbTeamID.Valid {
-		return nil, nil
+		return nil, fmt.Errorf("failed to load favorite teams for user %d: %w", userID, err)
 	}
+	defer rows.Close()
 
-	var criteriaID int
-	err = db.QueryRow(`SELECT id FROM criteria WHERE type = 'team' AND mlb_team_id = ?`, mlbTeamID.Int64).Scan(&criteriaID)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve favorite team criteria: %w", err)
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to resolve favorite team criteria: %w", err)
+		}
+		ids = append(ids, id)
 	}
-	return &criteriaID, nil
+	return ids, rows.Err()
 }
 
 // GenerateGrid builds a fresh grid template on the fly based on difficulty
 // and the two players' favorite teams, validates that every cell has at
-// least minAnswersPerCell valid answers, persists it to grid_templates +
+// least MinAnswersPerCell valid answers, persists it to grid_templates +
 // cell_answers, and returns it ready to use.
 //
-// p1FavTeamCriteriaID / p2FavTeamCriteriaID may be nil if a player has no
-// favorite team set — in that case a random team is used in its place.
-func (s *Service) GenerateGrid(difficulty string, p1FavTeamCriteriaID, p2FavTeamCriteriaID *int) (*GridTemplate, error) {
-	teamIDs, statIDs, err := s.loadCriteriaPools()
+// p1Favs / p2Favs are each player's ranked favorite team criteria IDs
+// (see GetFavoriteTeamCriteriaIDs). A favorite is drawn from each list,
+// weighted toward the top, without the two players colliding; an empty
+// list means a random team is used in its place.
+//
+// The same seed, difficulty, favorite teams, filters and DataVersion
+// always produce the same criteria. A favorite team the filters exclude
+// is replaced by a random team. Returns ErrFiltersTooStrict when filters
+// are set and no valid grid could be built.
+func (s *Service) GenerateGrid(difficulty string, p1Favs, p2Favs []int, opts GenerateOptions) (*GridTemplate, error) {
+	d, err := s.drawGrid(difficulty, p1Favs, p2Favs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	existingID, err := s.findGeneratedGrid(d.rowIDs, d.colIDs, difficulty, d.dataVersion)
 	if err != nil {
 		return nil, err
 	}
-	if len(teamIDs) < 6 || len(statIDs) < 2 {
+	if existingID == 0 {
+		gt, err := s.persistGeneratedGrid(d.rowIDs, d.colIDs, difficulty, sourceGenerated, d.totalAnswers, d.cellData)
+		if err != nil {
+			return nil, err
+		}
+		existingID = gt.ID
+	}
+	return s.recordSeed(existingID, d.seed, d.dataVersion, opts.Filters)
+}
+
+// drawnGrid is the grid a seed draws, before anything is stored.
+type drawnGrid struct {
+	seed           int64
+	dataVersion    string
+	rowIDs, colIDs [3]int
+	cellData       map[[2]int][]cellAnswerRow
+	totalAnswers   int
+}
+
+// drawGrid makes GenerateGrid's random choices and checks every cell,
+// without writing anything.
+func (s *Service) drawGrid(difficulty string, p1Favs, p2Favs []int, opts GenerateOptions) (*drawnGrid, error) {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = NewSeed()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	pools, traits, err := s.loadCriteriaPools(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	if len(pools["team"]) < minTeamsForDifficulty(difficulty) || len(pools["stat"]) < 2 {
+		if !opts.Filters.IsZero() {
+			return nil, fmt.Errorf("%w: only %d teams and %d stats match", ErrFiltersTooStrict, len(pools["team"]), len(pools["stat"]))
+		}
 		return nil, fmt.Errorf("not enough criteria to generate a grid")
 	}
+	p1Favs = pools.keep("team", p1Favs)
+	p2Favs = pools.keep("team", p2Favs)
+	dataVersion, err := s.DataVersion()
+	if err != nil {
+		return nil, err
+	}
 
 	for attempt := 0; attempt < maxGenerationAttempts; attempt++ {
-		rowIDs, colIDs, err := buildCriteriaSets(difficulty, p1FavTeamCriteriaID, p2FavTeamCriteriaID, teamIDs, statIDs)
+		rowIDs, colIDs, err := buildCriteriaSets(rng, difficulty, p1Favs, p2Favs, pools)
 		if err != nil {
 			return nil, err
 		}
+		if _, _, bad := findConflict(rowIDs, colIDs, traits); bad {
+			continue // e.g. Catcher × 300 Saves — no point querying it
+		}
 
-		cellData, totalAnswers, ok := s.collectCellAnswers(rowIDs, colIDs)
-		if !ok {
+		cellData, totalAnswers, short := s.collectCellAnswers(rowIDs, colIDs, false)
+		if len(short) > 0 {
 			continue // a cell didn't meet the minimum — retry with new random slots
 		}
+		return &drawnGrid{
+			seed:         seed,
+			dataVersion:  dataVersion,
+			rowIDs:       rowIDs,
+			colIDs:       colIDs,
+			cellData:     cellData,
+			totalAnswers: totalAnswers,
+		}, nil
+	}
 
-		gt, err := s.persistGeneratedGrid(rowIDs, colIDs, difficulty, totalAnswers, cellData)
-		if err != nil {
-			return nil, err
-		}
-		return gt, nil
+	// No silent fallback to GetRandomGrid — it could hand back a grid of
+	// a different difficulty. The caller decides what to do.
+	if !opts.Filters.IsZero() {
+		return nil, fmt.Errorf("%w: no valid grid after %d attempts (%s)", ErrFiltersTooStrict, maxGenerationAttempts, difficulty)
 	}
+	return nil, fmt.Errorf("%w after %d attempts (%s)", ErrGenerationFailed, maxGenerationAttempts, difficulty)
+}
+
+// GenerateOptions are GenerateGrid's settings beyond difficulty and
+// favorite teams. The zero value means a random seed and no filters.
+type GenerateOptions struct {
+	Seed    int64       // drives every random choice; 0 = pick one
+	Filters GridFilters // narrows the criteria pools
+}
+
+// minTeamsForDifficulty is how many team criteria buildCriteriaSets may
+// need: easy uses 2, regular 4, and a hard all-teams grid 6.
+func minTeamsForDifficulty(difficulty string) int {
+	switch difficulty {
+	case "easy":
+		return 2
+	case "regular":
+		return 4
+	}
+	return 6
+}
+
+// criteriaPools maps a criteria type ("team", "stat", "award", "era",
+// "season", "teammate", "position", "bio") to the IDs of that type.
+type criteriaPools map[string][]int
 
-	// Fallback — couldn't build a satisfying grid with favorite teams after
-	// several attempts (not enough data for that team combo). Fall back to
-	// a pre-built random grid so the game can still start.
-	return s.GetRandomGrid()
+// keep returns the ids that are in the pool for cType, in order.
+func (p criteriaPools) keep(cType string, ids []int) []int {
+	inPool := make(map[int]bool, len(p[cType]))
+	for _, id := range p[cType] {
+		inPool[id] = true
+	}
+	var kept []int
+	for _, id := range ids {
+		if inPool[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
 }
 
-// loadCriteriaPools returns all team criteria IDs and all non-team
-// (stat/award) criteria IDs.
-func (s *Service) loadCriteriaPools() (teamIDs []int, statIDs []int, err error) {
-	rows, err := s.db.Query(`SELECT id, type FROM criteria`)
+// loadCriteriaPools returns the criteria IDs that pass filters grouped by
+// type, plus the traits of each criteria for conflict checks.
+func (s *Service) loadCriteriaPools(filters GridFilters) (criteriaPools, map[int]criteriaTraits, error) {
+	where, args := filters.where()
+	// Ordered so a seeded rng indexes the same IDs every time
+	rows, err := s.db.Query(`
+		SELECT c.id, c.type, c.stat_group, c.bio_field, c.bio_value
+		FROM criteria c
+		LEFT JOIN teams t ON t.mlb_team_id = c.mlb_team_id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY c.id
+	`, args...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to load criteria pools: %w", err)
 	}
 	defer rows.Close()
 
+	pools := criteriaPools{}
+	traits := make(map[int]criteriaTraits)
 	for rows.Next() {
 		var id int
 		var cType string
-		if err := rows.Scan(&id, &cType); err != nil {
+		var statGroup, bioField, bioValue sql.NullString
+		if err := rows.Scan(&id, &cType, &statGroup, &bioField, &bioValue); err != nil {
 			continue
 		}
-		if cType == "team" {
-			teamIDs = append(teamIDs, id)
-		} else {
-			statIDs = append(statIDs, id)
+		pools[cType] = append(pools[cType], id)
+		traits[id] = criteriaTraits{
+			cType:     cType,
+			statGroup: statGroup.String,
+			bioField:  bioField.String,
+			bioValue:  bioValue.String,
 		}
 	}
-	return teamIDs, statIDs, nil
+	return pools, traits, nil
 }
 
 // buildCriteriaSets returns 3 row criteria IDs and 3 col criteria IDs
-// based on the requested difficulty.
-func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []int) (rowIDs, colIDs [3]int, err error) {
+// based on the requested difficulty. All randomness comes from rng, so
+// the same seed and pools always give the same picks.
+func buildCriteriaSets(rng *rand.Rand, difficulty string, p1Favs, p2Favs []int, pools criteriaPools) (rowIDs, colIDs [3]int, err error) {
 	used := map[int]bool{}
+	teamIDs, statIDs := pools["team"], pools["stat"]
+	kindUsed := map[string]int{}
+	bioUsed := 0
+
+	var bioPool []int
+	for _, kind := range bioKinds[difficulty] {
+		bioPool = append(bioPool, pools[kind]...)
+	}
 
 	pickRandomTeam := func() (int, error) {
 		for i := 0; i < 25; i++ {
-			id := teamIDs[rand.Intn(len(teamIDs))]
+			id := teamIDs[rng.Intn(len(teamIDs))]
 			if !used[id] {
 				used[id] = true
 				return id, nil
@@ -114,9 +287,41 @@ func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []
 		return 0, fmt.Errorf("could not find a unique random team")
 	}
 
+	// pickRandomStat fills a non-team slot, occasionally from the bio pool
+	// or one of the special pools that still has room under its cap.
 	pickRandomStat := func() (int, error) {
+		if len(bioPool) > 0 && bioUsed < bioHeaderCaps[difficulty] && rng.Intn(bioShare) == 0 {
+			for i := 0; i < 25; i++ {
+				id := bioPool[rng.Intn(len(bioPool))]
+				if !used[id] {
+					used[id] = true
+					bioUsed++
+					return id, nil
+				}
+			}
+		}
+		if rng.Intn(specialShare) == 0 {
+			var open []string
+			for _, kind := range specialKinds {
+				if len(pools[kind]) > 0 && kindUsed[kind] < specialHeaderCaps[kind] {
+					open = append(open, kind)
+				}
+			}
+			if len(open) > 0 {
+				kind := open[rng.Intn(len(open))]
+				ids := pools[kind]
+				for i := 0; i < 25; i++ {
+					id := ids[rng.Intn(len(ids))]
+					if !used[id] {
+						used[id] = true
+						kindUsed[kind]++
+						return id, nil
+					}
+				}
+			}
+		}
 		for i := 0; i < 25; i++ {
-			id := statIDs[rand.Intn(len(statIDs))]
+			id := statIDs[rng.Intn(len(statIDs))]
 			if !used[id] {
 				used[id] = true
 				return id, nil
@@ -132,7 +337,7 @@ func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []
 		// Row 2/3: stat criteria
 		// Col 1: player 2's favorite team (or random team if unset/duplicate)
 		// Col 2/3: stat criteria
-		r0, err := resolveFavoriteOrRandomTeam(p1Fav, used, pickRandomTeam)
+		r0, err := resolveFavoriteOrRandomTeam(rng, p1Favs, used, pickRandomTeam)
 		if err != nil {
 			return rowIDs, colIDs, err
 		}
@@ -150,7 +355,7 @@ func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []
 		}
 		rowIDs[2] = r2
 
-		c0, err := resolveFavoriteOrRandomTeam(p2Fav, used, pickRandomTeam)
+		c0, err := resolveFavoriteOrRandomTeam(rng, p2Favs, used, pickRandomTeam)
 		if err != nil {
 			return rowIDs, colIDs, err
 		}
@@ -172,7 +377,7 @@ func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []
 		// Row 1: player 1's favorite team   | Col 1: player 2's favorite team
 		// Row 2: random team                | Col 2: random team
 		// Row 3: stat criteria              | Col 3: stat criteria
-		r0, err := resolveFavoriteOrRandomTeam(p1Fav, used, pickRandomTeam)
+		r0, err := resolveFavoriteOrRandomTeam(rng, p1Favs, used, pickRandomTeam)
 		if err != nil {
 			return rowIDs, colIDs, err
 		}
@@ -190,7 +395,7 @@ func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []
 		}
 		rowIDs[2] = r2
 
-		c0, err := resolveFavoriteOrRandomTeam(p2Fav, used, pickRandomTeam)
+		c0, err := resolveFavoriteOrRandomTeam(rng, p2Favs, used, pickRandomTeam)
 		if err != nil {
 			return rowIDs, colIDs, err
 		}
@@ -209,15 +414,15 @@ func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []
 		colIDs[2] = c2
 
 	default: // "hard" — fully random, no favorite teams
-		grid_type := rand.Intn(4)
+		grid_type := rng.Intn(4)
 		var nTeamsRow, nTeamsCol int
 		if grid_type == 0 {
 			// all teams
 			nTeamsRow, nTeamsCol = 3, 3
 		} else {
 			// mixed — between 1 and 2 teams per side
-			nTeamsRow = 1 + rand.Intn(2)
-			nTeamsCol = 1 + rand.Intn(2)
+			nTeamsRow = 1 + rng.Intn(2)
+			nTeamsCol = 1 + rng.Intn(2)
 		}
 
 		fillSide := func(nTeams int) ([3]int, error) {
@@ -253,14 +458,35 @@ func buildCriteriaSets(difficulty string, p1Fav, p2Fav *int, teamIDs, statIDs []
 	return rowIDs, colIDs, nil
 }
 
-// resolveFavoriteOrRandomTeam returns the given favorite team criteria ID if
-// it's set and not already used; otherwise picks a random unused team.
-func resolveFavoriteOrRandomTeam(fav *int, used map[int]bool, pickRandomTeam func() (int, error)) (int, error) {
-	if fav != nil && !used[*fav] {
-		used[*fav] = true
-		return *fav, nil
+// resolveFavoriteOrRandomTeam draws one of the ranked favorites that
+// isn't already used — rank 1 of 3 is three times as likely as rank 3 —
+// or picks a random unused team if none are left. Skipping used teams is
+// what keeps two players who share a favorite from colliding.
+func resolveFavoriteOrRandomTeam(rng *rand.Rand, favs []int, used map[int]bool, pickRandomTeam func() (int, error)) (int, error) {
+	var open, weights []int
+	total := 0
+	for i, id := range favs {
+		if used[id] {
+			continue
+		}
+		w := len(favs) - i
+		open = append(open, id)
+		weights = append(weights, w)
+		total += w
+	}
+	if len(open) == 0 {
+		return pickRandomTeam()
 	}
-	return pickRandomTeam()
+
+	n := rng.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			used[open[i]] = true
+			return open[i], nil
+		}
+		n -= w
+	}
+	return pickRandomTeam() // unreachable
 }
 
 // cellAnswerRow mirrors a row from cell_answers before insertion.
@@ -271,36 +497,51 @@ type cellAnswerRow struct {
 	rarity      float64
 }
 
+// ShortCell is a grid cell with fewer than MinAnswersPerCell answers.
+type ShortCell struct {
+	Row     int `json:"row"`
+	Col     int `json:"col"`
+	Answers int `json:"answers"`
+}
+
 // collectCellAnswers fetches valid answers for every cell in the proposed
-// grid. Returns ok=false if any cell falls below minAnswersPerCell.
-func (s *Service) collectCellAnswers(rowIDs, colIDs [3]int) (map[[2]int][]cellAnswerRow, int, bool) {
+// grid and returns the cells that fall below MinAnswersPerCell. With
+// reportAll false it stops at the first short cell, which is all the
+// generator needs to retry.
+func (s *Service) collectCellAnswers(rowIDs, colIDs [3]int, reportAll bool) (map[[2]int][]cellAnswerRow, int, []ShortCell) {
 	cellData := make(map[[2]int][]cellAnswerRow)
 	total := 0
+	var short []ShortCell
 
 	for ri, rowC := range rowIDs {
 		for ci, colC := range colIDs {
 			answers, err := s.getValidAnswersWithRarity(rowC, colC)
-			if err != nil || len(answers) < minAnswersPerCell {
-				return nil, 0, false
+			if err != nil || len(answers) < MinAnswersPerCell {
+				short = append(short, ShortCell{Row: ri, Col: ci, Answers: len(answers)})
+				if !reportAll {
+					return nil, 0, short
+				}
+				continue
 			}
 			cellData[[2]int{ri, ci}] = answers
 			total += len(answers)
 		}
 	}
 
-	return cellData, total, true
+	return cellData, total, short
 }
 
 // getValidAnswersWithRarity finds players satisfying both criteria and
-// includes their existing rarity score (computed by rebuild_cell_answers.py
-// based on career accomplishments).
+// includes their rarity score from player_rarity (see the rarity package),
+// or 0.5 for players that haven't been scored yet.
 func (s *Service) getValidAnswersWithRarity(rowCriteriaID, colCriteriaID int) ([]cellAnswerRow, error) {
 	rows, err := s.db.Query(`
 		SELECT p.mlb_id, p.full_name, COALESCE(p.headshot_url, ''),
-		       COALESCE((SELECT rarity_score FROM cell_answers WHERE mlb_id = p.mlb_id LIMIT 1), 0.5)
+		       COALESCE(pr.rarity_score, 0.5)
 		FROM mlb_players p
 		JOIN player_criteria pc1 ON p.mlb_id = pc1.mlb_id AND pc1.criteria_id = ?
 		JOIN player_criteria pc2 ON p.mlb_id = pc2.mlb_id AND pc2.criteria_id = ?
+		LEFT JOIN player_rarity pr ON pr.mlb_id = p.mlb_id
 	`, rowCriteriaID, colCriteriaID)
 	if err != nil {
 		return nil, err
@@ -318,21 +559,28 @@ func (s *Service) getValidAnswersWithRarity(rowCriteriaID, colCriteriaID int) ([
 	return results, nil
 }
 
-// persistGeneratedGrid writes the generated grid template and its cell
-// answers to the database and returns it fully populated.
-func (s *Service) persistGeneratedGrid(rowIDs, colIDs [3]int, difficulty string, totalAnswers int, cellData map[[2]int][]cellAnswerRow) (*GridTemplate, error) {
-	dbDifficulty := difficulty
-	if dbDifficulty != "easy" && dbDifficulty != "medium" && dbDifficulty != "hard" {
-		dbDifficulty = "medium" // "regular" maps to the medium column value
+// dbDifficulty maps a room difficulty onto the grid_templates.difficulty
+// column values.
+func dbDifficulty(difficulty string) string {
+	if difficulty != "easy" && difficulty != "medium" && difficulty != "hard" {
+		return "medium" // "regular" maps to the medium column value
 	}
+	return difficulty
+}
 
+// persistGeneratedGrid writes the generated grid template and its cell
+// answers to the database and returns it fully populated. source is
+// recorded in grid_templates.source ("generated", "curated" or "custom");
+// custom templates are stored inactive so GetRandomGrid never picks them.
+func (s *Service) persistGeneratedGrid(rowIDs, colIDs [3]int, difficulty, source string, totalAnswers int, cellData map[[2]int][]cellAnswerRow) (*GridTemplate, error) {
 	res, err := s.db.Exec(`
 		INSERT INTO grid_templates
 		(row_criteria_1, row_criteria_2, row_criteria_3,
 		 col_criteria_1, col_criteria_2, col_criteria_3,
-		 min_answers, difficulty, active)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, TRUE)
-	`, rowIDs[0], rowIDs[1], rowIDs[2], colIDs[0], colIDs[1], colIDs[2], totalAnswers, dbDifficulty)
+		 min_answers, difficulty, source, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rowIDs[0], rowIDs[1], rowIDs[2], colIDs[0], colIDs[1], colIDs[2], totalAnswers, dbDifficulty(difficulty), source,
+		source != sourceCustom)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert generated grid template: %w", err)
 	}
@@ -358,8 +606,13 @@ func (s *Service) persistGeneratedGrid(rowIDs, colIDs [3]int, difficulty string,
 		}
 	}
 
+	return s.newTemplate(gridID, rowIDs, colIDs, difficulty)
+}
+
+// newTemplate fills in a template's criteria from their IDs.
+func (s *Service) newTemplate(id int, rowIDs, colIDs [3]int, difficulty string) (*GridTemplate, error) {
 	gt := &GridTemplate{
-		ID:         gridID,
+		ID:         id,
 		Difficulty: difficulty,
 	}
 
@@ -0,0 +1,134 @@
+package grid
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// testPools is a fixed dataset: IDs 1xx are teams, 2xx career stats,
+// 3xx awards, 4xx eras, 5xx seasons, 6xx teammates, 7xx positions and
+// 8xx other biography criteria.
+func testPools() criteriaPools {
+	pools := criteriaPools{}
+	add := func(cType string, base, n int) {
+		for i := 0; i < n; i++ {
+			pools[cType] = append(pools[cType], base+i)
+		}
+	}
+	add("team", 100, 30)
+	add("stat", 200, 20)
+	add("award", 300, 6)
+	add("era", 400, 5)
+	add("season", 500, 8)
+	add("teammate", 600, 4)
+	add("position", 700, 6)
+	add("bio", 800, 6)
+	return pools
+}
+
+func TestBuildCriteriaSetsSeeded(t *testing.T) {
+	tests := []struct {
+		difficulty     string
+		p1Favs, p2Favs []int
+	}{
+		{"easy", nil, nil},
+		{"easy", []int{101, 102}, []int{110}},
+		{"regular", []int{101}, []int{101, 105}},
+		{"hard", nil, nil},
+	}
+	pools := testPools()
+
+	build := func(t *testing.T, seed int64, difficulty string, p1Favs, p2Favs []int) ([3]int, [3]int) {
+		t.Helper()
+		rowIDs, colIDs, err := buildCriteriaSets(rand.New(rand.NewSource(seed)), difficulty, p1Favs, p2Favs, pools)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		return rowIDs, colIDs
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.difficulty, func(t *testing.T) {
+			for seed := int64(1); seed <= 50; seed++ {
+				rows, cols := build(t, seed, tt.difficulty, tt.p1Favs, tt.p2Favs)
+				for run := 0; run < 3; run++ {
+					r, c := build(t, seed, tt.difficulty, tt.p1Favs, tt.p2Favs)
+					if r != rows || c != cols {
+						t.Fatalf("seed %d run %d: got %v × %v, first run gave %v × %v", seed, run, r, c, rows, cols)
+					}
+				}
+
+				seen := map[int]bool{}
+				for _, id := range append(rows[:], cols[:]...) {
+					if seen[id] {
+						t.Fatalf("seed %d: criteria %d used twice in %v × %v", seed, id, rows, cols)
+					}
+					seen[id] = true
+				}
+			}
+
+			// Different seeds spread over different grids
+			distinct := map[[6]int]bool{}
+			for seed := int64(1); seed <= 50; seed++ {
+				rows, cols := build(t, seed, tt.difficulty, tt.p1Favs, tt.p2Favs)
+				distinct[[6]int{rows[0], rows[1], rows[2], cols[0], cols[1], cols[2]}] = true
+			}
+			if len(distinct) < 40 {
+				t.Errorf("50 seeds gave only %d distinct grids", len(distinct))
+			}
+
+			r1, c1 := build(t, 1, tt.difficulty, tt.p1Favs, tt.p2Favs)
+			r2, c2 := build(t, 2, tt.difficulty, tt.p1Favs, tt.p2Favs)
+			if r1 == r2 && c1 == c2 {
+				t.Errorf("seeds 1 and 2 both gave %v × %v", r1, c1)
+			}
+		})
+	}
+}
+
+func TestBuildCriteriaSetsFavorites(t *testing.T) {
+	pools := testPools()
+	for seed := int64(1); seed <= 50; seed++ {
+		rows, cols, err := buildCriteriaSets(rand.New(rand.NewSource(seed)), "regular", []int{101}, []int{101, 105}, pools)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Player 1 is drawn first and takes the shared favorite, so player 2
+		// gets their second one unless a random team took it already
+		if rows[0] != 101 || (cols[0] != 105 && rows[1] != 105) {
+			t.Errorf("seed %d: favorites gave %v × %v; want row 101, col 105", seed, rows, cols)
+		}
+	}
+}
+
+func TestBuildCriteriaSetsCaps(t *testing.T) {
+	pools := testPools()
+	inRange := func(id, base int) bool { return id >= base && id < base+100 }
+	for seed := int64(1); seed <= 200; seed++ {
+		for _, difficulty := range []string{"easy", "regular", "hard"} {
+			rows, cols, err := buildCriteriaSets(rand.New(rand.NewSource(seed)), difficulty, nil, nil, pools)
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := map[int]int{}
+			for _, id := range append(rows[:], cols[:]...) {
+				counts[id/100*100]++
+			}
+			bio := counts[700] + counts[800]
+			if bio > bioHeaderCaps[difficulty] {
+				t.Errorf("%s seed %d: %d bio headers, cap %d", difficulty, seed, bio, bioHeaderCaps[difficulty])
+			}
+			if difficulty == "easy" && counts[800] > 0 {
+				t.Errorf("easy seed %d: non-position bio header in %v × %v", seed, rows, cols)
+			}
+			for kind, base := range map[string]int{"award": 300, "era": 400, "season": 500, "teammate": 600} {
+				if counts[base] > specialHeaderCaps[kind] {
+					t.Errorf("%s seed %d: %d %s headers, cap %d", difficulty, seed, counts[base], kind, specialHeaderCaps[kind])
+				}
+			}
+			if !inRange(rows[0], 100) || !inRange(cols[0], 100) {
+				t.Errorf("%s seed %d: first headers %d, %d aren't teams", difficulty, seed, rows[0], cols[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package grid
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+)
+
+// ErrDataVersionMismatch is returned by RebuildGrid when the grid was
+// never stored and the criteria data has changed since it was generated.
+var ErrDataVersionMismatch = errors.New("criteria data has changed since that grid was generated")
+
+// maxSeed keeps seeds exact as JSON numbers in the browser (2^53).
+const maxSeed = 1 << 53
+
+// NewSeed returns a random seed for GenerateOptions. Never 0.
+func NewSeed() int64 {
+	return 1 + rand.Int63n(maxSeed-1)
+}
+
+// DataVersion returns the fingerprint of everything generation reads:
+// the criteria rows and which players satisfy each. Two databases with
+// the same version generate the same grid from the same seed. It's read
+// from grid_data_version, which RefreshDataVersion keeps current, and
+// only computed here the first time.
+func (s *Service) DataVersion() (string, error) {
+	var version string
+	err := s.db.QueryRow(`SELECT version FROM grid_data_version WHERE id = 1`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return RefreshDataVersion(s.db)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read data version: %w", err)
+	}
+	return version, nil
+}
+
+// RefreshDataVersion recomputes the data version and stores it for
+// DataVersion. Call it after anything changes criteria or
+// player_criteria: an ingest, a criteria evaluation, an integrity fix.
+func RefreshDataVersion(db *sql.DB) (string, error) {
+	rows, err := db.Query(`
+		SELECT c.id, c.type, COALESCE(c.stat_group, ''), COALESCE(c.bio_field, ''), COALESCE(c.bio_value, ''),
+		       COUNT(pc.mlb_id), COALESCE(SUM(pc.mlb_id), 0)
+		FROM criteria c
+		LEFT JOIN player_criteria pc ON pc.criteria_id = c.id
+		GROUP BY c.id, c.type, c.stat_group, c.bio_field, c.bio_value
+		ORDER BY c.id
+	`)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute data version: %w", err)
+	}
+	defer rows.Close()
+
+	h := fnv.New64a()
+	for rows.Next() {
+		var id, links int
+		var idSum int64
+		var cType, statGroup, bioField, bioValue string
+		if err := rows.Scan(&id, &cType, &statGroup, &bioField, &bioValue, &links, &idSum); err != nil {
+			return "", fmt.Errorf("failed to scan data version row: %w", err)
+		}
+		fmt.Fprintf(h, "%d|%s|%s|%s|%s|%d|%d\n", id, cType, statGroup, bioField, bioValue, links, idSum)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	version := fmt.Sprintf("%016x", h.Sum64())
+
+	if _, err := db.Exec(`
+		INSERT INTO grid_data_version (id, version) VALUES (1, ?)
+		ON DUPLICATE KEY UPDATE version = VALUES(version)
+	`, version); err != nil {
+		return "", fmt.Errorf("failed to store data version: %w", err)
+	}
+	return version, nil
+}
+
+// RebuildGrid returns the grid GenerateGrid built for opts.Seed, without
+// storing anything. A template recorded for that seed, filters and data
+// version is returned as is — however much the data has changed since;
+// otherwise the grid is drawn again, which is only faithful if the data
+// hasn't changed since. A grid drawn again that no template matches is
+// returned with ID 0.
+func (s *Service) RebuildGrid(difficulty string, p1Favs, p2Favs []int, opts GenerateOptions, dataVersion string) (*GridTemplate, error) {
+	seed := opts.Seed
+	query := `
+		SELECT gt.id FROM grid_seeds gs
+		JOIN grid_templates gt ON gt.id = gs.grid_template_id
+		WHERE gs.seed = ? AND gs.data_version = ? AND gs.filters = ? AND gt.difficulty = ?`
+	args := []interface{}{seed, dataVersion, opts.Filters.key(), dbDifficulty(difficulty)}
+	if len(p1Favs) > 0 {
+		query += ` AND gt.row_criteria_1 IN (?` + strings.Repeat(", ?", len(p1Favs)-1) + `)`
+		for _, id := range p1Favs {
+			args = append(args, id)
+		}
+	}
+	if len(p2Favs) > 0 {
+		query += ` AND gt.col_criteria_1 IN (?` + strings.Repeat(", ?", len(p2Favs)-1) + `)`
+		for _, id := range p2Favs {
+			args = append(args, id)
+		}
+	}
+
+	var id int
+	err := s.db.QueryRow(query+` ORDER BY gt.id LIMIT 1`, args...).Scan(&id)
+	if err == nil {
+		return s.seededTemplate(id, seed, dataVersion)
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to look up seeded grid: %w", err)
+	}
+
+	current, err := s.DataVersion()
+	if err != nil {
+		return nil, err
+	}
+	if current != dataVersion {
+		return nil, ErrDataVersionMismatch
+	}
+	d, err := s.drawGrid(difficulty, p1Favs, p2Favs, opts)
+	if err != nil {
+		return nil, err
+	}
+	if id, err = s.findGeneratedGrid(d.rowIDs, d.colIDs, difficulty, dataVersion); err != nil {
+		return nil, err
+	}
+	if id != 0 {
+		return s.seededTemplate(id, seed, dataVersion)
+	}
+	gt, err := s.newTemplate(0, d.rowIDs, d.colIDs, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	gt.Seed = seed
+	gt.DataVersion = dataVersion
+	return gt, nil
+}
+
+// findGeneratedGrid returns an existing generated template with exactly
+// these criteria, so regenerating from a seed doesn't pile up duplicates.
+// Returns 0 if there isn't one.
+func (s *Service) findGeneratedGrid(rowIDs, colIDs [3]int, difficulty, dataVersion string) (int, error) {
+	var id int
+	err := s.db.QueryRow(`
+		SELECT id FROM grid_templates
+		WHERE row_criteria_1 = ? AND row_criteria_2 = ? AND row_criteria_3 = ?
+		  AND col_criteria_1 = ? AND col_criteria_2 = ? AND col_criteria_3 = ?
+		  AND difficulty = ? AND data_version = ? AND source = ?
+		ORDER BY id LIMIT 1
+	`, rowIDs[0], rowIDs[1], rowIDs[2], colIDs[0], colIDs[1], colIDs[2],
+		dbDifficulty(difficulty), dataVersion, sourceGenerated).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up generated grid: %w", err)
+	}
+	return id, nil
+}
+
+// recordSeed maps seed, under filters, to a template in grid_seeds and
+// returns the template as that seed's grid. A freshly persisted template also takes
+// the seed and data version as its own, which findGeneratedGrid matches
+// on; an existing one keeps those it was first built with.
+func (s *Service) recordSeed(id int, seed int64, dataVersion string, filters GridFilters) (*GridTemplate, error) {
+	if _, err := s.db.Exec(`
+		INSERT IGNORE INTO grid_seeds (seed, data_version, filters, grid_template_id) VALUES (?, ?, ?, ?)
+	`, seed, dataVersion, filters.key(), id); err != nil {
+		return nil, fmt.Errorf("failed to record seed for grid template %d: %w", id, err)
+	}
+	if _, err := s.db.Exec(`
+		UPDATE grid_templates
+		SET seed = COALESCE(seed, ?), data_version = COALESCE(data_version, ?)
+		WHERE id = ?
+	`, seed, dataVersion, id); err != nil {
+		return nil, fmt.Errorf("failed to record seed for grid template %d: %w", id, err)
+	}
+	return s.seededTemplate(id, seed, dataVersion)
+}
+
+func (s *Service) seededTemplate(id int, seed int64, dataVersion string) (*GridTemplate, error) {
+	t, err := s.GetTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	gt := t.GridTemplate
+	gt.Seed = seed
+	gt.DataVersion = dataVersion
+	return &gt, nil
+}
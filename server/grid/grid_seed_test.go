@@ -0,0 +1,25 @@
+package grid
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"trivia-server/internal/fakesql"
+)
+
+// A seed that can't be reproduced is refused without anything stored.
+func TestRebuildGridWritesNothing(t *testing.T) {
+	db, fake := fakesql.Open(t,
+		"FROM grid_data_version", fakesql.Result{Columns: []string{"version"}, Rows: [][]driver.Value{{"0123456789abcdef"}}},
+	)
+	s := NewService(db)
+
+	opts := GenerateOptions{Seed: 8675309, Filters: GridFilters{League: "AL"}}
+	_, err := s.RebuildGrid("hard", nil, nil, opts, "fedcba9876543210")
+	if !errors.Is(err, ErrDataVersionMismatch) {
+		t.Fatalf("RebuildGrid = %v, want ErrDataVersionMismatch", err)
+	}
+	if execs := fake.Executed(""); len(execs) != 0 {
+		t.Errorf("RebuildGrid wrote %v", execs)
+	}
+}
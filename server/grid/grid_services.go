@@ -12,11 +12,12 @@ import (
 // ═══════════════════════════════════════════════════════════
 
 type Criteria struct {
-	ID         int    `json:"id"`
-	Type       string `json:"type"`
-	Label      string `json:"label"`
-	ShortLabel string `json:"short_label"`
-	MlbTeamID  *int   `json:"mlb_team_id,omitempty"` // optional, only for team-based criteria
+	ID         int     `json:"id"`
+	Type       string  `json:"type"`
+	Label      string  `json:"label"`
+	ShortLabel string  `json:"short_label"`
+	MlbTeamID  *int    `json:"mlb_team_id,omitempty"` // optional, only for team-based criteria
+	AwardID    *string `json:"award_id,omitempty"`    // optional, only for award criteria
 }
 
 type GridTemplate struct {
@@ -24,6 +25,8 @@ type GridTemplate struct {
 	RowCriteria []Criteria `json:"row_criteria"` // 3 items
 	ColCriteria []Criteria `json:"col_criteria"` // 3 items
 	Difficulty  string     `json:"difficulty"`
+	Seed        int64      `json:"seed,omitempty"`         // set for generated grids
+	DataVersion string     `json:"data_version,omitempty"` // see Service.DataVersion
 }
 
 type CellAnswer struct {
@@ -52,8 +55,9 @@ func NewService(db *sql.DB) *Service {
 	return &Service{db: db}
 }
 
-// GetRandomGrid picks a random active grid template from the database
-func (s *Service) GetRandomGrid() (*GridTemplate, error) {
+// GetRandomGrid picks a random active grid template from the database,
+// drawing from rng so a seeded caller always gets the same template
+func (s *Service) GetRandomGrid(rng *rand.Rand) (*GridTemplate, error) {
 	// Get count of available grids
 	var count int
 	err := s.db.QueryRow("SELECT COUNT(*) FROM grid_templates WHERE active = TRUE").Scan(&count)
@@ -62,7 +66,7 @@ func (s *Service) GetRandomGrid() (*GridTemplate, error) {
 	}
 
 	// Pick a random offset
-	offset := rand.Intn(count)
+	offset := rng.Intn(count)
 
 	var gt GridTemplate
 	var rowIDs [3]int
@@ -73,6 +77,7 @@ func (s *Service) GetRandomGrid() (*GridTemplate, error) {
 		       col_criteria_1, col_criteria_2, col_criteria_3, difficulty
 		FROM grid_templates
 		WHERE active = TRUE
+		ORDER BY id
 		LIMIT 1 OFFSET ?
 	`, offset).Scan(
 		&gt.ID,
@@ -111,12 +116,13 @@ func (s *Service) getCriteria(id int) (*Criteria, error) {
 	c := &Criteria{}
 	err := s.db.QueryRow(`
 		SELECT id, type, label, COALESCE(short_label, label),
-				mlb_team_id
+				mlb_team_id, award_id
 		FROM criteria WHERE id = ?
-	`, id).Scan(&c.ID, &c.Type, &c.Label, &c.ShortLabel, &c.MlbTeamID)
+	`, id).Scan(&c.ID, &c.Type, &c.Label, &c.ShortLabel, &c.MlbTeamID, &c.AwardID)
 	if err != nil {
 		return nil, fmt.Errorf("criteria %d not found: %w", id, err)
 	}
+	applyCriteriaWording(c)
 	return c, nil
 }
 
@@ -125,15 +131,19 @@ func (s *Service) getCriteria(id int) (*Criteria, error) {
 func (s *Service) ValidateAnswer(gridTemplateID, rowIndex, colIndex, mlbID int, playerName string) (*ValidationResult, error) {
 	result := &ValidationResult{}
 
-	// Look up the answer in pre-computed cell_answers table
+	// Look up the answer in pre-computed cell_answers table. Rarity comes
+	// from player_rarity so it's the same on every grid, falling back to
+	// the copy stored with the cell for unscored players.
 	var answer CellAnswer
 	err := s.db.QueryRow(`
-		SELECT mlb_id, player_name, COALESCE(headshot_url, ''), rarity_score
-		FROM cell_answers
-		WHERE grid_template_id = ?
-		  AND row_index = ?
-		  AND col_index = ?
-		  AND (mlb_id = ? OR LOWER(player_name) = LOWER(?))
+		SELECT ca.mlb_id, ca.player_name, COALESCE(ca.headshot_url, ''),
+		       COALESCE(pr.rarity_score, ca.rarity_score)
+		FROM cell_answers ca
+		LEFT JOIN player_rarity pr ON pr.mlb_id = ca.mlb_id
+		WHERE ca.grid_template_id = ?
+		  AND ca.row_index = ?
+		  AND ca.col_index = ?
+		  AND (ca.mlb_id = ? OR LOWER(ca.player_name) = LOWER(?))
 	`, gridTemplateID, rowIndex, colIndex, mlbID, playerName).Scan(
 		&answer.MlbID,
 		&answer.PlayerName,
@@ -168,10 +178,12 @@ func (s *Service) ValidateAnswer(gridTemplateID, rowIndex, colIndex, mlbID int,
 // GetCellAnswers returns all valid answers for a cell (for debugging/admin)
 func (s *Service) GetCellAnswers(gridTemplateID, rowIndex, colIndex int) ([]CellAnswer, error) {
 	rows, err := s.db.Query(`
-		SELECT mlb_id, player_name, COALESCE(headshot_url, ''), rarity_score
-		FROM cell_answers
-		WHERE grid_template_id = ? AND row_index = ? AND col_index = ?
-		ORDER BY rarity_score ASC
+		SELECT ca.mlb_id, ca.player_name, COALESCE(ca.headshot_url, ''),
+		       COALESCE(pr.rarity_score, ca.rarity_score) AS rarity
+		FROM cell_answers ca
+		LEFT JOIN player_rarity pr ON pr.mlb_id = ca.mlb_id
+		WHERE ca.grid_template_id = ? AND ca.row_index = ? AND ca.col_index = ?
+		ORDER BY rarity ASC
 	`, gridTemplateID, rowIndex, colIndex)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,89 @@
+package grid
+
+import (
+	"log"
+	"time"
+)
+
+// poolDifficulties are the difficulties the pool keeps grids ready for.
+var poolDifficulties = []string{"easy", "regular", "hard"}
+
+// poolRetryDelay is how long a worker waits after a failed generation
+// before trying again, so an empty database doesn't spin.
+const poolRetryDelay = 30 * time.Second
+
+// Pool keeps a buffer of validated, ready-to-play grids per difficulty so
+// starting a game never waits on generation. Pool grids have no favorite
+// teams, seed or filters; games that need them call GenerateGrid directly.
+type Pool struct {
+	svc   *Service
+	ready map[string]chan *GridTemplate
+	stop  chan struct{}
+}
+
+// NewPool creates a pool holding up to size grids per difficulty. Call
+// Start to begin filling it.
+func NewPool(svc *Service, size int) *Pool {
+	if size <= 0 {
+		size = 1
+	}
+	p := &Pool{
+		svc:   svc,
+		ready: make(map[string]chan *GridTemplate, len(poolDifficulties)),
+		stop:  make(chan struct{}),
+	}
+	for _, d := range poolDifficulties {
+		p.ready[d] = make(chan *GridTemplate, size)
+	}
+	return p
+}
+
+// Start runs one worker per difficulty. Each keeps its buffer full,
+// generating a replacement as soon as a grid is taken.
+func (p *Pool) Start() {
+	for _, d := range poolDifficulties {
+		go p.fill(d)
+	}
+}
+
+// Stop ends the workers. Grids already in the buffers stay takeable.
+func (p *Pool) Stop() {
+	close(p.stop)
+}
+
+// Take returns a ready grid of exactly that difficulty, or false if the
+// buffer is empty right now.
+func (p *Pool) Take(difficulty string) (*GridTemplate, bool) {
+	ch, ok := p.ready[difficulty]
+	if !ok {
+		return nil, false
+	}
+	select {
+	case gt := <-ch:
+		return gt, true
+	default:
+		return nil, false
+	}
+}
+
+func (p *Pool) fill(difficulty string) {
+	for {
+		gt, err := p.svc.GenerateGrid(difficulty, nil, nil, GenerateOptions{})
+		if err != nil {
+			log.Printf("Grid pool: failed to generate %s grid: %v", difficulty, err)
+			select {
+			case <-time.After(poolRetryDelay):
+				continue
+			case <-p.stop:
+				return
+			}
+		}
+
+		// Blocks while the buffer is full, which is what tops it up
+		select {
+		case p.ready[difficulty] <- gt:
+		case <-p.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package grid
+
+import "testing"
+
+func TestPoolTake(t *testing.T) {
+	p := NewPool(nil, 2)
+	if _, ok := p.Take("easy"); ok {
+		t.Error("took a grid from an empty pool")
+	}
+
+	p.ready["hard"] <- &GridTemplate{ID: 1, Difficulty: "hard"}
+	p.ready["hard"] <- &GridTemplate{ID: 2, Difficulty: "hard"}
+	if cap(p.ready["hard"]) != 2 {
+		t.Errorf("buffer holds %d grids, want 2", cap(p.ready["hard"]))
+	}
+
+	// Grids only come out at the difficulty they were made for
+	if _, ok := p.Take("easy"); ok {
+		t.Error("an easy grid came from the hard buffer")
+	}
+	if _, ok := p.Take("impossible"); ok {
+		t.Error("took a grid of an unknown difficulty")
+	}
+	for _, want := range []int{1, 2} {
+		if gt, ok := p.Take("hard"); !ok || gt.ID != want {
+			t.Errorf("Take = %v, %v; want grid %d", gt, ok, want)
+		}
+	}
+	if _, ok := p.Take("hard"); ok {
+		t.Error("took more grids than were ready")
+	}
+
+	if p := NewPool(nil, 0); cap(p.ready["regular"]) != 1 {
+		t.Errorf("a pool of size 0 holds %d grids, want 1", cap(p.ready["regular"]))
+	}
+}
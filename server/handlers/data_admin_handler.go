@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"trivia-server/integrity"
+	"trivia-server/rarity"
+
+	"github.com/gorilla/mux"
+)
+
+// DataAdminHandler serves the admin maintenance jobs that the CLI
+// subcommands also run.
+type DataAdminHandler struct {
+	db *sql.DB
+
+	// rebuild is rarity.Rebuild, run in the background by RebuildRarity
+	rebuild func(db *sql.DB, batchSize int) (*rarity.Result, error)
+
+	mu     sync.Mutex
+	rarity RarityJobStatus
+}
+
+func NewDataAdminHandler(db *sql.DB) *DataAdminHandler {
+	return &DataAdminHandler{db: db, rebuild: rarity.Rebuild}
+}
+
+type RebuildRarityRequest struct {
+	BatchSize int `json:"batch_size"` // templates per transaction, default 50
+}
+
+// RarityJobStatus is the state of the last rarity rebuild.
+type RarityJobStatus struct {
+	Running    bool           `json:"running"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Result     *rarity.Result `json:"result,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// RebuildRarity handles POST /api/admin/rarity/rebuild
+// Body (optional): {"batch_size": 50}
+// Starts recomputing player_rarity and rebuilding cell_answers for every
+// active template in the background and answers 202 with the job's
+// status, or 409 if a rebuild is already running. Poll
+// GET /api/admin/rarity/rebuild for the outcome.
+func (h *DataAdminHandler) RebuildRarity(w http.ResponseWriter, r *http.Request) {
+	var req RebuildRarityRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.mu.Lock()
+	if h.rarity.Running {
+		status := h.rarity
+		h.mu.Unlock()
+		writeJSON(w, http.StatusConflict, status)
+		return
+	}
+	now := time.Now()
+	h.rarity = RarityJobStatus{Running: true, StartedAt: &now}
+	status := h.rarity
+	h.mu.Unlock()
+
+	go h.runRarityRebuild(req.BatchSize)
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+func (h *DataAdminHandler) runRarityRebuild(batchSize int) {
+	result, err := h.rebuild(h.db, batchSize)
+	if err != nil {
+		log.Printf("Error rebuilding rarity: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	h.rarity.Running = false
+	h.rarity.FinishedAt = &now
+	h.rarity.Result = result
+	if err != nil {
+		h.rarity.Error = err.Error()
+	}
+}
+
+// RarityRebuildStatus handles GET /api/admin/rarity/rebuild
+// Returns whether a rebuild is running and how the last one went.
+func (h *DataAdminHandler) RarityRebuildStatus(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	status := h.rarity
+	h.mu.Unlock()
+	writeJSON(w, http.StatusOK, status)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// CheckIntegrity handles GET /api/admin/integrity
+// Reports drift between criteria, player links, cell answers and
+// templates without changing anything.
+func (h *DataAdminHandler) CheckIntegrity(w http.ResponseWriter, r *http.Request) {
+	h.runIntegrity(w, false)
+}
+
+// FixIntegrity handles POST /api/admin/integrity/fix
+// Same report as CheckIntegrity, with every repairable problem fixed in
+// one transaction.
+func (h *DataAdminHandler) FixIntegrity(w http.ResponseWriter, r *http.Request) {
+	h.runIntegrity(w, true)
+}
+
+func (h *DataAdminHandler) runIntegrity(w http.ResponseWriter, fix bool) {
+	report, err := integrity.Check(h.db, fix)
+	if err != nil {
+		log.Printf("Error running integrity check (fix=%v): %v", fix, err)
+		http.Error(w, "Integrity check failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// RegisterDataAdminRoutes sets up the maintenance routes on a router
+// that already requires an authenticated admin.
+func (h *DataAdminHandler) RegisterDataAdminRoutes(r *mux.Router) {
+	r.HandleFunc("/rarity/rebuild", h.RebuildRarity).Methods("POST")
+	r.HandleFunc("/rarity/rebuild", h.RarityRebuildStatus).Methods("GET")
+	r.HandleFunc("/integrity", h.CheckIntegrity).Methods("GET")
+	r.HandleFunc("/integrity/fix", h.FixIntegrity).Methods("POST")
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"trivia-server/rarity"
+)
+
+// fakeRebuild stands in for rarity.Rebuild, finishing when release is
+// closed.
+type fakeRebuild struct {
+	calls   chan int // batch sizes, as each run starts
+	release chan struct{}
+	err     error
+}
+
+func (f *fakeRebuild) run(db *sql.DB, batchSize int) (*rarity.Result, error) {
+	f.calls <- batchSize
+	<-f.release
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &rarity.Result{Players: 3, Templates: 2, Answers: 40}, nil
+}
+
+func rarityRequest(t *testing.T, h *DataAdminHandler, method, body string) (int, RarityJobStatus) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/rarity/rebuild", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	if method == http.MethodPost {
+		h.RebuildRarity(rec, req)
+	} else {
+		h.RarityRebuildStatus(rec, req)
+	}
+	var status RarityJobStatus
+	if rec.Code != http.StatusBadRequest {
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("%s: invalid status JSON: %v", method, err)
+		}
+	}
+	return rec.Code, status
+}
+
+// waitIdle polls the status endpoint until the job is done.
+func waitIdle(t *testing.T, h *DataAdminHandler) RarityJobStatus {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		h.mu.Lock()
+		running := h.rarity.Running
+		h.mu.Unlock()
+		if !running {
+			_, status := rarityRequest(t, h, http.MethodGet, "")
+			return status
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("rebuild never finished")
+	return RarityJobStatus{}
+}
+
+func TestRebuildRarityRunsInBackground(t *testing.T) {
+	fake := &fakeRebuild{calls: make(chan int, 2), release: make(chan struct{})}
+	h := &DataAdminHandler{rebuild: fake.run}
+
+	code, status := rarityRequest(t, h, http.MethodPost, `{"batch_size": 10}`)
+	if code != http.StatusAccepted || !status.Running || status.StartedAt == nil {
+		t.Fatalf("start = %d %+v, want 202 and running", code, status)
+	}
+	if got := <-fake.calls; got != 10 {
+		t.Errorf("batch size = %d, want 10", got)
+	}
+
+	// Only one rebuild at a time
+	if code, status := rarityRequest(t, h, http.MethodPost, ""); code != http.StatusConflict || !status.Running {
+		t.Errorf("second start = %d %+v, want 409 and running", code, status)
+	}
+	if code, status := rarityRequest(t, h, http.MethodGet, ""); code != http.StatusOK || !status.Running {
+		t.Errorf("status = %d %+v, want running", code, status)
+	}
+
+	close(fake.release)
+	status = waitIdle(t, h)
+	if status.FinishedAt == nil || status.Error != "" || status.Result == nil || status.Result.Answers != 40 {
+		t.Errorf("finished status = %+v", status)
+	}
+
+	// and another can start once it's done
+	if code, _ := rarityRequest(t, h, http.MethodPost, ""); code != http.StatusAccepted {
+		t.Errorf("restart = %d, want 202", code)
+	}
+	<-fake.calls
+	waitIdle(t, h)
+}
+
+func TestRebuildRarityFailure(t *testing.T) {
+	fake := &fakeRebuild{calls: make(chan int, 1), release: make(chan struct{}), err: errors.New("deadlock")}
+	close(fake.release)
+	h := &DataAdminHandler{rebuild: fake.run}
+
+	if code, _ := rarityRequest(t, h, http.MethodPost, ""); code != http.StatusAccepted {
+		t.Fatalf("start = %d, want 202", code)
+	}
+	<-fake.calls
+	if status := waitIdle(t, h); status.Error != "deadlock" || status.Result != nil {
+		t.Errorf("failed status = %+v, want the error", status)
+	}
+}
+
+func TestRebuildRarityInvalidBody(t *testing.T) {
+	h := &DataAdminHandler{rebuild: (&fakeRebuild{}).run}
+	if code, _ := rarityRequest(t, h, http.MethodPost, `{"batch_size":`); code != http.StatusBadRequest {
+		t.Errorf("invalid body = %d, want 400", code)
+	}
+	if h.rarity.Running {
+		t.Error("an invalid request started a rebuild")
+	}
+}
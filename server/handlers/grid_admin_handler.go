@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"trivia-server/grid"
+
+	"github.com/gorilla/mux"
+)
+
+type GridAdminHandler struct {
+	gridService *grid.Service
+}
+
+func NewGridAdminHandler(gridService *grid.Service) *GridAdminHandler {
+	return &GridAdminHandler{gridService: gridService}
+}
+
+type CreateTemplateRequest struct {
+	Difficulty  string `json:"difficulty"`
+	RowCriteria []int  `json:"row_criteria"` // 3 criteria IDs
+	ColCriteria []int  `json:"col_criteria"` // 3 criteria IDs
+}
+
+type SetActiveRequest struct {
+	Active bool `json:"active"`
+}
+
+type ScheduleRequest struct {
+	GridTemplateID int `json:"grid_template_id"`
+}
+
+// ListTemplates handles GET /api/admin/grids
+// Query: ?difficulty=hard&criteria_id=12&active=true&limit=50&offset=0
+func (h *GridAdminHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := grid.TemplateFilter{Difficulty: q.Get("difficulty")}
+
+	if v := q.Get("criteria_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid criteria_id", http.StatusBadRequest)
+			return
+		}
+		filter.CriteriaID = id
+	}
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid active flag", http.StatusBadRequest)
+			return
+		}
+		filter.Active = &active
+	}
+	filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(q.Get("offset"))
+
+	templates, err := h.gridService.ListTemplates(filter)
+	if err != nil {
+		log.Printf("Error listing grid templates: %v", err)
+		http.Error(w, "Failed to list grid templates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// PreviewTemplate handles GET /api/admin/grids/{id}
+func (h *GridAdminHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.gridService.PreviewTemplate(id)
+	if errors.Is(err, grid.ErrTemplateNotFound) {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error previewing grid template %d: %v", id, err)
+		http.Error(w, "Failed to preview template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// CreateTemplate handles POST /api/admin/grids
+// Body: {"difficulty": "hard", "row_criteria": [1, 2, 3], "col_criteria": [4, 5, 6]}
+func (h *GridAdminHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req CreateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.RowCriteria) != 3 || len(req.ColCriteria) != 3 {
+		http.Error(w, "row_criteria and col_criteria must each have 3 IDs", http.StatusBadRequest)
+		return
+	}
+	if req.Difficulty == "" {
+		req.Difficulty = "regular"
+	}
+
+	var rowIDs, colIDs [3]int
+	copy(rowIDs[:], req.RowCriteria)
+	copy(colIDs[:], req.ColCriteria)
+
+	gt, err := h.gridService.CreateTemplate(req.Difficulty, rowIDs, colIDs)
+	if errors.Is(err, grid.ErrInvalidGrid) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating grid template: %v", err)
+		http.Error(w, "Failed to create template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(gt)
+}
+
+// SetTemplateActive handles PUT /api/admin/grids/{id}/active
+// Body: {"active": false}
+func (h *GridAdminHandler) SetTemplateActive(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SetActiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	err = h.gridService.SetTemplateActive(id, req.Active)
+	if errors.Is(err, grid.ErrTemplateNotFound) {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating grid template %d: %v", id, err)
+		http.Error(w, "Failed to update template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":     id,
+		"active": req.Active,
+	})
+}
+
+// ListSchedule handles GET /api/admin/schedule
+// Query: ?from=2026-01-01&to=2026-01-31 (defaults to the next 30 days)
+func (h *GridAdminHandler) ListSchedule(w http.ResponseWriter, r *http.Request) {
+	from := time.Now()
+	to := from.AddDate(0, 0, 30)
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = d
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = d
+	}
+
+	schedule, err := h.gridService.ListSchedule(from, to)
+	if err != nil {
+		log.Printf("Error listing grid schedule: %v", err)
+		http.Error(w, "Failed to list schedule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"schedule": schedule,
+	})
+}
+
+// ScheduleTemplate handles PUT /api/admin/schedule/{date}
+// Body: {"grid_template_id": 42}
+func (h *GridAdminHandler) ScheduleTemplate(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse("2006-01-02", mux.Vars(r)["date"])
+	if err != nil {
+		http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	err = h.gridService.ScheduleTemplate(req.GridTemplateID, date)
+	if errors.Is(err, grid.ErrTemplateNotFound) {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error scheduling grid template %d: %v", req.GridTemplateID, err)
+		http.Error(w, "Failed to schedule template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grid.ScheduledGrid{
+		Date:           date.Format("2006-01-02"),
+		GridTemplateID: req.GridTemplateID,
+	})
+}
+
+// UnscheduleDate handles DELETE /api/admin/schedule/{date}
+func (h *GridAdminHandler) UnscheduleDate(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse("2006-01-02", mux.Vars(r)["date"])
+	if err != nil {
+		http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.gridService.UnscheduleDate(date); err != nil {
+		log.Printf("Error clearing grid schedule: %v", err)
+		http.Error(w, "Failed to clear schedule", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterGridAdminRoutes sets up the grid curation routes on a router
+// that already requires an authenticated admin.
+func (h *GridAdminHandler) RegisterGridAdminRoutes(r *mux.Router) {
+	r.HandleFunc("/grids", h.ListTemplates).Methods("GET")
+	r.HandleFunc("/grids", h.CreateTemplate).Methods("POST")
+	r.HandleFunc("/grids/{id:[0-9]+}", h.PreviewTemplate).Methods("GET")
+	r.HandleFunc("/grids/{id:[0-9]+}/active", h.SetTemplateActive).Methods("PUT")
+	r.HandleFunc("/schedule", h.ListSchedule).Methods("GET")
+	r.HandleFunc("/schedule/{date}", h.ScheduleTemplate).Methods("PUT")
+	r.HandleFunc("/schedule/{date}", h.UnscheduleDate).Methods("DELETE")
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"trivia-server/grid"
+
+	"github.com/gorilla/mux"
+)
+
+// The requests below are all refused before the grid service is used,
+// so it has no database.
+func TestGridAdminRejectsBadInput(t *testing.T) {
+	h := NewGridAdminHandler(grid.NewService(nil))
+	r := mux.NewRouter()
+	h.RegisterGridAdminRoutes(r)
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/grids?criteria_id=abc", "", http.StatusBadRequest},
+		{"GET", "/grids?active=maybe", "", http.StatusBadRequest},
+		{"POST", "/grids", `{"row_criteria": [1, 2, 3]`, http.StatusBadRequest},
+		{"POST", "/grids", `{"row_criteria": [1, 2], "col_criteria": [4, 5, 6]}`, http.StatusBadRequest},
+		{"POST", "/grids", `{"difficulty": "impossible", "row_criteria": [1, 2, 3], "col_criteria": [4, 5, 6]}`, http.StatusUnprocessableEntity},
+		{"PUT", "/grids/7/active", `{"active":`, http.StatusBadRequest},
+		{"GET", "/schedule?from=tomorrow", "", http.StatusBadRequest},
+		{"GET", "/schedule?to=2026-13-01", "", http.StatusBadRequest},
+		{"PUT", "/schedule/2026-02-30", `{"grid_template_id": 1}`, http.StatusBadRequest},
+		{"PUT", "/schedule/2026-03-01", `{"grid_template_id":`, http.StatusBadRequest},
+		{"DELETE", "/schedule/next-week", "", http.StatusBadRequest},
+		{"GET", "/grids/abc", "", http.StatusNotFound}, // not a route
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d (%s)", tt.method, tt.path, rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"trivia-server/criteria"
+	"trivia-server/grid"
+
+	"github.com/gorilla/mux"
+)
+
+type GridHandler struct {
+	gridService *grid.Service
+}
+
+func NewGridHandler(gridService *grid.Service) *GridHandler {
+	return &GridHandler{gridService: gridService}
+}
+
+type RebuildGridRequest struct {
+	Seed        int64  `json:"seed"`
+	DataVersion string `json:"data_version"`
+	Difficulty  string `json:"difficulty"`
+	// Ranked favorite team criteria the grid was generated with, if any
+	P1TeamCriteriaIDs []int `json:"p1_team_criteria_ids,omitempty"`
+	P2TeamCriteriaIDs []int `json:"p2_team_criteria_ids,omitempty"`
+	// Room filters the grid was generated with, if any
+	Filters grid.GridFilters `json:"filters"`
+}
+
+// RebuildGrid handles POST /api/grids/rebuild
+// Body: {"seed": 8675309, "data_version": "9f86d081884c7d65", "difficulty": "hard"}
+// Returns the grid that seed produced, without storing anything: 409 if
+// the data has changed since and it was never stored, 404 if the seed
+// doesn't draw a grid at all.
+func (h *GridHandler) RebuildGrid(w http.ResponseWriter, r *http.Request) {
+	var req RebuildGridRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Seed <= 0 || req.DataVersion == "" {
+		http.Error(w, "seed and data_version are required", http.StatusBadRequest)
+		return
+	}
+	switch req.Difficulty {
+	case "easy", "regular", "hard":
+	case "":
+		req.Difficulty = "regular"
+	default:
+		http.Error(w, "Invalid difficulty", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := req.Filters.Normalize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := grid.GenerateOptions{Seed: req.Seed, Filters: filters}
+	gt, err := h.gridService.RebuildGrid(req.Difficulty, req.P1TeamCriteriaIDs, req.P2TeamCriteriaIDs, opts, req.DataVersion)
+	if errors.Is(err, grid.ErrDataVersionMismatch) || errors.Is(err, grid.ErrFiltersTooStrict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, grid.ErrGenerationFailed) {
+		http.Error(w, "That seed doesn't produce a grid", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error rebuilding grid from seed %d: %v", req.Seed, err)
+		http.Error(w, "Failed to rebuild grid", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(gt)
+}
+
+// ListCriteria handles GET /api/criteria
+// Query: ?type=team&q=cubs
+func (h *GridHandler) ListCriteria(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	entries, err := h.gridService.ListCriteria(q.Get("type"), strings.TrimSpace(q.Get("q")))
+	if err != nil {
+		log.Printf("Error listing criteria: %v", err)
+		http.Error(w, "Failed to list criteria", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"criteria": entries,
+		"count":    len(entries),
+	})
+}
+
+// ExplainAnswer handles GET /api/players/{mlbId}/criteria/{criteriaId}
+// Returns the evidence that the player satisfies the criteria: seasons
+// with the team, the career total against the threshold, award years...
+func (h *GridHandler) ExplainAnswer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	mlbID, _ := strconv.Atoi(vars["mlbId"])
+	criteriaID, _ := strconv.Atoi(vars["criteriaId"])
+
+	ex, err := h.gridService.ExplainAnswer(mlbID, criteriaID)
+	if errors.Is(err, criteria.ErrPlayerNotFound) {
+		http.Error(w, "Player not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Criteria not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error explaining criteria %d for player %d: %v", criteriaID, mlbID, err)
+		http.Error(w, "Failed to explain answer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ex)
+}
+
+// RegisterGridRoutes sets up the player-facing grid routes on a router
+// that already requires authentication.
+func (h *GridHandler) RegisterGridRoutes(r *mux.Router) {
+	r.HandleFunc("/grids/rebuild", h.RebuildGrid).Methods("POST")
+	r.HandleFunc("/criteria", h.ListCriteria).Methods("GET")
+	r.HandleFunc("/players/{mlbId:[0-9]+}/criteria/{criteriaId:[0-9]+}", h.ExplainAnswer).Methods("GET")
+}
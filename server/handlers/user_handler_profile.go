@@ -8,8 +8,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
+	"trivia-server/models"
+	"trivia-server/sessions"
 )
 
 // ── Request types ─────────────────────────────────────────
@@ -23,9 +26,8 @@ type UpdatePasswordRequest struct {
 	NewPassword     string `json:"new_password"`
 }
 
-type UpdateFavoriteTeamRequest struct {
-	TeamID   int    `json:"team_id"` // 0 clears the favorite team
-	TeamName string `json:"team_name"`
+type UpdateFavoriteTeamsRequest struct {
+	Teams []models.FavoriteTeam `json:"teams"` // best first, up to 3; empty clears
 }
 
 type CheckUsernameRequest struct {
@@ -158,21 +160,27 @@ func (uh *UserHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Password updated successfully"))
 }
 
-// ── PUT /api/profile/team ─────────────────────────────────────
-// Body: {"team_id": 147, "team_name": "New York Yankees"}
-// Send {"team_id": 0} to clear the favorite team.
+// ── PUT /api/profile/teams ────────────────────────────────────
+// Body: {"teams": [{"team_id": 147, "team_name": "New York Yankees"},
+//                  {"team_id": 111, "team_name": "Boston Red Sox"}]}
+// Ranked best first, up to three. Send {"teams": []} to clear.
 
-func (uh *UserHandler) UpdateFavoriteTeam(w http.ResponseWriter, r *http.Request) {
+func (uh *UserHandler) UpdateFavoriteTeams(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("userID").(int)
 
-	var req UpdateFavoriteTeamRequest
+	var req UpdateFavoriteTeamsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := uh.userService.UpdateFavoriteTeam(userID, req.TeamID, req.TeamName); err != nil {
-		http.Error(w, "Failed to update favorite team", http.StatusInternalServerError)
+	err := uh.userService.SetFavoriteTeams(userID, req.Teams)
+	if errors.Is(err, sessions.ErrTooManyTeams) || errors.Is(err, sessions.ErrDuplicateTeam) || errors.Is(err, sessions.ErrUnknownTeam) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update favorite teams", http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,187 @@
+package ingest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"trivia-server/criteria"
+)
+
+// awardRecipientsFile mirrors the MLB Stats API response for
+// /awards/{awardId}/recipients, which is what the dump files contain.
+type awardRecipientsFile struct {
+	Awards []struct {
+		ID     string `json:"id"`
+		Season string `json:"season"`
+		Player struct {
+			ID       int    `json:"id"`
+			FullName string `json:"fullName"`
+		} `json:"player"`
+	} `json:"awards"`
+}
+
+// AwardImportResult summarizes one ImportAwards run.
+type AwardImportResult struct {
+	Files    int            `json:"files"`
+	Players  int            `json:"players"`
+	Links    int            `json:"links"`     // new player_criteria rows
+	PerAward map[string]int `json:"per_award"` // award_id -> recipients seen
+	// FullLists holds the awards whose dir/<ID>.json, the whole
+	// recipient list, was loaded rather than only per-season files.
+	FullLists map[string]bool `json:"full_lists"`
+}
+
+type awardCriteria struct {
+	criteriaID int
+	awardID    string
+}
+
+// ImportAwards loads award winners from local JSON files into
+// mlb_player_awards and player_criteria. For every award criteria in the
+// criteria table it reads dir/<AWARD_ID>.json and any dir/<AWARD_ID>/*.json
+// (one file per season is fine, but only dir/<AWARD_ID>.json counts as the
+// full recipient list). Missing files are skipped. The whole run
+// is one transaction, and rerunning it with the same files changes nothing.
+func ImportAwards(db *sql.DB, dir string) (*AwardImportResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin award import: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := importAwards(tx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit award import: %w", err)
+	}
+
+	log.Printf("Award import: %d files, %d players, %d new links", result.Files, result.Players, result.Links)
+	return result, nil
+}
+
+// importAwards does the work of ImportAwards inside the caller's
+// transaction.
+func importAwards(tx *sql.Tx, dir string) (*AwardImportResult, error) {
+	awards, err := loadAwardCriteria(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AwardImportResult{PerAward: make(map[string]int), FullLists: make(map[string]bool)}
+	players := make(map[int]bool)
+
+	for _, a := range awards {
+		files, err := awardFiles(dir, a.awardID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range files {
+			var f awardRecipientsFile
+			if err := readJSON(path, &f); err != nil {
+				return nil, err
+			}
+			result.Files++
+			if path == filepath.Join(dir, a.awardID+".json") {
+				result.FullLists[a.awardID] = true
+			}
+
+			for _, entry := range f.Awards {
+				if entry.Player.ID == 0 {
+					continue
+				}
+				season, _ := strconv.Atoi(entry.Season) // Hall of Fame entries have no season
+
+				if !players[entry.Player.ID] {
+					if err := upsertPlayerName(tx, entry.Player.ID, entry.Player.FullName); err != nil {
+						return nil, err
+					}
+					players[entry.Player.ID] = true
+				}
+
+				if _, err := tx.Exec(`
+					INSERT IGNORE INTO mlb_player_awards (mlb_id, award_id, season)
+					VALUES (?, ?, ?)
+				`, entry.Player.ID, a.awardID, season); err != nil {
+					return nil, fmt.Errorf("failed to insert %s award for %d: %w", a.awardID, entry.Player.ID, err)
+				}
+
+				n, err := insertPlayerCriteria(tx, entry.Player.ID, a.criteriaID)
+				if err != nil {
+					return nil, err
+				}
+				result.Links += n
+				result.PerAward[a.awardID]++
+			}
+		}
+	}
+
+	result.Players = len(players)
+	return result, nil
+}
+
+// AwardIDs returns the award_id of every award criteria, which is the
+// list of recipient dumps the importer looks for.
+func AwardIDs(q criteria.Querier) ([]string, error) {
+	awards, err := loadAwardCriteria(q)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(awards))
+	for i, a := range awards {
+		ids[i] = a.awardID
+	}
+	return ids, nil
+}
+
+// loadAwardCriteria returns every award criteria that has an award_id.
+func loadAwardCriteria(q criteria.Querier) ([]awardCriteria, error) {
+	rows, err := q.Query(`SELECT id, award_id FROM criteria WHERE type = 'award' AND award_id IS NOT NULL ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load award criteria: %w", err)
+	}
+	defer rows.Close()
+
+	var awards []awardCriteria
+	for rows.Next() {
+		var a awardCriteria
+		if err := rows.Scan(&a.criteriaID, &a.awardID); err != nil {
+			return nil, fmt.Errorf("failed to scan award criteria: %w", err)
+		}
+		awards = append(awards, a)
+	}
+	return awards, rows.Err()
+}
+
+// awardFiles lists the dump files for one award: dir/<ID>.json followed by
+// dir/<ID>/*.json.
+func awardFiles(dir, awardID string) ([]string, error) {
+	var files []string
+	single := filepath.Join(dir, awardID+".json")
+	if _, err := os.Stat(single); err == nil {
+		files = append(files, single)
+	}
+	perSeason, err := filepath.Glob(filepath.Join(dir, awardID, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s award files: %w", awardID, err)
+	}
+	return append(files, perSeason...), nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mlbAPI is the Stats API base, the same one populate.py calls.
+const mlbAPI = "https://statsapi.mlb.com/api/v1"
+
+// peopleBatchSize is how many personIds go in one /people request.
+const peopleBatchSize = 100
+
+// FetchOptions says which seasons and awards Fetch downloads.
+type FetchOptions struct {
+	FromSeason int
+	ToSeason   int
+	AwardIDs   []string
+}
+
+// manifestFile is where Fetch records what it downloaded, in the dump
+// directory's root.
+const manifestFile = "manifest.json"
+
+// fetchManifest is what one complete Fetch run downloaded: every player
+// on a roster from FromSeason to ToSeason, and the full recipient list of
+// each of Awards. Ingest trusts a dump to be complete only as far as its
+// manifest says.
+type fetchManifest struct {
+	FromSeason int      `json:"from_season"`
+	ToSeason   int      `json:"to_season"`
+	Awards     []string `json:"awards"`
+}
+
+// FetchResult summarizes one Fetch run.
+type FetchResult struct {
+	Players     int `json:"players"`
+	PeopleFiles int `json:"people_files"`
+	AwardFiles  int `json:"award_files"`
+}
+
+// Fetch downloads the dumps Ingest reads into dir: every player who
+// appeared in an MLB game in the season range, with biographies and
+// year-by-year stats, plus the recipients of each award. It is the only
+// part of ingestion that needs the network; existing files are
+// overwritten. The manifest goes in last, so a run that fails part way
+// leaves none.
+func Fetch(dir string, opts FetchOptions) (*FetchResult, error) {
+	if opts.FromSeason <= 0 || opts.ToSeason < opts.FromSeason {
+		return nil, fmt.Errorf("invalid season range %d-%d", opts.FromSeason, opts.ToSeason)
+	}
+	peopleDir := filepath.Join(dir, "people")
+	awardsDir := filepath.Join(dir, "awards")
+	for _, d := range []string{peopleDir, awardsDir} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", d, err)
+		}
+	}
+	manifestPath := filepath.Join(dir, manifestFile)
+	if err := os.Remove(manifestPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove %s: %w", manifestPath, err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	result := &FetchResult{}
+
+	ids := make(map[int]bool)
+	for season := opts.FromSeason; season <= opts.ToSeason; season++ {
+		var roster peopleFile
+		body, err := apiGet(client, "/sports/1/players", url.Values{"season": {strconv.Itoa(season)}})
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &roster); err != nil {
+			return nil, fmt.Errorf("failed to parse %d players: %w", season, err)
+		}
+		for _, p := range roster.People {
+			ids[p.ID] = true
+		}
+		log.Printf("Fetch: %d — %d players so far", season, len(ids))
+	}
+
+	sorted := make([]int, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Ints(sorted)
+	result.Players = len(sorted)
+
+	for start := 0; start < len(sorted); start += peopleBatchSize {
+		end := start + peopleBatchSize
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+		personIDs := make([]string, 0, end-start)
+		for _, id := range sorted[start:end] {
+			personIDs = append(personIDs, strconv.Itoa(id))
+		}
+
+		body, err := apiGet(client, "/people", url.Values{
+			"personIds": {strings.Join(personIDs, ",")},
+			"hydrate":   {"stats(group=[hitting,pitching],type=[yearByYear]),draft"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(peopleDir, fmt.Sprintf("%05d.json", start/peopleBatchSize))
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		result.PeopleFiles++
+	}
+
+	for _, awardID := range opts.AwardIDs {
+		body, err := apiGet(client, "/awards/"+url.PathEscape(awardID)+"/recipients", url.Values{"sportId": {"1"}})
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(awardsDir, awardID+".json")
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		result.AwardFiles++
+	}
+
+	manifest, err := json.MarshalIndent(fetchManifest{
+		FromSeason: opts.FromSeason,
+		ToSeason:   opts.ToSeason,
+		Awards:     opts.AwardIDs,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, manifest, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", manifestPath, err)
+	}
+
+	log.Printf("Fetch: %d players in %d files, %d award files", result.Players, result.PeopleFiles, result.AwardFiles)
+	return result, nil
+}
+
+// readManifest returns the manifest of the dumps in dir, or nil if Fetch
+// didn't write them (or didn't finish).
+func readManifest(dir string) (*fetchManifest, error) {
+	path := filepath.Join(dir, manifestFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var m fetchManifest
+	if err := readJSON(path, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// apiGet fetches one Stats API path, retrying transient failures like
+// populate.py's api_get. The body is checked to be JSON before it's
+// returned so a bad response never ends up in a dump file.
+func apiGet(client *http.Client, path string, params url.Values) ([]byte, error) {
+	u := mlbAPI + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+		}
+		resp, err := client.Get(u)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+			continue
+		}
+		if !json.Valid(body) {
+			lastErr = fmt.Errorf("response is not JSON")
+			continue
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("failed to fetch %s: %w", u, lastErr)
+}
@@ -0,0 +1,176 @@
+package ingest
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"trivia-server/criteria"
+)
+
+// EvaluationResult summarizes re-evaluating every criteria after an import.
+type EvaluationResult struct {
+	Criteria int      `json:"criteria"` // definitions evaluated
+	Added    int      `json:"added"`    // player_criteria rows added
+	Removed  int      `json:"removed"`  // player_criteria rows removed
+	Skipped  []string `json:"skipped"`  // criteria left alone, with the reason
+}
+
+// EvaluateOptions says which criteria an import has complete data for.
+// Those are recomputed exactly; every other criteria only gains the
+// links the new data supports, so a player whose seasons or biography
+// weren't loaded never loses a link populate.py gave them (Babe Ruth
+// stays in 500+ HR after a 1969-on import).
+type EvaluateOptions struct {
+	// FromSeason and ToSeason bound the seasons the import loaded every
+	// player for, which isn't every season a loaded career spans. Era and
+	// season criteria inside them are complete.
+	FromSeason, ToSeason int
+	// Awards holds the award IDs whose full recipient lists were loaded.
+	Awards map[string]bool
+	// Prune recomputes every criteria exactly, removing links the loaded
+	// data doesn't support. Only safe once that data covers every player.
+	Prune bool
+}
+
+// complete reports whether the loaded data settles def for every player.
+func (o EvaluateOptions) complete(def criteria.Definition) bool {
+	if o.Prune {
+		return true
+	}
+	switch def.Type {
+	case "award":
+		return def.AwardID != nil && o.Awards[*def.AwardID]
+	case "era", "season":
+		return o.FromSeason > 0 && def.StartYear != nil && def.EndYear != nil &&
+			*def.StartYear >= o.FromSeason && *def.EndYear <= o.ToSeason
+	}
+	return false
+}
+
+// Options tunes Ingest.
+type Options struct {
+	// Prune lets criteria the dumps don't fully cover (career stats,
+	// teams, teammates, biographies) lose links as well as gain them.
+	Prune bool
+}
+
+// Result summarizes one Ingest run.
+type Result struct {
+	People *PeopleImportResult `json:"people"`
+	Awards *AwardImportResult  `json:"awards"`
+	EvaluationResult
+}
+
+// Ingest loads a directory of MLB Stats API dumps laid out the way Fetch
+// writes them:
+//
+//	dir/manifest.json   what Fetch downloaded; see dumpCoverage
+//	dir/people/*.json   /people?personIds=... with yearByYear stats and draft hydrated
+//	dir/awards/<ID>.json (or dir/awards/<ID>/*.json)   /awards/{id}/recipients
+//
+// and then re-evaluates every criteria definition against it; see
+// EvaluateOptions for which ones can lose links. Everything happens in
+// one transaction and rerunning it on the same files changes nothing.
+// Criteria whose source data isn't loaded at all are skipped. Templates'
+// cell_answers aren't touched: rebuild them (rarity.Rebuild) once links
+// have changed.
+func Ingest(db *sql.DB, dir string, opts Options) (*Result, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin ingest: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &Result{}
+	if result.People, err = importPeople(tx, filepath.Join(dir, "people")); err != nil {
+		return nil, err
+	}
+	if result.Awards, err = importAwards(tx, filepath.Join(dir, "awards")); err != nil {
+		return nil, err
+	}
+	evalOpts, err := dumpCoverage(dir, result.Awards)
+	if err != nil {
+		return nil, err
+	}
+	evalOpts.Prune = opts.Prune
+	if err := evaluateAll(tx, evalOpts, &result.EvaluationResult); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit ingest: %w", err)
+	}
+
+	log.Printf("Ingest: %d players, %d season lines, %d award links, %d criteria (+%d / -%d), %d skipped",
+		result.People.Players, result.People.Seasons, result.Awards.Links,
+		result.Criteria, result.Added, result.Removed, len(result.Skipped))
+	return result, nil
+}
+
+// dumpCoverage says which criteria the dumps in dir settle. Only what a
+// finished Fetch asked for counts: the seasons it took rosters from
+// (players' careers reach either side of them, but only those seasons
+// hold every player) and the awards whose full recipient list it saved
+// and the import loaded. Without a manifest nothing is complete.
+func dumpCoverage(dir string, awards *AwardImportResult) (EvaluateOptions, error) {
+	opts := EvaluateOptions{Awards: make(map[string]bool)}
+	manifest, err := readManifest(dir)
+	if err != nil || manifest == nil {
+		return opts, err
+	}
+	opts.FromSeason, opts.ToSeason = manifest.FromSeason, manifest.ToSeason
+	for _, awardID := range manifest.Awards {
+		opts.Awards[awardID] = awards.FullLists[awardID]
+	}
+	return opts, nil
+}
+
+// criteriaEvaluator is the part of criteria.Evaluator evaluateAll uses.
+type criteriaEvaluator interface {
+	Evaluate(def criteria.Definition) ([]int, error)
+	Apply(def criteria.Definition, ids []int) (added, removed int, err error)
+	Extend(def criteria.Definition, ids []int) (added int, err error)
+}
+
+// evaluateAll re-evaluates every definition against the loaded data.
+func evaluateAll(tx *sql.Tx, opts EvaluateOptions, result *EvaluationResult) error {
+	defs, err := criteria.LoadDefinitions(tx)
+	if err != nil {
+		return err
+	}
+	return evaluateDefinitions(criteria.NewEvaluator(tx), defs, opts, result)
+}
+
+// evaluateDefinitions applies each of defs that opts says is complete
+// and extends the rest.
+func evaluateDefinitions(eval criteriaEvaluator, defs []criteria.Definition, opts EvaluateOptions, result *EvaluationResult) error {
+	for _, def := range defs {
+		ids, err := eval.Evaluate(def)
+		switch {
+		case errors.Is(err, criteria.ErrUnsupportedType),
+			errors.Is(err, criteria.ErrNoSeasonData),
+			errors.Is(err, criteria.ErrNoBioData),
+			errors.Is(err, criteria.ErrNoAwardData):
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", def.Label, err))
+			continue
+		case err != nil:
+			return fmt.Errorf("%s: %w", def.Label, err)
+		}
+
+		added, removed := 0, 0
+		if opts.complete(def) {
+			added, removed, err = eval.Apply(def, ids)
+		} else {
+			added, err = eval.Extend(def, ids)
+		}
+		if err != nil {
+			return err
+		}
+		result.Criteria++
+		result.Added += added
+		result.Removed += removed
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package ingest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"trivia-server/criteria"
+)
+
+// fakeEvaluator holds player_criteria in memory and answers Evaluate from
+// a fixed table.
+type fakeEvaluator struct {
+	results map[int][]int // criteria ID -> qualifying players
+	errs    map[int]error
+	links   map[int][]int // criteria ID -> linked players
+}
+
+func (f *fakeEvaluator) Evaluate(def criteria.Definition) ([]int, error) {
+	if err := f.errs[def.ID]; err != nil {
+		return nil, err
+	}
+	return f.results[def.ID], nil
+}
+
+func (f *fakeEvaluator) Apply(def criteria.Definition, ids []int) (int, int, error) {
+	link, unlink := criteria.Diff(f.links[def.ID], ids)
+	f.links[def.ID] = ids
+	return len(link), len(unlink), nil
+}
+
+func (f *fakeEvaluator) Extend(def criteria.Definition, ids []int) (int, error) {
+	link, _ := criteria.Diff(f.links[def.ID], ids)
+	f.links[def.ID] = append(f.links[def.ID], link...)
+	return len(link), nil
+}
+
+func intPtr(n int) *int       { return &n }
+func strPtr(s string) *string { return &s }
+
+func TestEvaluateDefinitions(t *testing.T) {
+	defs := []criteria.Definition{
+		{ID: 1, Type: "stat", Label: "500+ HR"},
+		{ID: 2, Type: "award", Label: "MVP", AwardID: strPtr("ALMVP")},
+		{ID: 3, Type: "award", Label: "Cy Young", AwardID: strPtr("ALCY")},
+		{ID: 4, Type: "era", Label: "Played in the 1980s", StartYear: intPtr(1980), EndYear: intPtr(1989)},
+		{ID: 5, Type: "era", Label: "Played in the 1960s", StartYear: intPtr(1960), EndYear: intPtr(1969)},
+		{ID: 6, Type: "team", Label: "Yankees"},
+		{ID: 7, Type: "bio", Label: "Born in Canada"},
+	}
+	newEval := func() *fakeEvaluator {
+		return &fakeEvaluator{
+			results: map[int][]int{
+				1: {10, 11},     // Ruth (1) missing: his seasons weren't loaded
+				2: {20, 21},     // the full recipient list
+				3: {30},         // partial: ALCY wasn't in the dump
+				4: {40, 41},     // inside the loaded seasons
+				5: {50},         // starts before them
+				6: {60, 61, 62}, // careers before 1969 missing
+			},
+			errs: map[int]error{7: criteria.ErrNoBioData},
+			links: map[int][]int{
+				1: {1, 10},
+				2: {20, 29},
+				3: {31},
+				4: {40, 49},
+				5: {51},
+				6: {63},
+			},
+		}
+	}
+	opts := EvaluateOptions{FromSeason: 1969, ToSeason: 2024, Awards: map[string]bool{"ALMVP": true}}
+
+	tests := []struct {
+		name                   string
+		prune                  bool
+		added, removed, counts int
+		kept                   map[int][]int
+	}{
+		{
+			name:    "extends what the dumps don't cover",
+			added:   1 + 1 + 1 + 1 + 1 + 3,
+			removed: 1 + 1, // 29 from MVP, 49 from the 1980s
+			counts:  6,
+			kept:    map[int][]int{1: {1, 10, 11}, 3: {31, 30}, 5: {51, 50}, 6: {63, 60, 61, 62}},
+		},
+		{
+			name:    "prune applies everything",
+			prune:   true,
+			added:   1 + 1 + 1 + 1 + 1 + 3,
+			removed: 6,
+			counts:  6,
+			kept:    map[int][]int{1: {10, 11}, 3: {30}, 5: {50}, 6: {60, 61, 62}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval := newEval()
+			o := opts
+			o.Prune = tt.prune
+
+			var result EvaluationResult
+			if err := evaluateDefinitions(eval, defs, o, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Added != tt.added || result.Removed != tt.removed || result.Criteria != tt.counts {
+				t.Errorf("got +%d / -%d over %d criteria, want +%d / -%d over %d",
+					result.Added, result.Removed, result.Criteria, tt.added, tt.removed, tt.counts)
+			}
+			if len(result.Skipped) != 1 {
+				t.Errorf("skipped %v, want the bio criteria only", result.Skipped)
+			}
+			for id, want := range tt.kept {
+				if got := eval.links[id]; !equalInts(got, want) {
+					t.Errorf("criteria %d links = %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateDefinitionsFails(t *testing.T) {
+	eval := &fakeEvaluator{errs: map[int]error{1: errors.New("boom")}, links: map[int][]int{}}
+	var result EvaluationResult
+	err := evaluateDefinitions(eval, []criteria.Definition{{ID: 1, Type: "stat", Label: "500+ HR"}}, EvaluateOptions{}, &result)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDumpCoverage(t *testing.T) {
+	dir := t.TempDir()
+	awards := &AwardImportResult{FullLists: map[string]bool{"MLBHOF": true}}
+
+	opts, err := dumpCoverage(dir, awards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.FromSeason != 0 || len(opts.Awards) != 0 {
+		t.Errorf("coverage without a manifest = %+v, want nothing", opts)
+	}
+
+	manifest := `{"from_season": 1990, "to_season": 2024, "awards": ["MLBHOF", "ALMVP"]}`
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts, err = dumpCoverage(dir, awards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.FromSeason != 1990 || opts.ToSeason != 2024 {
+		t.Errorf("seasons = %d-%d, want the fetched 1990-2024", opts.FromSeason, opts.ToSeason)
+	}
+	if !opts.Awards["MLBHOF"] || opts.Awards["ALMVP"] {
+		t.Errorf("complete awards = %v, want MLBHOF only: ALMVP's list wasn't loaded", opts.Awards)
+	}
+}
+
+// A dump that covers less than its careers span, or holds one season of
+// an award, only adds links: the players it doesn't hold keep theirs.
+func TestPartialDumpKeepsLinks(t *testing.T) {
+	dir := t.TempDir()
+	// fetch -from 1990: careers in the dump go back to the 1970s
+	manifest := `{"from_season": 1990, "to_season": 2024, "awards": []}`
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// one season of MVPs, as dir/awards/ALMVP/1977.json
+	awards := &AwardImportResult{PerAward: map[string]int{"ALMVP": 1}, FullLists: map[string]bool{}}
+
+	opts, err := dumpCoverage(dir, awards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := []criteria.Definition{
+		{ID: 1, Type: "era", Label: "Played in the 1970s", StartYear: intPtr(1970), EndYear: intPtr(1979)},
+		{ID: 2, Type: "season", Label: "40+ HR season", StartYear: intPtr(1980), EndYear: intPtr(1989)},
+		{ID: 3, Type: "award", Label: "MVP", AwardID: strPtr("ALMVP")},
+	}
+	eval := &fakeEvaluator{
+		results: map[int][]int{1: {10}, 2: {20}, 3: {30}},
+		links:   map[int][]int{1: {10, 11}, 2: {21}, 3: {31, 32}},
+	}
+
+	var result EvaluationResult
+	if err := evaluateDefinitions(eval, defs, opts, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 0 {
+		t.Errorf("a partial dump removed %d links", result.Removed)
+	}
+	want := map[int][]int{1: {10, 11}, 2: {21, 20}, 3: {31, 32, 30}}
+	for id, links := range want {
+		if got := eval.links[id]; !equalInts(got, links) {
+			t.Errorf("criteria %d links = %v, want %v", id, got, links)
+		}
+	}
+}
@@ -0,0 +1,1008 @@
+package ingest
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LahmanOptions tunes ImportLahman.
+type LahmanOptions struct {
+	// RegisterPath is an optional Chadwick Bureau register people.csv.
+	// Its key_bbref -> key_mlbam pairs are the most reliable crosswalk;
+	// without it players are matched on name and birth date.
+	RegisterPath string
+	// Prune lets career, team and bio criteria lose links the files
+	// don't support, as Options.Prune does for Ingest.
+	Prune bool
+}
+
+// LahmanImportResult summarizes one ImportLahman run.
+type LahmanImportResult struct {
+	Players     int `json:"players"`
+	Crosswalked int `json:"crosswalked"` // players matched to an mlb_id
+	TeamStints  int `json:"team_stints"` // player_teams rows
+	StatLines   int `json:"stat_lines"`  // player_stats rows
+	Awards      int `json:"awards"`      // new player_awards rows
+	Seasons     int `json:"seasons"`     // mlb_player_seasons rows written
+	MlbAwards   int `json:"mlb_awards"`  // new mlb_player_awards rows
+	EvaluationResult
+}
+
+type lahmanPerson struct {
+	id           string
+	bbrefID      string
+	first, last  string
+	birthDate    string // YYYY-MM-DD or ""
+	birthCity    string
+	birthState   string
+	birthCountry string
+	debut        string
+	finalGame    string
+	bats, throws string
+	height       int
+	weight       int
+}
+
+// lahmanLine is one player's season with one team, stints summed.
+type lahmanLine struct {
+	playerID, teamID string
+	year             int
+	batting          bool
+	pitching         bool
+	games            int
+	ab, h, d, t, hr  int
+	rbi, sb, bb      int
+	hbp, sf          int
+	w, l, sv, so     int
+	ipOuts, er       int
+}
+
+type lineKey struct {
+	playerID, teamID string
+	year             int
+}
+
+// appearanceTotals adds up a player's games with one team.
+type appearanceTotals struct {
+	start, end int
+	games      int
+	byPosition map[string]int
+}
+
+type lahmanAward struct {
+	playerID string
+	name     string
+	league   string
+	year     int
+	notes    string
+}
+
+// appearancePositions maps Appearances.csv columns to position labels.
+var appearancePositions = []struct{ column, position string }{
+	{"g_p", "P"}, {"g_c", "C"}, {"g_1b", "1B"}, {"g_2b", "2B"}, {"g_3b", "3B"},
+	{"g_ss", "SS"}, {"g_lf", "LF"}, {"g_cf", "CF"}, {"g_rf", "RF"}, {"g_dh", "DH"},
+}
+
+// lahmanBatchSize is how many players, stat lines or awards ImportLahman
+// writes per transaction.
+const lahmanBatchSize = 1000
+
+// ImportLahman loads the Lahman database CSVs in dir (People, Batting,
+// Pitching, Appearances, AwardsPlayers, HallOfFame) into the legacy
+// players, player_teams, player_stats and player_awards tables. Players
+// it can match to an mlb_id also get their full season history written
+// to mlb_player_seasons and their awards to mlb_player_awards, and every
+// criteria is re-evaluated; see EvaluateOptions for which ones can lose
+// links. Seasons and awards only count as complete where every player
+// was matched (see lahmanCoverage).
+//
+// Writes are committed every lahmanBatchSize rows rather than in one
+// transaction, so a failed run leaves part of the files loaded. Every
+// write is an upsert, so rerunning it finishes the job, and rerunning it
+// with the same files changes nothing.
+func ImportLahman(db *sql.DB, dir string, opts LahmanOptions) (*LahmanImportResult, error) {
+	people, err := readLahmanPeople(filepath.Join(dir, "People.csv"))
+	if err != nil {
+		return nil, err
+	}
+	lines := make(map[lineKey]*lahmanLine)
+	if err := readLahmanBatting(filepath.Join(dir, "Batting.csv"), lines); err != nil {
+		return nil, err
+	}
+	if err := readLahmanPitching(filepath.Join(dir, "Pitching.csv"), lines); err != nil {
+		return nil, err
+	}
+	appearances, err := readLahmanAppearances(filepath.Join(dir, "Appearances.csv"))
+	if err != nil {
+		return nil, err
+	}
+	awards, err := readLahmanAwards(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	register := make(map[string]int)
+	if opts.RegisterPath != "" {
+		if register, err = readRegister(opts.RegisterPath); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := beginBatch(db, "Lahman players")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { b.tx.Rollback() }()
+
+	teams, err := loadLocalTeams(b.tx)
+	if err != nil {
+		return nil, err
+	}
+	byName, err := loadMlbPlayersByName(b.tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LahmanImportResult{}
+	playerIDs := make(map[string]int, len(people)) // Lahman playerID -> players.id
+	mlbIDs := make(map[string]int)                 // Lahman playerID -> mlb_id
+
+	for _, p := range people {
+		primary := primaryPosition(appearances[p.id])
+		id, err := upsertLahmanPlayer(b.tx, p, primary)
+		if err != nil {
+			return nil, err
+		}
+		playerIDs[p.id] = id
+		result.Players++
+
+		mlbID := register[p.bbrefID]
+		if mlbID == 0 && p.birthDate != "" {
+			mlbID = byName[nameKey(p.first+" "+p.last, p.birthDate)]
+		}
+		if mlbID > 0 {
+			if err := crosswalkPlayer(b.tx, id, mlbID, p, primary); err != nil {
+				return nil, err
+			}
+			mlbIDs[p.id] = mlbID
+			result.Crosswalked++
+		}
+		if err := b.step(); err != nil {
+			return nil, err
+		}
+	}
+
+	// player_teams has no natural key, so each player's stints replace
+	// the ones a previous import wrote
+	if err := b.next("Lahman team stints"); err != nil {
+		return nil, err
+	}
+	for playerID, perTeam := range appearances {
+		n, err := replacePlayerTeams(b.tx, playerIDs[playerID], perTeam, teams)
+		if err != nil {
+			return nil, err
+		}
+		result.TeamStints += n
+		if err := b.step(); err != nil {
+			return nil, err
+		}
+	}
+
+	// player_stats is keyed on player, team and year, except for lines
+	// with teams the teams table doesn't have: those are replaced
+	if err := b.next("Lahman stat lines"); err != nil {
+		return nil, err
+	}
+	if _, err := b.tx.Exec(`
+		DELETE FROM player_stats
+		WHERE team_id IS NULL AND player_id IN (SELECT id FROM players WHERE lahman_id IS NOT NULL)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to clear teamless player_stats: %w", err)
+	}
+	coverage := newLahmanCoverage()
+	for _, line := range lines {
+		id, ok := playerIDs[line.playerID]
+		if !ok {
+			coverage.line(line.year, false)
+			continue
+		}
+		if err := upsertPlayerStats(b.tx, id, line, teams); err != nil {
+			return nil, err
+		}
+		result.StatLines++
+
+		mlbID, ok := mlbIDs[line.playerID]
+		team, known := lahmanTeams[line.teamID]
+		if ok && known {
+			n, err := upsertLahmanSeason(b.tx, mlbID, team.mlbTeamID, line)
+			if err != nil {
+				return nil, err
+			}
+			result.Seasons += n
+		}
+		coverage.line(line.year, ok && known)
+		if err := b.step(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.next("Lahman awards"); err != nil {
+		return nil, err
+	}
+	awardIDs := make(map[string]int)
+	for _, a := range awards {
+		mlbID, crosswalked := mlbIDs[a.playerID]
+		coverage.award(a, crosswalked)
+		id, ok := playerIDs[a.playerID]
+		if !ok {
+			continue
+		}
+		n, err := insertPlayerAward(b.tx, id, a, awardIDs)
+		if err != nil {
+			return nil, err
+		}
+		result.Awards += n
+
+		if crosswalked {
+			n, err := insertMlbAward(b.tx, mlbID, a)
+			if err != nil {
+				return nil, err
+			}
+			result.MlbAwards += n
+		}
+		if err := b.step(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.next("Lahman criteria evaluation"); err != nil {
+		return nil, err
+	}
+	evalOpts := coverage.options()
+	evalOpts.Prune = opts.Prune
+	if err := evaluateAll(b.tx, evalOpts, &result.EvaluationResult); err != nil {
+		return nil, err
+	}
+	if err := b.commit(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Lahman import: %d players (%d crosswalked), %d stat lines, %d season lines, %d criteria (+%d / -%d)",
+		result.Players, result.Crosswalked, result.StatLines, result.Seasons,
+		result.Criteria, result.Added, result.Removed)
+	return result, nil
+}
+
+// lahmanCoverage tracks which seasons and awards made it into the mlb_*
+// tables for every player the files have them for. Only crosswalked
+// players are written there, so a season or award with a player the
+// crosswalk missed (or a team lahmanTeams doesn't know) isn't complete:
+// applying its criteria would take that player's links away.
+type lahmanCoverage struct {
+	years  map[int]bool    // season -> every line written
+	awards map[string]bool // award ID -> every winner written
+}
+
+func newLahmanCoverage() *lahmanCoverage {
+	return &lahmanCoverage{years: make(map[int]bool), awards: make(map[string]bool)}
+}
+
+// line records whether one season line was written.
+func (c *lahmanCoverage) line(year int, written bool) {
+	all, seen := c.years[year]
+	c.years[year] = written && (all || !seen)
+}
+
+// award records whether one award's winner was written.
+func (c *lahmanCoverage) award(a lahmanAward, written bool) {
+	awardID, _ := mlbAward(a)
+	if awardID == "" {
+		return
+	}
+	all, seen := c.awards[awardID]
+	c.awards[awardID] = written && (all || !seen)
+}
+
+// options returns the longest run of complete seasons, which
+// EvaluateOptions needs unbroken, and the complete awards.
+func (c *lahmanCoverage) options() EvaluateOptions {
+	opts := EvaluateOptions{Awards: make(map[string]bool)}
+	for year, all := range c.years {
+		if !all || c.years[year-1] {
+			continue // not complete, or not the start of a run
+		}
+		end := year
+		for c.years[end+1] {
+			end++
+		}
+		if end-year > opts.ToSeason-opts.FromSeason || opts.FromSeason == 0 {
+			opts.FromSeason, opts.ToSeason = year, end
+		}
+	}
+	for awardID, all := range c.awards {
+		if all {
+			opts.Awards[awardID] = true
+		}
+	}
+	return opts
+}
+
+// batch is one stage of ImportLahman, committed every lahmanBatchSize
+// steps so no transaction holds a whole table.
+type batch struct {
+	db    *sql.DB
+	tx    *sql.Tx
+	stage string
+	steps int
+}
+
+func beginBatch(db *sql.DB, stage string) (*batch, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin %s: %w", stage, err)
+	}
+	return &batch{db: db, tx: tx, stage: stage}, nil
+}
+
+// step counts one write and commits if the batch is full.
+func (b *batch) step() error {
+	b.steps++
+	if b.steps%lahmanBatchSize != 0 {
+		return nil
+	}
+	return b.restart(b.stage)
+}
+
+// next commits the stage so far and starts the next one.
+func (b *batch) next(stage string) error {
+	b.steps = 0
+	return b.restart(stage)
+}
+
+func (b *batch) restart(stage string) error {
+	if err := b.commit(); err != nil {
+		return err
+	}
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin %s: %w", stage, err)
+	}
+	b.tx, b.stage = tx, stage
+	return nil
+}
+
+func (b *batch) commit() error {
+	if err := b.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", b.stage, err)
+	}
+	return nil
+}
+
+// ── CSV reading ──────────────────────────────────────────
+
+type csvRow struct {
+	cols   map[string]int
+	record []string
+}
+
+// str returns a column by its lower-cased header name ("" if absent).
+func (r csvRow) str(name string) string {
+	i, ok := r.cols[name]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+// num returns a numeric column, treating blanks as 0.
+func (r csvRow) num(name string) int {
+	n, _ := strconv.Atoi(r.str(name))
+	return n
+}
+
+// readCSV calls fn for every row of a headed CSV file. Header names are
+// lower-cased since Lahman isn't consistent ("yearID" vs "yearid").
+func readCSV(path string, fn func(csvRow) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s header: %w", path, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "\ufeff"))] = i
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err := fn(csvRow{cols: cols, record: record}); err != nil {
+			return err
+		}
+	}
+}
+
+// readOptionalCSV is readCSV for files the import can do without.
+func readOptionalCSV(path string, fn func(csvRow) error) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		log.Printf("Lahman import: %s not found, skipping", filepath.Base(path))
+		return nil
+	}
+	return readCSV(path, fn)
+}
+
+func readLahmanPeople(path string) ([]lahmanPerson, error) {
+	var people []lahmanPerson
+	err := readCSV(path, func(r csvRow) error {
+		p := lahmanPerson{
+			id:           r.str("playerid"),
+			bbrefID:      r.str("bbrefid"),
+			first:        r.str("namefirst"),
+			last:         r.str("namelast"),
+			birthCity:    r.str("birthcity"),
+			birthState:   r.str("birthstate"),
+			birthCountry: r.str("birthcountry"),
+			debut:        lahmanDate(r.str("debut")),
+			finalGame:    lahmanDate(r.str("finalgame")),
+			bats:         r.str("bats"),
+			throws:       r.str("throws"),
+			height:       r.num("height"),
+			weight:       r.num("weight"),
+		}
+		if y, m, d := r.num("birthyear"), r.num("birthmonth"), r.num("birthday"); y > 0 && m > 0 && d > 0 {
+			p.birthDate = fmt.Sprintf("%04d-%02d-%02d", y, m, d)
+		}
+		if p.id != "" && p.last != "" {
+			people = append(people, p)
+		}
+		return nil
+	})
+	return people, err
+}
+
+func readLahmanBatting(path string, lines map[lineKey]*lahmanLine) error {
+	return readOptionalCSV(path, func(r csvRow) error {
+		l := lineFor(lines, r)
+		l.batting = true
+		l.games += r.num("g")
+		l.ab += r.num("ab")
+		l.h += r.num("h")
+		l.d += r.num("2b")
+		l.t += r.num("3b")
+		l.hr += r.num("hr")
+		l.rbi += r.num("rbi")
+		l.sb += r.num("sb")
+		l.bb += r.num("bb")
+		l.hbp += r.num("hbp")
+		l.sf += r.num("sf")
+		return nil
+	})
+}
+
+func readLahmanPitching(path string, lines map[lineKey]*lahmanLine) error {
+	return readOptionalCSV(path, func(r csvRow) error {
+		l := lineFor(lines, r)
+		l.pitching = true
+		if !l.batting {
+			l.games += r.num("g")
+		}
+		l.w += r.num("w")
+		l.l += r.num("l")
+		l.sv += r.num("sv")
+		l.so += r.num("so")
+		l.ipOuts += r.num("ipouts")
+		l.er += r.num("er")
+		return nil
+	})
+}
+
+func lineFor(lines map[lineKey]*lahmanLine, r csvRow) *lahmanLine {
+	k := lineKey{playerID: r.str("playerid"), teamID: r.str("teamid"), year: r.num("yearid")}
+	l, ok := lines[k]
+	if !ok {
+		l = &lahmanLine{playerID: k.playerID, teamID: k.teamID, year: k.year}
+		lines[k] = l
+	}
+	return l
+}
+
+// readLahmanAppearances returns playerID -> teamID -> totals.
+func readLahmanAppearances(path string) (map[string]map[string]*appearanceTotals, error) {
+	out := make(map[string]map[string]*appearanceTotals)
+	err := readOptionalCSV(path, func(r csvRow) error {
+		playerID, teamID, year := r.str("playerid"), r.str("teamid"), r.num("yearid")
+		perTeam, ok := out[playerID]
+		if !ok {
+			perTeam = make(map[string]*appearanceTotals)
+			out[playerID] = perTeam
+		}
+		t, ok := perTeam[teamID]
+		if !ok {
+			t = &appearanceTotals{start: year, end: year, byPosition: make(map[string]int)}
+			perTeam[teamID] = t
+		}
+		if year < t.start {
+			t.start = year
+		}
+		if year > t.end {
+			t.end = year
+		}
+		t.games += r.num("g_all")
+		for _, p := range appearancePositions {
+			t.byPosition[p.position] += r.num(p.column)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// readLahmanAwards reads AwardsPlayers.csv plus the players inducted
+// into the Hall of Fame from HallOfFame.csv.
+func readLahmanAwards(dir string) ([]lahmanAward, error) {
+	var awards []lahmanAward
+	err := readOptionalCSV(filepath.Join(dir, "AwardsPlayers.csv"), func(r csvRow) error {
+		a := lahmanAward{
+			playerID: r.str("playerid"),
+			name:     r.str("awardid"),
+			league:   r.str("lgid"),
+			year:     r.num("yearid"),
+			notes:    r.str("notes"),
+		}
+		if r.str("tie") == "Y" {
+			a.notes = strings.TrimSpace(a.notes + " (tie)")
+		}
+		awards = append(awards, a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readOptionalCSV(filepath.Join(dir, "HallOfFame.csv"), func(r csvRow) error {
+		if r.str("inducted") != "Y" || r.str("category") != "Player" {
+			return nil
+		}
+		awards = append(awards, lahmanAward{
+			playerID: r.str("playerid"),
+			name:     "Hall of Fame",
+			league:   "MLB",
+			year:     r.num("yearid"),
+			notes:    r.str("votedby"),
+		})
+		return nil
+	})
+	return awards, err
+}
+
+// readRegister returns key_bbref -> key_mlbam from the Chadwick register.
+func readRegister(path string) (map[string]int, error) {
+	register := make(map[string]int)
+	err := readCSV(path, func(r csvRow) error {
+		bbref, mlbID := r.str("key_bbref"), r.num("key_mlbam")
+		if bbref != "" && mlbID > 0 {
+			register[bbref] = mlbID
+		}
+		return nil
+	})
+	return register, err
+}
+
+// lahmanDate accepts both the ISO dates of recent Lahman releases and the
+// M/D/YYYY of older ones, returning "" for anything else.
+func lahmanDate(s string) string {
+	for _, layout := range []string{"2006-01-02", "1/2/2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+// ── crosswalk ───────────────────────────────────────────
+
+var nameReplacer = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n", "ü", "u",
+	".", "", "'", "", "-", " ",
+)
+
+// nameKey normalizes a name and birth date so "José Ramírez" born
+// 1992-09-17 and Lahman's "Jose Ramirez" land on the same key.
+func nameKey(fullName, birthDate string) string {
+	var words []string
+	for _, w := range strings.Fields(nameReplacer.Replace(strings.ToLower(fullName))) {
+		switch w {
+		case "jr", "sr", "ii", "iii", "iv":
+			continue
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ") + "|" + birthDate
+}
+
+// loadMlbPlayersByName indexes mlb_players by nameKey. Keys shared by
+// more than one player map to -1 so they're never matched.
+func loadMlbPlayersByName(tx *sql.Tx) (map[string]int, error) {
+	rows, err := tx.Query(`
+		SELECT mlb_id, full_name, DATE_FORMAT(birth_date, '%Y-%m-%d')
+		FROM mlb_players
+		WHERE birth_date IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load players for crosswalk: %w", err)
+	}
+	defer rows.Close()
+
+	byName := make(map[string]int)
+	for rows.Next() {
+		var mlbID int
+		var name, birth string
+		if err := rows.Scan(&mlbID, &name, &birth); err != nil {
+			return nil, fmt.Errorf("failed to scan player for crosswalk: %w", err)
+		}
+		k := nameKey(name, birth)
+		if _, dup := byName[k]; dup {
+			byName[k] = -1
+			continue
+		}
+		byName[k] = mlbID
+	}
+	return byName, rows.Err()
+}
+
+// crosswalkPlayer records the match on players and fills in any
+// biography the Stats API didn't give mlb_players, creating the
+// mlb_players row for players it has never seen.
+func crosswalkPlayer(tx *sql.Tx, playerID, mlbID int, p lahmanPerson, position string) error {
+	if _, err := tx.Exec(`UPDATE players SET mlb_id = ? WHERE id = ?`, mlbID, playerID); err != nil {
+		return fmt.Errorf("failed to crosswalk %s: %w", p.id, err)
+	}
+
+	bats := p.bats
+	if bats == "B" {
+		bats = "S" // Lahman's "both" is the API's switch hitter
+	}
+	country := p.birthCountry
+	if full, ok := lahmanCountries[country]; ok {
+		country = full
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO mlb_players
+			(mlb_id, full_name, position, headshot_url, active,
+			 bats, throws, birth_country, birth_state, birth_date,
+			 debut_year, final_year)
+		VALUES (?, ?, ?, ?, FALSE, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			position      = COALESCE(position, VALUES(position)),
+			bats          = COALESCE(bats, VALUES(bats)),
+			throws        = COALESCE(throws, VALUES(throws)),
+			birth_country = COALESCE(birth_country, VALUES(birth_country)),
+			birth_state   = COALESCE(birth_state, VALUES(birth_state)),
+			birth_date    = COALESCE(birth_date, VALUES(birth_date)),
+			debut_year    = COALESCE(debut_year, VALUES(debut_year)),
+			final_year    = COALESCE(final_year, VALUES(final_year))
+	`, mlbID, strings.TrimSpace(p.first+" "+p.last), nullString(position), HeadshotURL(mlbID),
+		nullString(bats), nullString(p.throws), nullString(country),
+		nullString(p.birthState), nullString(p.birthDate),
+		dateYear(p.debut), dateYear(p.finalGame))
+	if err != nil {
+		return fmt.Errorf("failed to upsert crosswalked player %d: %w", mlbID, err)
+	}
+	return nil
+}
+
+// ── legacy tables ───────────────────────────────────────
+
+type localTeamKey struct {
+	abbr   string
+	active bool
+}
+
+// loadLocalTeams indexes the teams table by abbreviation and is_active.
+func loadLocalTeams(tx *sql.Tx) (map[localTeamKey]int, error) {
+	rows, err := tx.Query(`SELECT id, abbreviation, is_active FROM teams`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := make(map[localTeamKey]int)
+	for rows.Next() {
+		var id int
+		var k localTeamKey
+		if err := rows.Scan(&id, &k.abbr, &k.active); err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams[k] = id
+	}
+	return teams, rows.Err()
+}
+
+// localTeam returns the teams.id for a Lahman teamID, or nil.
+func localTeam(teams map[localTeamKey]int, lahmanID string) *int {
+	t, ok := lahmanTeams[lahmanID]
+	if !ok || t.abbr == "" {
+		return nil
+	}
+	id, ok := teams[localTeamKey{t.abbr, t.active}]
+	if !ok {
+		return nil
+	}
+	return &id
+}
+
+func upsertLahmanPlayer(tx *sql.Tx, p lahmanPerson, position string) (int, error) {
+	res, err := tx.Exec(`
+		INSERT INTO players
+			(lahman_id, first_name, last_name, birth_date, birth_city, birth_state,
+			 birth_country, debut_date, final_game_date, primary_position,
+			 bats, throws, height_inches, weight_lbs)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			id               = LAST_INSERT_ID(id),
+			first_name       = VALUES(first_name),
+			last_name        = VALUES(last_name),
+			birth_date       = VALUES(birth_date),
+			birth_city       = VALUES(birth_city),
+			birth_state      = VALUES(birth_state),
+			birth_country    = VALUES(birth_country),
+			debut_date       = VALUES(debut_date),
+			final_game_date  = VALUES(final_game_date),
+			primary_position = VALUES(primary_position),
+			bats             = VALUES(bats),
+			throws           = VALUES(throws),
+			height_inches    = VALUES(height_inches),
+			weight_lbs       = VALUES(weight_lbs)
+	`, p.id, p.first, p.last, nullString(p.birthDate), nullString(p.birthCity), nullString(p.birthState),
+		nullString(p.birthCountry), nullString(p.debut), nullString(p.finalGame), nullString(position),
+		nullString(p.bats), nullString(p.throws), nullInt(p.height), nullInt(p.weight))
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert Lahman player %s: %w", p.id, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get id for Lahman player %s: %w", p.id, err)
+	}
+	return int(id), nil
+}
+
+// primaryPosition is the position a player appeared at most.
+func primaryPosition(perTeam map[string]*appearanceTotals) string {
+	totals := make(map[string]int)
+	for _, t := range perTeam {
+		for pos, g := range t.byPosition {
+			totals[pos] += g
+		}
+	}
+	best, bestGames := "", 0
+	for _, p := range appearancePositions {
+		if totals[p.position] > bestGames {
+			best, bestGames = p.position, totals[p.position]
+		}
+	}
+	return best
+}
+
+// replacePlayerTeams replaces the player's player_teams rows with one
+// per team they appeared for, flagging the one with the most games as
+// primary.
+func replacePlayerTeams(tx *sql.Tx, playerID int, perTeam map[string]*appearanceTotals, teams map[localTeamKey]int) (int, error) {
+	if playerID == 0 {
+		return 0, nil
+	}
+	if _, err := tx.Exec(`DELETE FROM player_teams WHERE player_id = ?`, playerID); err != nil {
+		return 0, fmt.Errorf("failed to clear teams for player %d: %w", playerID, err)
+	}
+	primary, primaryGames := "", -1
+	for teamID, t := range perTeam {
+		if localTeam(teams, teamID) != nil && t.games > primaryGames {
+			primary, primaryGames = teamID, t.games
+		}
+	}
+
+	n := 0
+	for teamID, t := range perTeam {
+		team := localTeam(teams, teamID)
+		if team == nil {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO player_teams (player_id, team_id, start_year, end_year, position, is_primary_team)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, playerID, *team, t.start, t.end, nullString(primaryPosition(map[string]*appearanceTotals{teamID: t})),
+			teamID == primary); err != nil {
+			return n, fmt.Errorf("failed to insert team %s for player %d: %w", teamID, playerID, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// upsertPlayerStats writes a line to player_stats, replacing the one a
+// previous import wrote for the same player, team and year.
+func upsertPlayerStats(tx *sql.Tx, playerID int, l *lahmanLine, teams map[localTeamKey]int) error {
+	var avg, era float64
+	if l.ab > 0 {
+		avg = float64(l.h) / float64(l.ab)
+	}
+	if l.ipOuts > 0 {
+		era = float64(l.er) * 27 / float64(l.ipOuts)
+	}
+	if era > 99.99 {
+		era = 99.99 // DECIMAL(4,2)
+	}
+	// Stored in box-score notation, so 200⅓ innings is 200.1
+	innings := float64(l.ipOuts/3) + float64(l.ipOuts%3)/10
+
+	_, err := tx.Exec(`
+		INSERT INTO player_stats
+			(player_id, team_id, year, games_played, at_bats, hits, doubles, triples,
+			 home_runs, rbis, stolen_bases, batting_average,
+			 wins, losses, saves, innings_pitched, strikeouts, era)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			games_played    = VALUES(games_played),
+			at_bats         = VALUES(at_bats),
+			hits            = VALUES(hits),
+			doubles         = VALUES(doubles),
+			triples         = VALUES(triples),
+			home_runs       = VALUES(home_runs),
+			rbis            = VALUES(rbis),
+			stolen_bases    = VALUES(stolen_bases),
+			batting_average = VALUES(batting_average),
+			wins            = VALUES(wins),
+			losses          = VALUES(losses),
+			saves           = VALUES(saves),
+			innings_pitched = VALUES(innings_pitched),
+			strikeouts      = VALUES(strikeouts),
+			era             = VALUES(era)
+	`, playerID, localTeam(teams, l.teamID), l.year, l.games, l.ab, l.h, l.d, l.t,
+		l.hr, l.rbi, l.sb, avg, l.w, l.l, l.sv, innings, l.so, era)
+	if err != nil {
+		return fmt.Errorf("failed to upsert %d stats for player %d: %w", l.year, playerID, err)
+	}
+	return nil
+}
+
+// insertPlayerAward links a player to an awards row, creating the award
+// the first time its name and league are seen. Returns 1 if the link is
+// new.
+func insertPlayerAward(tx *sql.Tx, playerID int, a lahmanAward, awardIDs map[string]int) (int, error) {
+	league := a.league
+	if league == "ML" || league == "" {
+		league = "MLB"
+	}
+	key := a.name + "|" + league
+	awardID, ok := awardIDs[key]
+	if !ok {
+		res, err := tx.Exec(`
+			INSERT INTO awards (name, category, league) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)
+		`, a.name, nullString(lahmanAwardCategories[a.name]), league)
+		if err != nil {
+			return 0, fmt.Errorf("failed to upsert award %q: %w", a.name, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get id for award %q: %w", a.name, err)
+		}
+		awardID = int(id)
+		awardIDs[key] = awardID
+	}
+
+	res, err := tx.Exec(`
+		INSERT IGNORE INTO player_awards (player_id, award_id, year, notes)
+		VALUES (?, ?, ?, ?)
+	`, playerID, awardID, a.year, nullString(a.notes))
+	if err != nil {
+		return 0, fmt.Errorf("failed to link award %q to player %d: %w", a.name, playerID, err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ── mlb_* tables ────────────────────────────────────────
+
+// upsertLahmanSeason writes a crosswalked player's line through the same
+// upserts the Stats API import uses, so the two sources agree on a row.
+func upsertLahmanSeason(tx *sql.Tx, mlbID, mlbTeamID int, l *lahmanLine) (int, error) {
+	split := statSplit{Season: strconv.Itoa(l.year)}
+	split.Team.ID = mlbTeamID
+	split.Stat = seasonStat{
+		GamesPlayed:    l.games,
+		AtBats:         l.ab,
+		Hits:           l.h,
+		Doubles:        l.d,
+		Triples:        l.t,
+		HomeRuns:       l.hr,
+		RBI:            l.rbi,
+		StolenBases:    l.sb,
+		BaseOnBalls:    l.bb,
+		HitByPitch:     l.hbp,
+		SacFlies:       l.sf,
+		Wins:           l.w,
+		Losses:         l.l,
+		Saves:          l.sv,
+		StrikeOuts:     l.so,
+		InningsPitched: fmt.Sprintf("%d.%d", l.ipOuts/3, l.ipOuts%3),
+		EarnedRuns:     l.er,
+	}
+
+	n := 0
+	if l.batting {
+		if err := upsertHittingSeason(tx, mlbID, l.year, split); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if l.pitching {
+		if err := upsertPitchingSeason(tx, mlbID, l.year, split); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// insertMlbAward records the awards that have an award criteria.
+// Returns 1 if the row is new.
+func insertMlbAward(tx *sql.Tx, mlbID int, a lahmanAward) (int, error) {
+	awardID, season := mlbAward(a)
+	if awardID == "" {
+		return 0, nil
+	}
+
+	res, err := tx.Exec(`
+		INSERT IGNORE INTO mlb_player_awards (mlb_id, award_id, season)
+		VALUES (?, ?, ?)
+	`, mlbID, awardID, season)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert %s award for %d: %w", awardID, mlbID, err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// mlbAward returns the Stats API award ID and season of a Lahman award,
+// or "" for awards no criteria uses.
+func mlbAward(a lahmanAward) (awardID string, season int) {
+	switch {
+	case a.name == "Hall of Fame":
+		return "MLBHOF", 0
+	case a.name == "World Series MVP":
+		return "WSMVP", a.year
+	case a.league == "AL" || a.league == "NL":
+		if suffix, ok := lahmanAwards[a.name]; ok {
+			return a.league + suffix, a.year
+		}
+	}
+	return "", 0
+}
+
+func nullInt(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+package ingest
+
+// lahmanTeam ties a Lahman teamID to the local teams row (by abbreviation
+// and is_active, since the Pilots and Mariners share "SEA") and to the
+// MLB Stats API franchise ID that mlb_player_seasons uses.
+type lahmanTeam struct {
+	abbr      string // teams.abbreviation, "" if there's no local row
+	active    bool
+	mlbTeamID int
+}
+
+// lahmanTeams covers every franchise from 1901 on. Relocated franchises
+// keep the API ID of the team they became, the way the Stats API files
+// their history; earlier clubs aren't mapped and are skipped.
+var lahmanTeams = map[string]lahmanTeam{
+	// American League
+	"NYA": {"NYY", true, 147},
+	"BOS": {"BOS", true, 111},
+	"TOR": {"TOR", true, 141},
+	"TBA": {"TB", true, 139},
+	"BAL": {"BAL", true, 110},
+	"SLA": {"SLB", false, 110},
+	"CHA": {"CWS", true, 145},
+	"CLE": {"CLE", true, 114},
+	"DET": {"DET", true, 116},
+	"KCA": {"KC", true, 118},
+	"MIN": {"MIN", true, 142},
+	"WS1": {"WAS", false, 142},
+	"HOU": {"HOU", true, 117},
+	"LAA": {"LAA", true, 108},
+	"ANA": {"LAA", true, 108},
+	"CAL": {"LAA", true, 108},
+	"OAK": {"OAK", true, 133},
+	"KC1": {"", false, 133},
+	"PHA": {"", false, 133},
+	"SEA": {"SEA", true, 136},
+	"TEX": {"TEX", true, 140},
+	"WS2": {"", false, 140},
+	"SE1": {"SEA", false, 158},
+	"ML4": {"MIL", true, 158},
+
+	// National League
+	"ATL": {"ATL", true, 144},
+	"ML1": {"", false, 144},
+	"BSN": {"", false, 144},
+	"MIA": {"MIA", true, 146},
+	"FLO": {"MIA", true, 146},
+	"NYN": {"NYM", true, 121},
+	"PHI": {"PHI", true, 143},
+	"WAS": {"WSH", true, 120},
+	"MON": {"MON", false, 120},
+	"CHN": {"CHC", true, 112},
+	"CIN": {"CIN", true, 113},
+	"MIL": {"MIL", true, 158},
+	"PIT": {"PIT", true, 134},
+	"SLN": {"STL", true, 138},
+	"ARI": {"ARI", true, 109},
+	"COL": {"COL", true, 115},
+	"LAN": {"LAD", true, 119},
+	"BRO": {"", false, 119},
+	"SDN": {"SD", true, 135},
+	"SFN": {"SF", true, 137},
+	"NY1": {"", false, 137},
+}
+
+// lahmanAwards maps Lahman award names to the criteria award_id suffix;
+// the league ("AL"/"NL") goes in front.
+var lahmanAwards = map[string]string{
+	"Most Valuable Player": "MVP",
+	"Cy Young Award":       "CY",
+	"Rookie of the Year":   "ROY",
+	"Gold Glove":           "GG",
+	"Silver Slugger":       "SS",
+}
+
+// lahmanAwardCategories fills awards.category for the legacy table.
+var lahmanAwardCategories = map[string]string{
+	"Most Valuable Player": "MVP",
+	"World Series MVP":     "MVP",
+	"Cy Young Award":       "Pitching",
+	"Rookie of the Year":   "Rookie",
+	"Gold Glove":           "Fielding",
+	"Silver Slugger":       "Batting",
+	"Hall of Fame":         "Hall of Fame",
+}
+
+// lahmanCountries spells out the countries Lahman abbreviates, matching
+// the Stats API's birthCountry values.
+var lahmanCountries = map[string]string{
+	"D.R.": "Dominican Republic",
+	"CAN":  "Canada",
+	"P.R.": "Puerto Rico",
+	"V.I.": "U.S. Virgin Islands",
+}
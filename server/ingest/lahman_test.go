@@ -0,0 +1,172 @@
+package ingest
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var lahmanDir = filepath.Join("testdata", "lahman")
+
+func TestReadLahmanPeople(t *testing.T) {
+	people, err := readLahmanPeople(filepath.Join(lahmanDir, "People.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The BOM on the header is ignored and the row without a name skipped
+	want := []lahmanPerson{
+		{
+			id: "ryanno01", bbrefID: "ryanno01", first: "Nolan", last: "Ryan",
+			birthDate: "1947-01-31", birthCity: "Refugio", birthState: "TX", birthCountry: "USA",
+			debut: "1966-09-11", finalGame: "1993-09-22", bats: "R", throws: "R", height: 74, weight: 170,
+		},
+		{
+			id: "carewro01", bbrefID: "carewro01", first: "Rod", last: "Carew",
+			birthDate: "1945-10-01", birthCity: "Gatun", birthCountry: "Panama",
+			debut: "1967-04-11", finalGame: "1985-10-05", bats: "L", throws: "R", height: 72, weight: 170,
+		},
+	}
+	if !reflect.DeepEqual(people, want) {
+		t.Errorf("readLahmanPeople =\n%+v\nwant\n%+v", people, want)
+	}
+}
+
+func TestReadLahmanLines(t *testing.T) {
+	lines := make(map[lineKey]*lahmanLine)
+	if err := readLahmanBatting(filepath.Join(lahmanDir, "Batting.csv"), lines); err != nil {
+		t.Fatal(err)
+	}
+	if err := readLahmanPitching(filepath.Join(lahmanDir, "Pitching.csv"), lines); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+
+	carew := lines[lineKey{"carewro01", "MIN", 1977}]
+	if carew == nil || !carew.batting || carew.pitching || carew.h != 239 || carew.d != 38 || carew.hbp != 3 || carew.sf != 5 {
+		t.Errorf("Carew 1977 = %+v", carew)
+	}
+
+	// A pitcher who also batted keeps his batting games
+	rookie := lines[lineKey{"ryanno01", "NYN", 1966}]
+	if rookie == nil || !rookie.batting || !rookie.pitching || rookie.games != 2 || rookie.ipOuts != 9 || rookie.er != 5 {
+		t.Errorf("Ryan 1966 = %+v", rookie)
+	}
+	angel := lines[lineKey{"ryanno01", "CAL", 1973}]
+	if angel == nil || angel.batting || angel.games != 41 || angel.w != 21 || angel.so != 383 || angel.sv != 1 {
+		t.Errorf("Ryan 1973 = %+v", angel)
+	}
+}
+
+func TestReadLahmanAppearances(t *testing.T) {
+	appearances, err := readLahmanAppearances(filepath.Join(lahmanDir, "Appearances.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	twins := appearances["carewro01"]["MIN"]
+	if twins == nil || twins.start != 1977 || twins.end != 1978 || twins.games != 307 || twins.byPosition["1B"] != 299 {
+		t.Errorf("Carew's Twins totals = %+v", twins)
+	}
+	if got := primaryPosition(appearances["carewro01"]); got != "1B" {
+		t.Errorf("Carew's primary position = %q, want 1B", got)
+	}
+	if got := primaryPosition(appearances["ryanno01"]); got != "P" {
+		t.Errorf("Ryan's primary position = %q, want P", got)
+	}
+	if got := primaryPosition(nil); got != "" {
+		t.Errorf("primary position without appearances = %q, want none", got)
+	}
+}
+
+func TestReadLahmanAwards(t *testing.T) {
+	awards, err := readLahmanAwards(lahmanDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only players actually inducted count as Hall of Famers
+	want := []lahmanAward{
+		{playerID: "carewro01", name: "Most Valuable Player", league: "AL", year: 1977},
+		{playerID: "carewro01", name: "Silver Slugger", league: "AL", year: 1977, notes: "1B (tie)"},
+		{playerID: "carewro01", name: "Hall of Fame", league: "MLB", year: 1991, notes: "BBWAA"},
+		{playerID: "ryanno01", name: "Hall of Fame", league: "MLB", year: 1999, notes: "BBWAA"},
+	}
+	if !reflect.DeepEqual(awards, want) {
+		t.Errorf("readLahmanAwards =\n%+v\nwant\n%+v", awards, want)
+	}
+
+	none, err := readLahmanAwards(t.TempDir())
+	if err != nil || len(none) != 0 {
+		t.Errorf("readLahmanAwards without the files = %v, %v; want nothing", none, err)
+	}
+}
+
+func TestLahmanDate(t *testing.T) {
+	tests := map[string]string{
+		"1966-09-11": "1966-09-11",
+		"4/11/1967":  "1967-04-11",
+		"10/5/1985":  "1985-10-05",
+		"":           "",
+		"1966":       "",
+	}
+	for in, want := range tests {
+		if got := lahmanDate(in); got != want {
+			t.Errorf("lahmanDate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	same := [][2]string{
+		{"José Ramírez", "Jose Ramirez"},
+		{"Ken Griffey Jr.", "Ken Griffey"},
+		{"J.D. Martinez", "JD Martinez"},
+		{"Jean-Pierre O'Neill", "Jean Pierre ONeill"},
+	}
+	for _, names := range same {
+		if a, b := nameKey(names[0], "1990-01-01"), nameKey(names[1], "1990-01-01"); a != b {
+			t.Errorf("nameKey(%q) = %q, nameKey(%q) = %q; want equal", names[0], a, names[1], b)
+		}
+	}
+	if nameKey("Jose Ramirez", "1990-01-01") == nameKey("Jose Ramirez", "1992-09-17") {
+		t.Error("players born on different days share a name key")
+	}
+}
+
+func TestLahmanCoverage(t *testing.T) {
+	c := newLahmanCoverage()
+	for year := 1970; year <= 1979; year++ {
+		c.line(year, true)
+	}
+	for year := 1985; year <= 1990; year++ {
+		c.line(year, true)
+	}
+	// 1975 has a player the crosswalk missed, after one it matched
+	c.line(1975, false)
+	c.line(1980, false)
+
+	c.award(lahmanAward{name: "Most Valuable Player", league: "AL", year: 1977}, true)
+	c.award(lahmanAward{name: "Most Valuable Player", league: "NL", year: 1977}, true)
+	c.award(lahmanAward{name: "Most Valuable Player", league: "NL", year: 1978}, false)
+	c.award(lahmanAward{name: "Hall of Fame"}, false)
+	c.award(lahmanAward{name: "Triple Crown", league: "AL", year: 1967}, true) // no criteria
+
+	opts := c.options()
+	if opts.FromSeason != 1985 || opts.ToSeason != 1990 {
+		t.Errorf("complete seasons = %d-%d, want the longest unbroken run, 1985-1990", opts.FromSeason, opts.ToSeason)
+	}
+	if want := map[string]bool{"ALMVP": true}; !reflect.DeepEqual(opts.Awards, want) {
+		t.Errorf("complete awards = %v, want %v", opts.Awards, want)
+	}
+}
+
+func TestLahmanCoverageUnmatched(t *testing.T) {
+	c := newLahmanCoverage()
+	c.line(1977, false)
+	c.award(lahmanAward{name: "Hall of Fame"}, false)
+
+	if opts := c.options(); opts.FromSeason != 0 || len(opts.Awards) != 0 {
+		t.Errorf("coverage with nobody matched = %+v, want nothing complete", opts)
+	}
+}
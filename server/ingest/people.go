@@ -0,0 +1,184 @@
+package ingest
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+)
+
+// peopleFile mirrors the MLB Stats API response for /people?personIds=...,
+// which is what the dump files contain.
+type peopleFile struct {
+	People []mlbPerson `json:"people"`
+}
+
+type mlbPerson struct {
+	ID                 int    `json:"id"`
+	FullName           string `json:"fullName"`
+	BirthDate          string `json:"birthDate"` // YYYY-MM-DD
+	BirthCountry       string `json:"birthCountry"`
+	BirthStateProvince string `json:"birthStateProvince"`
+	Active             bool   `json:"active"`
+	MlbDebutDate       string `json:"mlbDebutDate"`   // YYYY-MM-DD
+	LastPlayedDate     string `json:"lastPlayedDate"` // YYYY-MM-DD
+	PrimaryPosition    struct {
+		Abbreviation string `json:"abbreviation"`
+	} `json:"primaryPosition"`
+	BatSide struct {
+		Code string `json:"code"`
+	} `json:"batSide"`
+	PitchHand struct {
+		Code string `json:"code"`
+	} `json:"pitchHand"`
+	Stats  []statGroup `json:"stats"`  // present when hydrated with yearByYear stats
+	Drafts []mlbDraft  `json:"drafts"` // present when hydrated with draft
+}
+
+// mlbDraft is one time a player was drafted.
+type mlbDraft struct {
+	Year       string `json:"year"`
+	PickRound  string `json:"pickRound"`  // "1", "2", ... or "1C" for supplemental picks
+	PickNumber int    `json:"pickNumber"` // overall
+}
+
+// lastDraft is the draft a player signed from: the last one they were
+// picked in. ok is false for players never drafted.
+func lastDraft(p mlbPerson) (d mlbDraft, ok bool) {
+	for _, draft := range p.Drafts {
+		if draft.Year == "" || draft.PickRound == "" {
+			continue
+		}
+		if !ok || draft.Year > d.Year {
+			d, ok = draft, true
+		}
+	}
+	return d, ok
+}
+
+// PeopleImportResult summarizes one ImportPeople run.
+type PeopleImportResult struct {
+	Files   int `json:"files"`
+	Players int `json:"players"`
+	Seasons int `json:"seasons"` // mlb_player_seasons rows written
+}
+
+// ImportPeople loads player biographies (position, handedness,
+// birthplace, birth date and, hydrated with draft, draft) from dir/*.json into mlb_players, creating
+// players it hasn't seen. Files fetched with
+// hydrate=stats(group=[hitting,pitching],type=[yearByYear]) also fill
+// mlb_player_seasons. The whole run is one transaction.
+func ImportPeople(db *sql.DB, dir string) (*PeopleImportResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin people import: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := importPeople(tx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit people import: %w", err)
+	}
+
+	log.Printf("People import: %d files, %d players, %d season lines", result.Files, result.Players, result.Seasons)
+	return result, nil
+}
+
+// importPeople does the work of ImportPeople inside the caller's
+// transaction.
+func importPeople(tx *sql.Tx, dir string) (*PeopleImportResult, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list people files: %w", err)
+	}
+
+	result := &PeopleImportResult{}
+	for _, path := range files {
+		var f peopleFile
+		if err := readJSON(path, &f); err != nil {
+			return nil, err
+		}
+		result.Files++
+
+		for _, p := range f.People {
+			if p.ID == 0 {
+				continue
+			}
+			if err := upsertPerson(tx, p); err != nil {
+				return nil, err
+			}
+			lines, err := upsertSeasons(tx, p)
+			if err != nil {
+				return nil, err
+			}
+			result.Players++
+			result.Seasons += len(lines)
+		}
+	}
+	return result, nil
+}
+
+// upsertPerson writes one biography. Empty fields become NULL so a thin
+// record never blanks out data a fuller one already stored.
+func upsertPerson(tx *sql.Tx, p mlbPerson) error {
+	_, err := tx.Exec(`
+		INSERT INTO mlb_players
+			(mlb_id, full_name, position, headshot_url, active,
+			 bats, throws, birth_country, birth_state, birth_date,
+			 debut_year, final_year)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			full_name     = VALUES(full_name),
+			position      = COALESCE(VALUES(position), position),
+			active        = VALUES(active),
+			bats          = COALESCE(VALUES(bats), bats),
+			throws        = COALESCE(VALUES(throws), throws),
+			birth_country = COALESCE(VALUES(birth_country), birth_country),
+			birth_state   = COALESCE(VALUES(birth_state), birth_state),
+			birth_date    = COALESCE(VALUES(birth_date), birth_date),
+			debut_year    = COALESCE(VALUES(debut_year), debut_year),
+			final_year    = COALESCE(VALUES(final_year), final_year)
+	`, p.ID, p.FullName, nullString(p.PrimaryPosition.Abbreviation), HeadshotURL(p.ID), p.Active,
+		nullString(p.BatSide.Code), nullString(p.PitchHand.Code),
+		nullString(p.BirthCountry), nullString(p.BirthStateProvince), nullString(p.BirthDate),
+		dateYear(p.MlbDebutDate), dateYear(p.LastPlayedDate))
+	if err != nil {
+		return fmt.Errorf("failed to upsert player %d: %w", p.ID, err)
+	}
+
+	draft, drafted := lastDraft(p)
+	if !drafted {
+		return nil
+	}
+	if _, err := tx.Exec(`
+		UPDATE mlb_players SET draft_year = ?, draft_round = ?, draft_pick = ?
+		WHERE mlb_id = ?
+	`, dateYear(draft.Year), draft.PickRound, nullInt(draft.PickNumber), p.ID); err != nil {
+		return fmt.Errorf("failed to record draft of player %d: %w", p.ID, err)
+	}
+	return nil
+}
+
+// dateYear returns the year of a YYYY-MM-DD date, or nil.
+func dateYear(date string) interface{} {
+	if len(date) < 4 {
+		return nil
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil || year == 0 {
+		return nil
+	}
+	return year
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
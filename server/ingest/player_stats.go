@@ -0,0 +1,104 @@
+package ingest
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"trivia-server/models"
+)
+
+// PlayerStatsImportResult summarizes one ImportPlayerStats run.
+type PlayerStatsImportResult struct {
+	Lines   int `json:"lines"`   // player_stats rows with an mlb_id and MLB team
+	Seasons int `json:"seasons"` // mlb_player_seasons rows added
+}
+
+// ImportPlayerStats copies the season lines in the legacy player_stats
+// table into mlb_player_seasons, so era, season and career criteria can
+// be evaluated from them. Only players matched to an mlb_id (see
+// ImportLahman) and teams with an mlb_team_id are copied, and a line
+// mlb_player_seasons already has is left alone: API and Lahman lines
+// carry walks, HBP and sacrifice flies, which player_stats doesn't. The
+// whole run is one transaction.
+func ImportPlayerStats(db *sql.DB) (*PlayerStatsImportResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin player stats import: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT p.mlb_id, t.mlb_team_id,
+		       ps.year, ps.games_played, ps.at_bats, ps.hits, ps.doubles, ps.triples,
+		       ps.home_runs, ps.rbis, ps.stolen_bases,
+		       ps.wins, ps.losses, ps.saves, ps.innings_pitched, ps.strikeouts, ps.era
+		FROM player_stats ps
+		JOIN players p ON p.id = ps.player_id
+		JOIN mlb_players mp ON mp.mlb_id = p.mlb_id
+		JOIN teams t ON t.id = ps.team_id
+		WHERE t.mlb_team_id IS NOT NULL
+		ORDER BY p.mlb_id, ps.year
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load player stats: %w", err)
+	}
+	type line struct {
+		mlbID, mlbTeamID int
+		stats            models.PlayerStats
+	}
+	var lines []line
+	for rows.Next() {
+		var l line
+		s := &l.stats
+		if err := rows.Scan(&l.mlbID, &l.mlbTeamID,
+			&s.Year, &s.GamesPlayed, &s.AtBats, &s.Hits, &s.Doubles, &s.Triples,
+			&s.HomeRuns, &s.RBIs, &s.StolenBases,
+			&s.Wins, &s.Losses, &s.Saves, &s.InningsPitched, &s.Strikeouts, &s.ERA); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan player stats: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &PlayerStatsImportResult{Lines: len(lines)}
+	for _, l := range lines {
+		s := l.stats
+		outs, earnedRuns := pitchingFromStats(s)
+		res, err := tx.Exec(`
+			INSERT IGNORE INTO mlb_player_seasons
+				(mlb_id, season, mlb_team_id, games, at_bats, hits, doubles, triples,
+				 home_runs, rbi, stolen_bases, wins, losses, saves,
+				 strikeouts, outs_pitched, earned_runs)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, l.mlbID, s.Year, l.mlbTeamID, s.GamesPlayed, s.AtBats, s.Hits, s.Doubles, s.Triples,
+			s.HomeRuns, s.RBIs, s.StolenBases, s.Wins, s.Losses, s.Saves,
+			s.Strikeouts, outs, earnedRuns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy %d stats for player %d: %w", s.Year, l.mlbID, err)
+		}
+		n, _ := res.RowsAffected()
+		result.Seasons += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit player stats import: %w", err)
+	}
+
+	log.Printf("Player stats import: %d lines, %d new season lines", result.Lines, result.Seasons)
+	return result, nil
+}
+
+// pitchingFromStats recovers outs pitched and earned runs from a
+// player_stats line, which stores innings in box-score notation (200.1
+// is 200⅓) and only the ERA.
+func pitchingFromStats(s models.PlayerStats) (outs, earnedRuns int) {
+	whole := math.Floor(s.InningsPitched)
+	outs = int(whole)*3 + int(math.Round((s.InningsPitched-whole)*10))
+	earnedRuns = int(math.Round(s.ERA * float64(outs) / 27))
+	return outs, earnedRuns
+}
@@ -0,0 +1,26 @@
+package ingest
+
+import (
+	"testing"
+	"trivia-server/models"
+)
+
+func TestPitchingFromStats(t *testing.T) {
+	tests := []struct {
+		innings, era   float64
+		outs, earnedRs int
+	}{
+		{0, 0, 0, 0},
+		{9, 1.00, 27, 1},
+		{200.1, 3.15, 601, 70},
+		{233.2, 3.35, 701, 87},
+		{0.2, 13.50, 2, 1},
+	}
+	for _, tt := range tests {
+		outs, er := pitchingFromStats(models.PlayerStats{InningsPitched: tt.innings, ERA: tt.era})
+		if outs != tt.outs || er != tt.earnedRs {
+			t.Errorf("pitchingFromStats(%v IP, %v ERA) = %d outs, %d ER; want %d, %d",
+				tt.innings, tt.era, outs, er, tt.outs, tt.earnedRs)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package ingest
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// HeadshotURL builds the MLB headshot URL for a player, matching the one
+// populate.py stores.
+func HeadshotURL(mlbID int) string {
+	return fmt.Sprintf("https://img.mlbstatic.com/mlb-photos/image/upload/"+
+		"d_people:generic:headshot:67:current.png/w_213,q_auto:best/"+
+		"v1/people/%d/headshot/67/current", mlbID)
+}
+
+// upsertPlayerName makes sure a player exists in mlb_players, updating
+// the name if it already does.
+func upsertPlayerName(tx *sql.Tx, mlbID int, fullName string) error {
+	_, err := tx.Exec(`
+		INSERT INTO mlb_players (mlb_id, full_name, headshot_url)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			full_name    = VALUES(full_name),
+			headshot_url = VALUES(headshot_url)
+	`, mlbID, fullName, HeadshotURL(mlbID))
+	if err != nil {
+		return fmt.Errorf("failed to upsert player %d: %w", mlbID, err)
+	}
+	return nil
+}
+
+// insertPlayerCriteria links a player to a criteria. Returns 1 if the
+// link is new, 0 if it already existed.
+func insertPlayerCriteria(tx *sql.Tx, mlbID, criteriaID int) (int, error) {
+	res, err := tx.Exec(`
+		INSERT IGNORE INTO player_criteria (mlb_id, criteria_id)
+		VALUES (?, ?)
+	`, mlbID, criteriaID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to link player %d to criteria %d: %w", mlbID, criteriaID, err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
@@ -0,0 +1,178 @@
+package ingest
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statGroup mirrors one entry of a person's hydrated "stats" array.
+type statGroup struct {
+	Type struct {
+		DisplayName string `json:"displayName"`
+	} `json:"type"`
+	Group struct {
+		DisplayName string `json:"displayName"`
+	} `json:"group"`
+	Splits []statSplit `json:"splits"`
+}
+
+type statSplit struct {
+	Season string `json:"season"`
+	Team   struct {
+		ID int `json:"id"`
+	} `json:"team"`
+	Stat seasonStat `json:"stat"`
+}
+
+// seasonStat holds the fields of both stat groups; each split only fills
+// the ones for its group.
+type seasonStat struct {
+	GamesPlayed    int    `json:"gamesPlayed"`
+	AtBats         int    `json:"atBats"`
+	Hits           int    `json:"hits"`
+	Doubles        int    `json:"doubles"`
+	Triples        int    `json:"triples"`
+	HomeRuns       int    `json:"homeRuns"`
+	RBI            int    `json:"rbi"`
+	StolenBases    int    `json:"stolenBases"`
+	BaseOnBalls    int    `json:"baseOnBalls"`
+	HitByPitch     int    `json:"hitByPitch"`
+	SacFlies       int    `json:"sacFlies"`
+	Wins           int    `json:"wins"`
+	Losses         int    `json:"losses"`
+	Saves          int    `json:"saves"`
+	StrikeOuts     int    `json:"strikeOuts"`
+	InningsPitched string `json:"inningsPitched"` // "200.1" = 200⅓
+	EarnedRuns     int    `json:"earnedRuns"`
+}
+
+// seasonLine is one year-by-year split for one team: group is "hitting"
+// or "pitching".
+type seasonLine struct {
+	group  string
+	season int
+	split  statSplit
+}
+
+// yearByYear returns p's per-team year-by-year lines. Traded players get
+// one split per team plus a combined one with no team; the per-team
+// splits are what we store.
+func yearByYear(p mlbPerson) []seasonLine {
+	var lines []seasonLine
+	for _, group := range p.Stats {
+		if group.Type.DisplayName != "yearByYear" {
+			continue
+		}
+		switch group.Group.DisplayName {
+		case "hitting", "pitching":
+		default:
+			continue
+		}
+		for _, split := range group.Splits {
+			season, _ := strconv.Atoi(split.Season)
+			if season == 0 || split.Team.ID == 0 {
+				continue
+			}
+			lines = append(lines, seasonLine{group: group.Group.DisplayName, season: season, split: split})
+		}
+	}
+	return lines
+}
+
+// upsertSeasons writes p's year-by-year lines to mlb_player_seasons and
+// returns them. Hitting and pitching splits for the same stint land on
+// the same row, each only touching its own columns, so files can be
+// imported in any order and any number of times.
+func upsertSeasons(tx *sql.Tx, p mlbPerson) ([]seasonLine, error) {
+	lines := yearByYear(p)
+	for _, line := range lines {
+		var err error
+		if line.group == "hitting" {
+			err = upsertHittingSeason(tx, p.ID, line.season, line.split)
+		} else {
+			err = upsertPitchingSeason(tx, p.ID, line.season, line.split)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+func upsertHittingSeason(tx *sql.Tx, mlbID, season int, split statSplit) error {
+	s := split.Stat
+	_, err := tx.Exec(`
+		INSERT INTO mlb_player_seasons
+			(mlb_id, season, mlb_team_id, games, at_bats, hits, doubles, triples,
+			 home_runs, rbi, stolen_bases, walks, hit_by_pitch, sac_flies)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			games        = GREATEST(games, VALUES(games)),
+			at_bats      = VALUES(at_bats),
+			hits         = VALUES(hits),
+			doubles      = VALUES(doubles),
+			triples      = VALUES(triples),
+			home_runs    = VALUES(home_runs),
+			rbi          = VALUES(rbi),
+			stolen_bases = VALUES(stolen_bases),
+			walks        = VALUES(walks),
+			hit_by_pitch = VALUES(hit_by_pitch),
+			sac_flies    = VALUES(sac_flies)
+	`, mlbID, season, split.Team.ID, s.GamesPlayed, s.AtBats, s.Hits, s.Doubles, s.Triples,
+		s.HomeRuns, s.RBI, s.StolenBases, s.BaseOnBalls, s.HitByPitch, s.SacFlies)
+	if err != nil {
+		return fmt.Errorf("failed to upsert %d hitting season for player %d: %w", season, mlbID, err)
+	}
+	return nil
+}
+
+// upsertPitchingSeason stores pitching lines. Strikeouts come only from
+// here — the hitting group's strikeOuts are times the batter struck out.
+func upsertPitchingSeason(tx *sql.Tx, mlbID, season int, split statSplit) error {
+	s := split.Stat
+	outs, err := outsPitched(s.InningsPitched)
+	if err != nil {
+		return fmt.Errorf("player %d, %d: %w", mlbID, season, err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO mlb_player_seasons
+			(mlb_id, season, mlb_team_id, games, wins, losses, saves,
+			 strikeouts, outs_pitched, earned_runs)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			games        = GREATEST(games, VALUES(games)),
+			wins         = VALUES(wins),
+			losses       = VALUES(losses),
+			saves        = VALUES(saves),
+			strikeouts   = VALUES(strikeouts),
+			outs_pitched = VALUES(outs_pitched),
+			earned_runs  = VALUES(earned_runs)
+	`, mlbID, season, split.Team.ID, s.GamesPlayed, s.Wins, s.Losses, s.Saves,
+		s.StrikeOuts, outs, s.EarnedRuns)
+	if err != nil {
+		return fmt.Errorf("failed to upsert %d pitching season for player %d: %w", season, mlbID, err)
+	}
+	return nil
+}
+
+// outsPitched converts the API's innings notation, where the digit after
+// the point counts outs rather than tenths ("200.2" is 602 outs).
+func outsPitched(ip string) (int, error) {
+	if ip == "" {
+		return 0, nil
+	}
+	whole, frac, _ := strings.Cut(ip, ".")
+	innings, err := strconv.Atoi(whole)
+	if err != nil {
+		return 0, fmt.Errorf("invalid innings pitched %q", ip)
+	}
+	outs := 0
+	if frac != "" {
+		if outs, err = strconv.Atoi(frac); err != nil || outs > 2 {
+			return 0, fmt.Errorf("invalid innings pitched %q", ip)
+		}
+	}
+	return innings*3 + outs, nil
+}
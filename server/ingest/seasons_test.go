@@ -0,0 +1,146 @@
+package ingest
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOutsPitched(t *testing.T) {
+	tests := []struct {
+		ip      string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0.0", 0, false},
+		{"0.1", 1, false},
+		{"200", 600, false},
+		{"200.2", 602, false},
+		{"233.2", 701, false},
+		{"1.3", 0, true},
+		{"x.1", 0, true},
+		{"5.a", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := outsPitched(tt.ip)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("outsPitched(%q) error = %v, wantErr %v", tt.ip, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("outsPitched(%q) = %d, want %d", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestPeopleDump(t *testing.T) {
+	var f peopleFile
+	if err := readJSON(filepath.Join("testdata", "people", "1969.json"), &f); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.People) != 3 {
+		t.Fatalf("got %d people, want 3", len(f.People))
+	}
+
+	carew := f.People[0]
+	if carew.ID != 121578 || carew.FullName != "Rod Carew" || carew.PrimaryPosition.Abbreviation != "2B" ||
+		carew.BatSide.Code != "L" || carew.PitchHand.Code != "R" || carew.BirthCountry != "Panama" {
+		t.Errorf("unexpected biography: %+v", carew)
+	}
+	if dateYear(carew.MlbDebutDate) != 1967 || dateYear(carew.LastPlayedDate) != 1985 {
+		t.Errorf("career span = %v-%v, want 1967-1985", dateYear(carew.MlbDebutDate), dateYear(carew.LastPlayedDate))
+	}
+	if ryan := f.People[1]; dateYear(ryan.MlbDebutDate) != nil {
+		t.Errorf("missing debut date = %v, want nil", dateYear(ryan.MlbDebutDate))
+	}
+
+	type line struct {
+		group  string
+		season int
+		team   int
+	}
+	summarize := func(p mlbPerson) []line {
+		var got []line
+		for _, l := range yearByYear(p) {
+			got = append(got, line{l.group, l.season, l.split.Team.ID})
+		}
+		return got
+	}
+
+	// The career split has no season and is dropped
+	if got, want := summarize(carew), []line{{"hitting", 1969, 142}, {"hitting", 1978, 142}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Carew lines = %v, want %v", got, want)
+	}
+	first := yearByYear(carew)[0].split.Stat
+	if first.AtBats != 458 || first.Hits != 152 || first.BaseOnBalls != 37 || first.SacFlies != 4 {
+		t.Errorf("unexpected 1969 hitting line: %+v", first)
+	}
+
+	// The combined split after a trade has no team, and fielding isn't stored
+	ryan := f.People[1]
+	if got, want := summarize(ryan), []line{{"pitching", 1971, 121}, {"pitching", 1980, 117}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ryan lines = %v, want %v", got, want)
+	}
+	if ip := yearByYear(ryan)[1].split.Stat.InningsPitched; ip != "233.2" {
+		t.Errorf("innings pitched = %q, want 233.2", ip)
+	}
+}
+
+func TestAwardDumps(t *testing.T) {
+	dir := filepath.Join("testdata", "awards")
+
+	files, err := awardFiles(dir, "MLBHOF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "MLBHOF.json")}; !reflect.DeepEqual(files, want) {
+		t.Errorf("MLBHOF files = %v, want %v", files, want)
+	}
+	files, err = awardFiles(dir, "ALMVP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "ALMVP", "1977.json")}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ALMVP files = %v, want %v", files, want)
+	}
+	if files, _ := awardFiles(dir, "NLCY"); len(files) != 0 {
+		t.Errorf("missing award has files %v", files)
+	}
+
+	var f awardRecipientsFile
+	if err := readJSON(filepath.Join(dir, "MLBHOF.json"), &f); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Awards) != 3 {
+		t.Fatalf("got %d recipients, want 3", len(f.Awards))
+	}
+	if a := f.Awards[1]; a.Season != "1991" || a.Player.ID != 121578 || a.Player.FullName != "Rod Carew" {
+		t.Errorf("unexpected recipient: %+v", a)
+	}
+}
+
+func TestLastDraft(t *testing.T) {
+	var f peopleFile
+	if err := readJSON(filepath.Join("testdata", "people", "1969.json"), &f); err != nil {
+		t.Fatal(err)
+	}
+	if _, drafted := lastDraft(f.People[0]); drafted {
+		t.Error("Carew, signed as an amateur free agent, has a draft")
+	}
+	want := mlbDraft{Year: "1965", PickRound: "12", PickNumber: 295}
+	if got, drafted := lastDraft(f.People[1]); !drafted || got != want {
+		t.Errorf("Ryan's draft = %+v, %v; want %+v", got, drafted, want)
+	}
+
+	// A player who didn't sign out of high school signs from a later draft
+	redrafted := mlbPerson{Drafts: []mlbDraft{
+		{Year: "2012", PickRound: "1", PickNumber: 7},
+		{Year: "2015", PickRound: "2", PickNumber: 60},
+		{Year: "2014", PickRound: "1", PickNumber: 22},
+		{Year: "2016", PickRound: ""},
+	}}
+	if got, _ := lastDraft(redrafted); got.Year != "2015" || got.PickRound != "2" {
+		t.Errorf("lastDraft = %+v, want the 2015 second round", got)
+	}
+}
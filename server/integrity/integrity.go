@@ -0,0 +1,353 @@
+// Package integrity finds (and optionally repairs) drift between
+// criteria, player_criteria, cell_answers and grid_templates — the
+// problems fix_missing_links.py and fix_missing_stat_links.py used to
+// patch by hand.
+package integrity
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"trivia-server/criteria"
+	"trivia-server/grid"
+)
+
+// ShortCell is a cell of an active template with too few answers.
+type ShortCell struct {
+	TemplateID int `json:"template_id"`
+	Row        int `json:"row"`
+	Col        int `json:"col"`
+	Answers    int `json:"answers"`
+}
+
+// StaleAnswer is a cell_answers row whose player no longer satisfies
+// the cell's row or column criteria.
+type StaleAnswer struct {
+	TemplateID int `json:"template_id"`
+	Row        int `json:"row"`
+	Col        int `json:"col"`
+	MlbID      int `json:"mlb_id"`
+}
+
+// UnlinkedTeam is a team criteria without an mlb_team_id. MlbTeamID is
+// the ID the checker could resolve from the teams table, if any.
+type UnlinkedTeam struct {
+	CriteriaID int    `json:"criteria_id"`
+	Label      string `json:"label"`
+	MlbTeamID  *int   `json:"mlb_team_id,omitempty"`
+}
+
+// StatViolation is a player linked to a stat criteria whose season data
+// doesn't reach the threshold.
+type StatViolation struct {
+	CriteriaID int    `json:"criteria_id"`
+	Label      string `json:"label"`
+	MlbID      int    `json:"mlb_id"`
+}
+
+// Fixes counts what Check changed in fix mode.
+type Fixes struct {
+	LinkedTeams          int `json:"linked_teams"`
+	RemovedLinks         int `json:"removed_links"`
+	DeletedAnswers       int `json:"deleted_answers"`
+	DeactivatedTemplates int `json:"deactivated_templates"`
+}
+
+// Report lists every problem Check found.
+type Report struct {
+	UnlinkedTeams  []UnlinkedTeam  `json:"unlinked_teams"`
+	StatViolations []StatViolation `json:"stat_violations"`
+	StaleAnswers   []StaleAnswer   `json:"stale_answers"`
+	ShortCells     []ShortCell     `json:"short_cells"`
+	Skipped        []string        `json:"skipped,omitempty"` // checks that couldn't run, with the reason
+	Fixed          *Fixes          `json:"fixed,omitempty"`   // nil unless run with fix
+}
+
+// Clean reports whether no problems were found.
+func (r *Report) Clean() bool {
+	return len(r.UnlinkedTeams) == 0 && len(r.StatViolations) == 0 &&
+		len(r.StaleAnswers) == 0 && len(r.ShortCells) == 0
+}
+
+// Check runs every consistency check inside one transaction. With fix it
+// also repairs what it finds and commits; otherwise nothing is written.
+// The checks run in dependency order — team links, stat links, stale
+// answers, short cells — so each one sees the repairs made before it:
+//
+//   - team criteria get the mlb_team_id of the teams row their label or
+//     short label names; ones that can't be resolved are only reported
+//   - links to stat criteria the player doesn't meet are removed, for
+//     players whose whole career is in mlb_player_seasons
+//   - stale cell_answers rows are deleted
+//   - active templates left with a short cell are deactivated
+func Check(db *sql.DB, fix bool) (*Report, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin integrity check: %w", err)
+	}
+	defer tx.Rollback()
+
+	report := &Report{}
+	if fix {
+		report.Fixed = &Fixes{}
+	}
+
+	steps := []func(*sql.Tx, *Report) error{
+		checkTeams, checkStatLinks, checkStaleAnswers, checkShortCells,
+	}
+	for _, step := range steps {
+		if err := step(tx, report); err != nil {
+			return nil, err
+		}
+	}
+
+	if fix {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit integrity fixes: %w", err)
+		}
+		if report.Fixed.RemovedLinks > 0 || report.Fixed.LinkedTeams > 0 {
+			if _, err := grid.RefreshDataVersion(db); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	log.Printf("Integrity check: %d unlinked teams, %d stat violations, %d stale answers, %d short cells",
+		len(report.UnlinkedTeams), len(report.StatViolations), len(report.StaleAnswers), len(report.ShortCells))
+	return report, nil
+}
+
+func checkTeams(tx *sql.Tx, report *Report) error {
+	rows, err := tx.Query(`
+		SELECT c.id, c.label,
+		       (SELECT t.mlb_team_id FROM teams t
+		        WHERE t.is_active = TRUE AND t.mlb_team_id IS NOT NULL
+		          AND (CONCAT(t.city, ' ', t.name) = c.label OR t.abbreviation = c.short_label)
+		        ORDER BY t.id LIMIT 1)
+		FROM criteria c
+		WHERE c.type = 'team' AND c.mlb_team_id IS NULL
+		ORDER BY c.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to check team criteria: %w", err)
+	}
+	var found []UnlinkedTeam
+	for rows.Next() {
+		var u UnlinkedTeam
+		if err := rows.Scan(&u.CriteriaID, &u.Label, &u.MlbTeamID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan team criteria: %w", err)
+		}
+		found = append(found, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	report.UnlinkedTeams = found
+
+	if report.Fixed == nil {
+		return nil
+	}
+	for _, u := range found {
+		if u.MlbTeamID == nil {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE criteria SET mlb_team_id = ? WHERE id = ?`, *u.MlbTeamID, u.CriteriaID); err != nil {
+			return fmt.Errorf("failed to link team criteria %d: %w", u.CriteriaID, err)
+		}
+		report.Fixed.LinkedTeams++
+	}
+	return nil
+}
+
+// checkStatLinks compares each stat criteria's links with what the
+// evaluator computes from mlb_player_seasons. Only extra links count —
+// a missing link may just be a player whose seasons weren't imported —
+// and only for players whose whole career is loaded (see linkedPlayers).
+func checkStatLinks(tx *sql.Tx, report *Report) error {
+	defs, err := criteria.LoadDefinitions(tx)
+	if err != nil {
+		return err
+	}
+
+	eval := criteria.NewEvaluator(tx)
+	for _, def := range defs {
+		if def.Type != "stat" {
+			continue
+		}
+		qualifying, err := eval.Evaluate(def)
+		if errors.Is(err, criteria.ErrNoSeasonData) {
+			report.Skipped = append(report.Skipped, "stat thresholds: "+err.Error())
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", def.Label, err)
+		}
+		meets := make(map[int]bool, len(qualifying))
+		for _, id := range qualifying {
+			meets[id] = true
+		}
+
+		linked, err := linkedPlayers(tx, def.ID)
+		if err != nil {
+			return err
+		}
+		for _, id := range linked {
+			if meets[id] {
+				continue
+			}
+			report.StatViolations = append(report.StatViolations, StatViolation{CriteriaID: def.ID, Label: def.Label, MlbID: id})
+			if report.Fixed == nil {
+				continue
+			}
+			if _, err := tx.Exec(`DELETE FROM player_criteria WHERE criteria_id = ? AND mlb_id = ?`, def.ID, id); err != nil {
+				return fmt.Errorf("failed to unlink player %d from criteria %d: %w", id, def.ID, err)
+			}
+			report.Fixed.RemovedLinks++
+		}
+	}
+	return nil
+}
+
+// linkedPlayers returns the players linked to a criteria whose season
+// lines span their whole career, debut to final season. Anyone with only
+// some seasons loaded (a pre-1969 career, a Lahman crosswalk miss) or no
+// known career span isn't judged: falling short of a career threshold on
+// part of a career proves nothing.
+func linkedPlayers(tx *sql.Tx, criteriaID int) ([]int, error) {
+	rows, err := tx.Query(`
+		SELECT pc.mlb_id FROM player_criteria pc
+		JOIN mlb_players p ON p.mlb_id = pc.mlb_id
+		WHERE pc.criteria_id = ?
+		  AND p.debut_year IS NOT NULL AND p.final_year IS NOT NULL
+		  AND (SELECT MIN(s.season) FROM mlb_player_seasons s WHERE s.mlb_id = pc.mlb_id) <= p.debut_year
+		  AND (SELECT MAX(s.season) FROM mlb_player_seasons s WHERE s.mlb_id = pc.mlb_id) >= p.final_year
+		ORDER BY pc.mlb_id
+	`, criteriaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load links for criteria %d: %w", criteriaID, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan linked player: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// cellRowCriteria and cellColCriteria pick the criteria a cell_answers
+// row's player must satisfy.
+const cellRowCriteria = `
+	CASE ca.row_index WHEN 0 THEN gt.row_criteria_1 WHEN 1 THEN gt.row_criteria_2 ELSE gt.row_criteria_3 END`
+const cellColCriteria = `
+	CASE ca.col_index WHEN 0 THEN gt.col_criteria_1 WHEN 1 THEN gt.col_criteria_2 ELSE gt.col_criteria_3 END`
+
+func checkStaleAnswers(tx *sql.Tx, report *Report) error {
+	rows, err := tx.Query(`
+		SELECT ca.id, ca.grid_template_id, ca.row_index, ca.col_index, ca.mlb_id
+		FROM cell_answers ca
+		JOIN grid_templates gt ON gt.id = ca.grid_template_id
+		WHERE NOT EXISTS (SELECT 1 FROM player_criteria pc WHERE pc.mlb_id = ca.mlb_id AND pc.criteria_id = ` + cellRowCriteria + `)
+		   OR NOT EXISTS (SELECT 1 FROM player_criteria pc WHERE pc.mlb_id = ca.mlb_id AND pc.criteria_id = ` + cellColCriteria + `)
+		ORDER BY ca.grid_template_id, ca.row_index, ca.col_index, ca.mlb_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to check cell answers: %w", err)
+	}
+	var ids []int
+	touched := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		var s StaleAnswer
+		if err := rows.Scan(&id, &s.TemplateID, &s.Row, &s.Col, &s.MlbID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan cell answer: %w", err)
+		}
+		ids = append(ids, id)
+		touched[s.TemplateID] = true
+		report.StaleAnswers = append(report.StaleAnswers, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if report.Fixed == nil {
+		return nil
+	}
+	for _, id := range ids {
+		if _, err := tx.Exec(`DELETE FROM cell_answers WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete stale cell answer %d: %w", id, err)
+		}
+		report.Fixed.DeletedAnswers++
+	}
+	for id := range touched {
+		if _, err := tx.Exec(`
+			UPDATE grid_templates
+			SET min_answers = (SELECT COUNT(*) FROM cell_answers WHERE grid_template_id = ?)
+			WHERE id = ?
+		`, id, id); err != nil {
+			return fmt.Errorf("failed to update min_answers for template %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// checkShortCells counts answers for all nine cells of every active
+// template — cells with no rows at all count as zero.
+func checkShortCells(tx *sql.Tx, report *Report) error {
+	rows, err := tx.Query(`
+		SELECT gt.id, cells.r, cells.c, COUNT(ca.id)
+		FROM grid_templates gt
+		CROSS JOIN (
+			SELECT 0 AS r, 0 AS c UNION ALL SELECT 0, 1 UNION ALL SELECT 0, 2
+			UNION ALL SELECT 1, 0 UNION ALL SELECT 1, 1 UNION ALL SELECT 1, 2
+			UNION ALL SELECT 2, 0 UNION ALL SELECT 2, 1 UNION ALL SELECT 2, 2
+		) cells
+		LEFT JOIN cell_answers ca
+		  ON ca.grid_template_id = gt.id AND ca.row_index = cells.r AND ca.col_index = cells.c
+		WHERE gt.active = TRUE
+		GROUP BY gt.id, cells.r, cells.c
+		HAVING COUNT(ca.id) < ?
+		ORDER BY gt.id, cells.r, cells.c
+	`, grid.MinAnswersPerCell)
+	if err != nil {
+		return fmt.Errorf("failed to check short cells: %w", err)
+	}
+	templates := make(map[int]bool)
+	var order []int
+	for rows.Next() {
+		var s ShortCell
+		if err := rows.Scan(&s.TemplateID, &s.Row, &s.Col, &s.Answers); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan short cell: %w", err)
+		}
+		report.ShortCells = append(report.ShortCells, s)
+		if !templates[s.TemplateID] {
+			templates[s.TemplateID] = true
+			order = append(order, s.TemplateID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if report.Fixed == nil {
+		return nil
+	}
+	for _, id := range order {
+		if _, err := tx.Exec(`UPDATE grid_templates SET active = FALSE WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to deactivate template %d: %w", id, err)
+		}
+		report.Fixed.DeactivatedTemplates++
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package integrity
+
+import (
+	"database/sql/driver"
+	"testing"
+	"trivia-server/internal/fakesql"
+)
+
+func ids(column string, values ...int64) fakesql.Result {
+	r := fakesql.Result{Columns: []string{column}}
+	for _, v := range values {
+		r.Rows = append(r.Rows, []driver.Value{v})
+	}
+	return r
+}
+
+// driftedDB has one problem of every kind: an unlinked team that can be
+// resolved, player 2 linked to 500 HR without the home runs, a cell
+// answer that relied on that link and the short cell it leaves.
+func driftedDB(t *testing.T) (*fakesql.DB, func(fix bool) *Report) {
+	db, fake := fakesql.Open(t,
+		"FROM teams t", fakesql.Result{
+			Columns: []string{"id", "label", "mlb_team_id"},
+			Rows:    [][]driver.Value{{int64(5), "Seattle Mariners", int64(136)}, {int64(6), "Montreal Expos", nil}},
+		},
+		"FROM criteria ORDER BY id", fakesql.Result{
+			Columns: []string{"id", "type", "label", "mlb_team_id", "stat_field", "stat_value", "stat_group",
+				"award_id", "start_year", "end_year", "anchor_mlb_id", "bio_field", "bio_value"},
+			Rows: [][]driver.Value{
+				{int64(5), "team", "Seattle Mariners", int64(136), nil, nil, nil, nil, nil, nil, nil, nil, nil},
+				{int64(9), "stat", "500+ HR Career", nil, "homeRuns", float64(500), "hitting", nil, nil, nil, nil, nil, nil},
+			},
+		},
+		"FROM mlb_player_seasons LIMIT 1", ids("found", 1),
+		"SUM(home_runs)", ids("mlb_id", 1),
+		"p.debut_year IS NOT NULL", ids("mlb_id", 1, 2),
+		"NOT EXISTS", fakesql.Result{
+			Columns: []string{"id", "grid_template_id", "row_index", "col_index", "mlb_id"},
+			Rows:    [][]driver.Value{{int64(77), int64(3), int64(0), int64(1), int64(2)}},
+		},
+		"CROSS JOIN", fakesql.Result{
+			Columns: []string{"id", "r", "c", "answers"},
+			Rows:    [][]driver.Value{{int64(3), int64(0), int64(1), int64(2)}},
+		},
+	)
+	return fake, func(fix bool) *Report {
+		t.Helper()
+		report, err := Check(db, fix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+}
+
+func TestCheckReports(t *testing.T) {
+	fake, check := driftedDB(t)
+	report := check(false)
+
+	if len(report.UnlinkedTeams) != 2 || *report.UnlinkedTeams[0].MlbTeamID != 136 || report.UnlinkedTeams[1].MlbTeamID != nil {
+		t.Errorf("unlinked teams = %+v", report.UnlinkedTeams)
+	}
+	if want := (StatViolation{CriteriaID: 9, Label: "500+ HR Career", MlbID: 2}); len(report.StatViolations) != 1 || report.StatViolations[0] != want {
+		t.Errorf("stat violations = %+v, want %+v", report.StatViolations, want)
+	}
+	if want := (StaleAnswer{TemplateID: 3, Row: 0, Col: 1, MlbID: 2}); len(report.StaleAnswers) != 1 || report.StaleAnswers[0] != want {
+		t.Errorf("stale answers = %+v, want %+v", report.StaleAnswers, want)
+	}
+	if want := (ShortCell{TemplateID: 3, Row: 0, Col: 1, Answers: 2}); len(report.ShortCells) != 1 || report.ShortCells[0] != want {
+		t.Errorf("short cells = %+v, want %+v", report.ShortCells, want)
+	}
+	if report.Clean() || report.Fixed != nil {
+		t.Errorf("report = %+v, want problems and no fixes", report)
+	}
+
+	// Without fix nothing is written
+	if len(fake.Executed("")) != 0 || fake.Committed() {
+		t.Errorf("report-only check wrote %v (committed: %v)", fake.Executed(""), fake.Committed())
+	}
+}
+
+func TestCheckFixes(t *testing.T) {
+	fake, check := driftedDB(t)
+	report := check(true)
+
+	if want := (Fixes{LinkedTeams: 1, RemovedLinks: 1, DeletedAnswers: 1, DeactivatedTemplates: 1}); report.Fixed == nil || *report.Fixed != want {
+		t.Errorf("fixes = %+v, want %+v", report.Fixed, want)
+	}
+	if !fake.Committed() {
+		t.Error("fixes weren't committed")
+	}
+	for substr, want := range map[string]string{
+		"SET mlb_team_id":               "UPDATE criteria SET mlb_team_id = ? WHERE id = ? [136 5]",
+		"DELETE FROM player_criteria":   "DELETE FROM player_criteria WHERE criteria_id = ? AND mlb_id = ? [9 2]",
+		"DELETE FROM cell_answers":      "DELETE FROM cell_answers WHERE id = ? [77]",
+		"SET active = FALSE":            "UPDATE grid_templates SET active = FALSE WHERE id = ? [3]",
+		"INSERT INTO grid_data_version": "",
+	} {
+		got := fake.Executed(substr)
+		if len(got) != 1 || (want != "" && got[0] != want) {
+			t.Errorf("statements with %q = %q, want one: %q", substr, got, want)
+		}
+	}
+}
+
+func TestCheckWithoutSeasons(t *testing.T) {
+	db, _ := fakesql.Open(t,
+		"FROM criteria ORDER BY id", fakesql.Result{
+			Columns: []string{"id", "type", "label", "mlb_team_id", "stat_field", "stat_value", "stat_group",
+				"award_id", "start_year", "end_year", "anchor_mlb_id", "bio_field", "bio_value"},
+			Rows: [][]driver.Value{
+				{int64(9), "stat", "500+ HR Career", nil, "homeRuns", float64(500), "hitting", nil, nil, nil, nil, nil, nil},
+			},
+		},
+		"FROM mlb_player_seasons LIMIT 1", ids("found", 0),
+	)
+	report, err := Check(db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stat links can't be judged, so none are removed
+	if len(report.Skipped) != 1 || len(report.StatViolations) != 0 || report.Fixed.RemovedLinks != 0 {
+		t.Errorf("report = %+v, want the stat check skipped", report)
+	}
+	if !report.Clean() {
+		t.Error("report with nothing found isn't clean")
+	}
+}
@@ -0,0 +1,165 @@
+// Package fakesql is a scripted database/sql driver for tests. A query
+// is answered with the Result of the first entry whose key it contains,
+// and every statement executed is recorded instead of run.
+package fakesql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Result is what the fake database answers a query with. A query
+// answered with an Err fails with it.
+type Result struct {
+	Columns []string
+	Rows    [][]driver.Value
+	Err     error
+}
+
+// DB is one fake database's script and what was done to it.
+type DB struct {
+	answers []answer
+
+	mu        sync.Mutex
+	execs     []string
+	committed bool
+}
+
+type answer struct {
+	contains string
+	result   Result
+}
+
+var (
+	mu  sync.Mutex
+	dbs = map[string]*DB{}
+)
+
+func init() {
+	sql.Register("fakesql", fakeDriver{})
+}
+
+// Open opens a database that answers queries from answers, which
+// alternate between a substring of the query and its Result. Queries
+// nothing matches get no rows.
+func Open(t testing.TB, answers ...interface{}) (*sql.DB, *DB) {
+	t.Helper()
+	db := &DB{}
+	for i := 0; i+1 < len(answers); i += 2 {
+		db.answers = append(db.answers, answer{answers[i].(string), answers[i+1].(Result)})
+	}
+
+	mu.Lock()
+	name := fmt.Sprintf("%s/%d", t.Name(), len(dbs))
+	dbs[name] = db
+	mu.Unlock()
+
+	conn, err := sql.Open("fakesql", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, db
+}
+
+func (db *DB) answer(query string) Result {
+	for _, a := range db.answers {
+		if strings.Contains(query, a.contains) {
+			return a.result
+		}
+	}
+	return Result{Columns: []string{"value"}}
+}
+
+// Executed returns the recorded statements that contain substr, each
+// with its arguments appended. An empty substr returns them all.
+func (db *DB) Executed(substr string) []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var found []string
+	for _, e := range db.execs {
+		if strings.Contains(e, substr) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Committed reports whether a transaction was committed.
+func (db *DB) Committed() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.committed
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	db, ok := dbs[name]
+	if !ok {
+		return nil, fmt.Errorf("no fake database %q", name)
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct{ db *DB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{c.db}, nil }
+
+type fakeTx struct{ db *DB }
+
+func (tx fakeTx) Commit() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.committed = true
+	return nil
+}
+func (tx fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *DB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.execs = append(s.db.execs, fmt.Sprintf("%s %v", strings.Join(strings.Fields(s.query), " "), args))
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	result := s.db.answer(s.query)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result Result
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.Columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.Rows[r.next])
+	r.next++
+	return nil
+}
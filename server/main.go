@@ -1,31 +1,125 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"expvar"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"trivia-server/grid"
 	"trivia-server/handlers"
 	"trivia-server/sessions"
 	"trivia-server/websocket"
 
 	"github.com/go-redis/redis"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
 
 func setupWebSocket(db *sql.DB) *websocket.Hub {
 	hub := websocket.NewHub(db)
+
+	poolSize, err := strconv.Atoi(os.Getenv("GRID_POOL_SIZE"))
+	if err != nil || poolSize <= 0 {
+		poolSize = 3
+	}
+	hub.GridPool = grid.NewPool(grid.NewService(db), poolSize)
+	hub.GridPool.Start()
+
+	if grace, err := strconv.Atoi(os.Getenv("RECONNECT_GRACE_SECONDS")); err == nil && grace >= 0 {
+		hub.ReconnectGrace = time.Duration(grace) * time.Second
+	}
+	// Comma-separated words masked in chat
+	if words := os.Getenv("CHAT_BANNED_WORDS"); words != "" {
+		hub.ChatFilter = websocket.NewChatFilter(strings.Split(words, ","))
+	}
+	hub.Limits = rateLimitsFromEnv(hub.Limits)
+	// "takeover" (default): a user's newest connection takes their seat;
+	// "mirror": every tab stays connected and follows the seat
+	switch policy := websocket.SessionPolicy(os.Getenv("SESSION_POLICY")); policy {
+	case "":
+	case websocket.SessionTakeover, websocket.SessionMirror:
+		hub.SessionPolicy = policy
+	default:
+		log.Fatalf("Invalid SESSION_POLICY %q: want takeover or mirror", policy)
+	}
+
 	go hub.Run()
 	return hub
 }
 
+// setupBackplane connects the hub to the other instances. INSTANCE_ID
+// names this one (a random ID by default) and WS_LEASE_SECONDS sets how
+// long its rooms survive it going away.
+func setupBackplane(hub *websocket.Hub, redisClient *redis.Client, gm *websocket.GameManager) {
+	instanceID := os.Getenv("INSTANCE_ID")
+	if instanceID == "" {
+		instanceID = uuid.New().String()
+	}
+	backplane := websocket.NewBackplane(hub, redisClient, gm, instanceID)
+	if lease, err := strconv.Atoi(os.Getenv("WS_LEASE_SECONDS")); err == nil && lease >= 3 {
+		backplane.LeaseTTL = time.Duration(lease) * time.Second
+	}
+	if err := backplane.Start(); err != nil {
+		log.Fatal("Failed to start websocket backplane:", err)
+	}
+}
+
+// rateLimitsFromEnv overrides the websocket rate limits that are set in
+// the environment, e.g. WS_TYPE_LIMITS=create_room=0.2:3,make_move=2:4.
+func rateLimitsFromEnv(limits websocket.Limits) websocket.Limits {
+	envFloat := func(name string, dst *float64) {
+		if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && v >= 0 {
+			*dst = v
+		}
+	}
+	envInt := func(name string, dst *int) {
+		if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v >= 0 {
+			*dst = v
+		}
+	}
+
+	envFloat("WS_CONN_RATE", &limits.Connection.Rate)
+	envInt("WS_CONN_BURST", &limits.Connection.Burst)
+	envFloat("WS_USER_RATE", &limits.User.Rate)
+	envInt("WS_USER_BURST", &limits.User.Burst)
+	envInt("WS_THROTTLE_AFTER", &limits.ThrottleAfter)
+	envInt("WS_DISCONNECT_AFTER", &limits.DisconnectAfter)
+	if secs, err := strconv.Atoi(os.Getenv("WS_THROTTLE_SECONDS")); err == nil && secs >= 0 {
+		limits.ThrottleFor = time.Duration(secs) * time.Second
+	}
+
+	if spec := os.Getenv("WS_TYPE_LIMITS"); spec != "" {
+		perType, err := websocket.ParseTypeLimits(spec)
+		if err != nil {
+			log.Fatal("Invalid WS_TYPE_LIMITS: ", err)
+		}
+		for msgType, limit := range perType {
+			limits.PerType[msgType] = limit
+		}
+	}
+	return limits
+}
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system env vars")
 	}
 
+	// One-off admin subcommands (import-awards, ...) run and exit
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -36,7 +130,6 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
 
 	// Redis connection
 	redisClient := redis.NewClient(&redis.Options{
@@ -49,17 +142,37 @@ func main() {
 	userService := sessions.NewUserService(db, redisClient)
 	jwtService := sessions.NewJWTService(os.Getenv("JWT_SECRET"), redisClient)
 	userHandler := handlers.NewUserHandler(userService, jwtService)
+	gridService := grid.NewService(db)
+	gridHandler := handlers.NewGridHandler(gridService)
+	gridAdminHandler := handlers.NewGridAdminHandler(gridService)
+	dataAdminHandler := handlers.NewDataAdminHandler(db)
 
 	// Router
 	router := mux.NewRouter()
 	SetupUserRoutes(router, userHandler, jwtService)
+	SetupGridRoutes(router, gridHandler, jwtService)
+	SetupAdminRoutes(router, gridAdminHandler, dataAdminHandler, userService, jwtService)
 
 	// WebSocket Hub
 	wsHub := setupWebSocket(db)
 
 	// Create GameManager and pass into handler along with JWT service
 	gm := websocket.NewGameManager()
+
+	// WS_BACKPLANE=redis shares rooms and the lobby with every other
+	// instance using the same Redis, so several can run side by side
+	if os.Getenv("WS_BACKPLANE") == "redis" {
+		setupBackplane(wsHub, redisClient, gm)
+	}
+
+	// Games in progress are saved to Redis as they change; pick up the
+	// ones the last run left off, holding each seat for its player
+	wsHub.Games = websocket.NewGameStore(redisClient)
+	if restored := wsHub.RestoreGames(gm); restored > 0 {
+		log.Printf("Restored %d games from before the restart", restored)
+	}
 	router.HandleFunc("/ws", websocket.Handler(wsHub, jwtService, gm))
+	router.HandleFunc("/api/protocol/schema", websocket.SchemaHandler).Methods("GET")
 
 	// SPA fallback — serve static files if they exist, otherwise serve index.html
 	// This allows /lobby and /game to work as browser URLs without 404ing
@@ -83,8 +196,46 @@ func main() {
 	}).Methods("GET")
 
 	// Start server
-	log.Printf("Server starting on :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, router))
+	srv := &http.Server{Addr: ":" + port, Handler: router}
+	go func() {
+		log.Printf("Server starting on :%s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// SIGTERM (docker stop) or Ctrl-C: drain and stop within
+	// SHUTDOWN_TIMEOUT_SECONDS
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	shutdown(srv, wsHub, db, redisClient)
+}
+
+// shutdown stops the server gracefully: the websocket hub drains and
+// saves its games, in-flight HTTP requests finish, then the DB and Redis
+// connections close.
+func shutdown(srv *http.Server, hub *websocket.Hub, db *sql.DB, redisClient *redis.Client) {
+	timeout := 30 * time.Second
+	if secs, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT_SECONDS")); err == nil && secs > 0 {
+		timeout = time.Duration(secs) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	log.Printf("Shutting down, draining for up to %s", timeout)
+
+	hub.Shutdown(ctx)
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	hub.GridPool.Stop()
+	if err := redisClient.Close(); err != nil {
+		log.Printf("Failed to close Redis: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
+	log.Printf("Server stopped")
 }
 
 func SetupUserRoutes(router *mux.Router, userHandler *handlers.UserHandler, jwtService *sessions.JWTService) {
@@ -101,8 +252,25 @@ func SetupUserRoutes(router *mux.Router, userHandler *handlers.UserHandler, jwtS
 	protected.HandleFunc("/profile/check-username", userHandler.CheckUsernameAvailable).Methods("POST")
 	protected.HandleFunc("/profile/username", userHandler.UpdateUsername).Methods("PUT")
 	protected.HandleFunc("/profile/password", userHandler.UpdatePassword).Methods("PUT")
-	protected.HandleFunc("/profile/team", userHandler.UpdateFavoriteTeam).Methods("PUT")
+	protected.HandleFunc("/profile/teams", userHandler.UpdateFavoriteTeams).Methods("PUT")
 	protected.HandleFunc("/profile/history", userHandler.GetGameHistory).Methods("GET")
 	protected.HandleFunc("/profile", userHandler.DeleteAccount).Methods("DELETE")
 
 }
+
+func SetupGridRoutes(router *mux.Router, gridHandler *handlers.GridHandler, jwtService *sessions.JWTService) {
+	grids := router.PathPrefix("/api").Subrouter()
+	grids.Use(sessions.AuthMiddleware(jwtService))
+	gridHandler.RegisterGridRoutes(grids)
+}
+
+func SetupAdminRoutes(router *mux.Router, gridAdminHandler *handlers.GridAdminHandler, dataAdminHandler *handlers.DataAdminHandler, userService *sessions.UserService, jwtService *sessions.JWTService) {
+	// Admin routes — authenticated, then checked against users.is_admin
+	admin := router.PathPrefix("/api/admin").Subrouter()
+	admin.Use(sessions.AuthMiddleware(jwtService))
+	admin.Use(sessions.AdminMiddleware(userService))
+	gridAdminHandler.RegisterGridAdminRoutes(admin)
+	dataAdminHandler.RegisterDataAdminRoutes(admin)
+	// Runtime counters, including websocket_rate_limits
+	admin.Handle("/metrics", expvar.Handler()).Methods("GET")
+}
@@ -16,6 +16,15 @@ type User struct {
 	GamesWon         int       `json:"games_won" db:"games_won"`
 	FavoriteTeamID   *int      `json:"favorite_team_id,omitempty" db:"favorite_team_id"`
 	FavoriteTeamName *string   `json:"favorite_team_name,omitempty" db:"favorite_team_name"`
+	// Ranked favorites, best first; FavoriteTeamID mirrors the first
+	FavoriteTeams []FavoriteTeam `json:"favorite_teams,omitempty"`
+}
+
+// FavoriteTeam is one of a user's ranked favorite teams
+type FavoriteTeam struct {
+	Rank      int    `json:"rank"`
+	MlbTeamID int    `json:"team_id"`
+	TeamName  string `json:"team_name"`
 }
 
 // UserStats represents user game statistics
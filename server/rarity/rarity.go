@@ -0,0 +1,162 @@
+// Package rarity scores how obscure each player is as a grid answer and
+// keeps cell_answers in step with those scores.
+//
+// A player's rarity_score runs from 0.0 (very rare) to 1.0 (very common)
+// and is a weighted blend of three signals, each scaled to [0, 1] against
+// the highest value among all players:
+//
+//	fame       = accomplishment / max accomplishment
+//	breadth    = ln(1 + criteria) / ln(1 + max criteria)
+//	popularity = ln(1 + uses) / ln(1 + max uses)
+//
+//	rarity_score = 0.60·fame + 0.25·breadth + 0.15·popularity
+//
+// accomplishment sums Weight over every criteria the player satisfies,
+// so a Hall of Famer MVP outweighs a journeyman who played for five
+// teams. criteria counts them unweighted: players who fit many cells are
+// easier to think of. uses is answer_frequency, i.e. how often players
+// have actually answered with them. The logs keep a few famous outliers
+// from squashing everyone else toward zero.
+package rarity
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	fameWeight       = 0.60
+	breadthWeight    = 0.25
+	popularityWeight = 0.15
+
+	// DefaultScore is what readers fall back to for players that have
+	// never been scored.
+	DefaultScore = 0.5
+
+	insertBatchSize = 500
+)
+
+// Score is one player's computed rarity and the inputs behind it.
+type Score struct {
+	MlbID          int     `json:"mlb_id"`
+	Rarity         float64 `json:"rarity_score"`
+	Accomplishment int     `json:"accomplishment_score"`
+	Criteria       int     `json:"criteria_count"`
+	Uses           int     `json:"use_count"`
+}
+
+// Weight is how much satisfying one criteria adds to a player's
+// accomplishment score — the same tiers rebuild_cell_answers.py used.
+func Weight(cType, awardID string) int {
+	switch awardID {
+	case "MLBHOF", "ALMVP", "NLMVP":
+		return 5
+	case "ALCY", "NLCY", "WSMVP", "WSCHAMP":
+		return 4
+	case "ALAS", "NLAS", "ALGG", "NLGG", "ALSS", "NLSS":
+		return 3
+	case "ALROY", "NLROY":
+		return 2
+	}
+	if cType == "stat" {
+		return 2
+	}
+	return 1
+}
+
+// Compute scores every player linked to at least one criteria, ordered
+// by mlb_id.
+func Compute(db *sql.DB) ([]Score, error) {
+	rows, err := db.Query(`
+		SELECT pc.mlb_id, c.type, COALESCE(c.award_id, ''), COUNT(*),
+		       COALESCE(MAX(af.use_count), 0)
+		FROM player_criteria pc
+		JOIN criteria c ON c.id = pc.criteria_id
+		LEFT JOIN answer_frequency af ON af.mlb_id = pc.mlb_id
+		GROUP BY pc.mlb_id, c.type, c.award_id
+		ORDER BY pc.mlb_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load player criteria for rarity: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []Score
+	for rows.Next() {
+		var mlbID, count, uses int
+		var cType, awardID string
+		if err := rows.Scan(&mlbID, &cType, &awardID, &count, &uses); err != nil {
+			return nil, fmt.Errorf("failed to scan rarity row: %w", err)
+		}
+		if len(scores) == 0 || scores[len(scores)-1].MlbID != mlbID {
+			scores = append(scores, Score{MlbID: mlbID, Uses: uses})
+		}
+		s := &scores[len(scores)-1]
+		s.Accomplishment += Weight(cType, awardID) * count
+		s.Criteria += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	scoreRarity(scores)
+	return scores, nil
+}
+
+// scoreRarity fills in each score's Rarity from its inputs, scaled
+// against the highest of each among scores.
+func scoreRarity(scores []Score) {
+	var maxAcc, maxCriteria, maxUses int
+	for _, s := range scores {
+		maxAcc = max(maxAcc, s.Accomplishment)
+		maxCriteria = max(maxCriteria, s.Criteria)
+		maxUses = max(maxUses, s.Uses)
+	}
+	for i := range scores {
+		s := &scores[i]
+		fame := ratio(float64(s.Accomplishment), float64(maxAcc))
+		breadth := ratio(math.Log1p(float64(s.Criteria)), math.Log1p(float64(maxCriteria)))
+		popularity := ratio(math.Log1p(float64(s.Uses)), math.Log1p(float64(maxUses)))
+		s.Rarity = math.Round((fameWeight*fame+breadthWeight*breadth+popularityWeight*popularity)*10000) / 10000
+	}
+}
+
+func ratio(v, max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return v / max
+}
+
+// Store replaces player_rarity with scores in one transaction.
+func Store(db *sql.DB, scores []Score) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin rarity update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM player_rarity`); err != nil {
+		return fmt.Errorf("failed to clear player_rarity: %w", err)
+	}
+
+	for start := 0; start < len(scores); start += insertBatchSize {
+		batch := scores[start:min(start+insertBatchSize, len(scores))]
+		args := make([]interface{}, 0, len(batch)*5)
+		for _, s := range batch {
+			args = append(args, s.MlbID, s.Rarity, s.Accomplishment, s.Criteria, s.Uses)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO player_rarity (mlb_id, rarity_score, accomplishment_score, criteria_count, use_count)
+			VALUES `+strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?), ", len(batch)), ", "),
+			args...); err != nil {
+			return fmt.Errorf("failed to store rarity scores: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rarity update: %w", err)
+	}
+	return nil
+}
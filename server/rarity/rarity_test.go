@@ -0,0 +1,67 @@
+package rarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeight(t *testing.T) {
+	tests := []struct {
+		cType, awardID string
+		want           int
+	}{
+		{"award", "MLBHOF", 5},
+		{"award", "NLMVP", 5},
+		{"award", "ALCY", 4},
+		{"award", "WSCHAMP", 4},
+		{"award", "NLAS", 3},
+		{"award", "ALROY", 2},
+		{"award", "ALCPOY", 1}, // awards outside the tiers
+		{"stat", "", 2},
+		{"team", "", 1},
+		{"teammate", "", 1},
+	}
+	for _, tt := range tests {
+		if got := Weight(tt.cType, tt.awardID); got != tt.want {
+			t.Errorf("Weight(%q, %q) = %d, want %d", tt.cType, tt.awardID, got, tt.want)
+		}
+	}
+}
+
+func TestScoreRarity(t *testing.T) {
+	scores := []Score{
+		{MlbID: 1, Accomplishment: 40, Criteria: 20, Uses: 99}, // the top of every signal
+		{MlbID: 2, Accomplishment: 20, Criteria: 20, Uses: 0},
+		{MlbID: 3, Accomplishment: 0, Criteria: 0, Uses: 0},
+	}
+	scoreRarity(scores)
+
+	if scores[0].Rarity != 1 {
+		t.Errorf("top player = %v, want 1", scores[0].Rarity)
+	}
+	want := math.Round((fameWeight*0.5+breadthWeight*1)*10000) / 10000
+	if scores[1].Rarity != want {
+		t.Errorf("half the fame, all the breadth, no uses = %v, want %v", scores[1].Rarity, want)
+	}
+	if scores[2].Rarity != 0 {
+		t.Errorf("player with nothing = %v, want 0", scores[2].Rarity)
+	}
+
+	// Breadth and popularity are on a log scale, so half the criteria
+	// is worth more than half the breadth
+	logScaled := []Score{{MlbID: 1, Criteria: 100}, {MlbID: 2, Criteria: 50}}
+	scoreRarity(logScaled)
+	if got := logScaled[1].Rarity; got <= breadthWeight/2 || got >= breadthWeight {
+		t.Errorf("half the criteria = %v, want between %v and %v", got, breadthWeight/2, breadthWeight)
+	}
+}
+
+func TestScoreRarityNothingToScale(t *testing.T) {
+	// No one has any uses yet: popularity is 0 rather than NaN
+	scores := []Score{{MlbID: 1, Accomplishment: 5, Criteria: 3}}
+	scoreRarity(scores)
+	if want := fameWeight + breadthWeight; math.Abs(scores[0].Rarity-want) > 1e-9 {
+		t.Errorf("rarity = %v, want %v", scores[0].Rarity, want)
+	}
+	scoreRarity(nil)
+}
@@ -0,0 +1,147 @@
+package rarity
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"trivia-server/grid"
+)
+
+// DefaultBatchSize is how many templates RebuildCellAnswers rewrites per
+// transaction.
+const DefaultBatchSize = 50
+
+// Result summarizes one Rebuild run.
+type Result struct {
+	Players   int   `json:"players"`   // players scored
+	Templates int   `json:"templates"` // templates rebuilt
+	Answers   int   `json:"answers"`   // cell_answers rows written
+	Degraded  []int `json:"degraded"`  // templates with a cell under grid.MinAnswersPerCell
+}
+
+// Rebuild recomputes player_rarity and then every active template's
+// cell_answers from it.
+func Rebuild(db *sql.DB, batchSize int) (*Result, error) {
+	scores, err := Compute(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := Store(db, scores); err != nil {
+		return nil, err
+	}
+
+	result, err := RebuildCellAnswers(db, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	result.Players = len(scores)
+	return result, nil
+}
+
+// RebuildCellAnswers rewrites cell_answers for every active template from
+// the current player_criteria and player_rarity, batchSize templates per
+// transaction so a long rebuild never holds locks on everything at once.
+// Games in progress keep validating against the old rows until a batch
+// commits. min_answers is refreshed along the way.
+func RebuildCellAnswers(db *sql.DB, batchSize int) (*Result, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	templates, err := loadActiveTemplates(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for start := 0; start < len(templates); start += batchSize {
+		batch := templates[start:min(start+batchSize, len(templates))]
+		if err := rebuildBatch(db, batch, result); err != nil {
+			return nil, err
+		}
+		log.Printf("Rarity rebuild: %d/%d templates", result.Templates, len(templates))
+	}
+	return result, nil
+}
+
+type templateCriteria struct {
+	id     int
+	rowIDs [3]int
+	colIDs [3]int
+}
+
+func loadActiveTemplates(db *sql.DB) ([]templateCriteria, error) {
+	rows, err := db.Query(`
+		SELECT id, row_criteria_1, row_criteria_2, row_criteria_3,
+		       col_criteria_1, col_criteria_2, col_criteria_3
+		FROM grid_templates
+		WHERE active = TRUE
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load grid templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []templateCriteria
+	for rows.Next() {
+		var t templateCriteria
+		if err := rows.Scan(&t.id, &t.rowIDs[0], &t.rowIDs[1], &t.rowIDs[2],
+			&t.colIDs[0], &t.colIDs[1], &t.colIDs[2]); err != nil {
+			return nil, fmt.Errorf("failed to scan grid template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+func rebuildBatch(db *sql.DB, batch []templateCriteria, result *Result) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin cell answer rebuild: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, t := range batch {
+		if _, err := tx.Exec(`DELETE FROM cell_answers WHERE grid_template_id = ?`, t.id); err != nil {
+			return fmt.Errorf("failed to clear cell answers for template %d: %w", t.id, err)
+		}
+
+		total, degraded := 0, false
+		for ri, rowC := range t.rowIDs {
+			for ci, colC := range t.colIDs {
+				res, err := tx.Exec(`
+					INSERT INTO cell_answers
+					(grid_template_id, row_index, col_index, mlb_id, player_name, headshot_url, rarity_score)
+					SELECT ?, ?, ?, p.mlb_id, p.full_name, p.headshot_url, COALESCE(pr.rarity_score, ?)
+					FROM mlb_players p
+					JOIN player_criteria pc1 ON pc1.mlb_id = p.mlb_id AND pc1.criteria_id = ?
+					JOIN player_criteria pc2 ON pc2.mlb_id = p.mlb_id AND pc2.criteria_id = ?
+					LEFT JOIN player_rarity pr ON pr.mlb_id = p.mlb_id
+				`, t.id, ri, ci, DefaultScore, rowC, colC)
+				if err != nil {
+					return fmt.Errorf("failed to rebuild cell (%d,%d) of template %d: %w", ri, ci, t.id, err)
+				}
+				n, _ := res.RowsAffected()
+				total += int(n)
+				if n < grid.MinAnswersPerCell {
+					degraded = true
+				}
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE grid_templates SET min_answers = ? WHERE id = ?`, total, t.id); err != nil {
+			return fmt.Errorf("failed to update min_answers for template %d: %w", t.id, err)
+		}
+		result.Templates++
+		result.Answers += total
+		if degraded {
+			result.Degraded = append(result.Degraded, t.id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cell answer rebuild: %w", err)
+	}
+	return nil
+}
@@ -44,6 +44,33 @@ func AuthMiddleware(jwtService *JWTService) mux.MiddlewareFunc {
 	}
 }
 
+// AdminMiddleware only lets admin users through. It must run after
+// AuthMiddleware, which puts the userID into the request context.
+func AdminMiddleware(userService *UserService) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := r.Context().Value("userID").(int)
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			isAdmin, err := userService.IsAdmin(userID)
+			if err != nil {
+				log.Printf("Admin check failed for user %d: %v", userID, err)
+				http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+				return
+			}
+			if !isAdmin {
+				http.Error(w, "Admin access required", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 type JWTService struct {
 	secretKey string
 	redis     *redis.Client
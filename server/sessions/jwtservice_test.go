@@ -0,0 +1,19 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminMiddlewareWithoutUser(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	// Without AuthMiddleware in front there's no user to check
+	rec := httptest.NewRecorder()
+	AdminMiddleware(nil)(next).ServeHTTP(rec, httptest.NewRequest("GET", "/api/admin/grids", nil))
+	if rec.Code != http.StatusUnauthorized || called {
+		t.Errorf("request without a user = %d (handler called: %v), want 401", rec.Code, called)
+	}
+}
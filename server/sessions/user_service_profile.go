@@ -8,6 +8,7 @@ package sessions
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"trivia-server/models"
 
@@ -75,28 +76,98 @@ func (us *UserService) UpdatePassword(userID int, currentPassword, newPassword s
 	return nil
 }
 
-// UpdateFavoriteTeam sets or clears the user's favorite team.
-// Pass teamID = 0 and teamName = "" to clear.
-func (us *UserService) UpdateFavoriteTeam(userID int, teamID int, teamName string) error {
-	if teamID == 0 {
-		_, err := us.db.Exec(
-			`UPDATE users SET favorite_team_id = NULL, favorite_team_name = NULL, updated_at = NOW() WHERE id = ?`,
-			userID,
-		)
+// MaxFavoriteTeams is how many ranked favorite teams a user may keep.
+const MaxFavoriteTeams = 3
+
+var (
+	ErrTooManyTeams  = fmt.Errorf("at most %d favorite teams are allowed", MaxFavoriteTeams)
+	ErrDuplicateTeam = errors.New("a team can only be listed once")
+	ErrUnknownTeam   = errors.New("unknown team")
+)
+
+// SetFavoriteTeams replaces the user's ranked favorite teams, best first.
+// Each team ID must match a team criteria's mlb_team_id; a missing name
+// is filled from the criteria label. An empty list clears them.
+func (us *UserService) SetFavoriteTeams(userID int, teams []models.FavoriteTeam) error {
+	if len(teams) > MaxFavoriteTeams {
+		return ErrTooManyTeams
+	}
+
+	tx, err := us.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin favorite team update: %w", err)
+	}
+	defer tx.Rollback()
+
+	seen := make(map[int]bool, len(teams))
+	for i := range teams {
+		t := &teams[i]
+		if seen[t.MlbTeamID] {
+			return fmt.Errorf("%w: %d", ErrDuplicateTeam, t.MlbTeamID)
+		}
+		seen[t.MlbTeamID] = true
+
+		var label string
+		err := tx.QueryRow(`SELECT label FROM criteria WHERE type = 'team' AND mlb_team_id = ?`, t.MlbTeamID).Scan(&label)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %d", ErrUnknownTeam, t.MlbTeamID)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to clear favorite team: %w", err)
+			return fmt.Errorf("failed to check team %d: %w", t.MlbTeamID, err)
+		}
+		if t.TeamName == "" {
+			t.TeamName = label
 		}
-		return nil
+		t.Rank = i + 1
 	}
 
-	_, err := us.db.Exec(
+	if _, err := tx.Exec(`DELETE FROM user_favorite_teams WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to clear favorite teams: %w", err)
+	}
+	for _, t := range teams {
+		if _, err := tx.Exec(
+			`INSERT INTO user_favorite_teams (user_id, team_rank, mlb_team_id, team_name) VALUES (?, ?, ?, ?)`,
+			userID, t.Rank, t.MlbTeamID, t.TeamName,
+		); err != nil {
+			return fmt.Errorf("failed to save favorite team %d: %w", t.MlbTeamID, err)
+		}
+	}
+
+	// Keep the single-team columns pointing at the top pick
+	var topID, topName interface{}
+	if len(teams) > 0 {
+		topID, topName = teams[0].MlbTeamID, teams[0].TeamName
+	}
+	if _, err := tx.Exec(
 		`UPDATE users SET favorite_team_id = ?, favorite_team_name = ?, updated_at = NOW() WHERE id = ?`,
-		teamID, teamName, userID,
-	)
-	if err != nil {
+		topID, topName, userID,
+	); err != nil {
 		return fmt.Errorf("failed to update favorite team: %w", err)
 	}
-	return nil
+
+	return tx.Commit()
+}
+
+// GetFavoriteTeams returns the user's ranked favorite teams, best first.
+func (us *UserService) GetFavoriteTeams(userID int) ([]models.FavoriteTeam, error) {
+	rows, err := us.db.Query(`
+		SELECT team_rank, mlb_team_id, team_name FROM user_favorite_teams
+		WHERE user_id = ? ORDER BY team_rank
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorite teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := make([]models.FavoriteTeam, 0, MaxFavoriteTeams)
+	for rows.Next() {
+		var t models.FavoriteTeam
+		if err := rows.Scan(&t.Rank, &t.MlbTeamID, &t.TeamName); err != nil {
+			return nil, fmt.Errorf("failed to scan favorite team: %w", err)
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
 }
 
 // DeleteAccount soft-deletes a user: the row is kept (so historical
@@ -122,6 +193,10 @@ func (us *UserService) DeleteAccount(userID int) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete account: %w", err)
 	}
+
+	if _, err := us.db.Exec(`DELETE FROM user_favorite_teams WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to clear favorite teams: %w", err)
+	}
 	return nil
 }
 
@@ -206,5 +281,10 @@ func (us *UserService) GetUserByIDWithTeam(userID int) (*models.User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
+
+	user.FavoriteTeams, err = us.GetFavoriteTeams(userID)
+	if err != nil {
+		return nil, err
+	}
 	return user, nil
 }
@@ -0,0 +1,18 @@
+package sessions
+
+import (
+	"errors"
+	"testing"
+	"trivia-server/models"
+)
+
+func TestSetFavoriteTeamsTooMany(t *testing.T) {
+	teams := make([]models.FavoriteTeam, MaxFavoriteTeams+1)
+	for i := range teams {
+		teams[i].MlbTeamID = 108 + i
+	}
+	// Refused before the database is touched
+	if err := NewUserService(nil, nil).SetFavoriteTeams(1, teams); !errors.Is(err, ErrTooManyTeams) {
+		t.Errorf("SetFavoriteTeams with %d teams = %v, want ErrTooManyTeams", len(teams), err)
+	}
+}
@@ -123,3 +123,19 @@ func (us *UserService) UpdateUserStats(userID int, won bool) error {
 	}
 	return nil
 }
+
+// IsAdmin reports whether the user may use the admin endpoints.
+func (us *UserService) IsAdmin(userID int) (bool, error) {
+	var isAdmin bool
+	err := us.db.QueryRow(
+		`SELECT COALESCE(is_admin, FALSE) FROM users WHERE id = ? AND is_active = TRUE`,
+		userID,
+	).Scan(&isAdmin)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check admin status: %w", err)
+	}
+	return isAdmin, nil
+}
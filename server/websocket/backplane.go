@@ -0,0 +1,687 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// DefaultLeaseTTL is how long an instance keeps a room, and its entry in
+// the lobby, without renewing them.
+const DefaultLeaseTTL = 15 * time.Second
+
+// Redis keys and channels the instances share
+const (
+	lobbyKey     = "ws:lobby"         // hash: instance ID -> lobbyEntry
+	lobbyChannel = "ws:lobby:changes" // frameRooms and frameLobby
+)
+
+func instanceChannel(instanceID string) string { return "ws:instance:" + instanceID }
+func roomLeaseKey(roomID string) string        { return "ws:room:" + roomID + ":owner" }
+func seatKey(userID string) string             { return "ws:seat:" + userID }
+
+// Frames the instances exchange
+const (
+	frameJoin       = "join"       // home -> owner: a command that makes a proxy
+	frameCommand    = "command"    // home -> owner: a command for a proxy
+	frameResume     = "resume"     // home -> owner: a reconnecting user's seat
+	frameDisconnect = "disconnect" // home -> owner: the connection closed
+	frameDeliver    = "deliver"    // owner -> home: a message for the connection
+	frameDetach     = "detach"     // owner -> home: the proxy is gone, Data is a bounced command
+	frameReplace    = "replace"    // owner -> home: a newer connection took the seat
+	frameRooms      = "rooms"      // lobby: an instance's rooms changed
+	frameLobby      = "lobby"      // lobby: a message for every connection
+)
+
+type frame struct {
+	Kind     string          `json:"kind"`
+	From     string          `json:"from"`
+	ClientID string          `json:"client_id,omitempty"`
+	UserID   string          `json:"user_id,omitempty"`
+	Username string          `json:"username,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// lobbyEntry is the rooms one instance owns, as it last published them.
+type lobbyEntry struct {
+	Rooms   []RoomSummary `json:"rooms"`
+	Updated time.Time     `json:"updated"`
+}
+
+// remoteRoom is where the room of a connection on this instance lives.
+type remoteRoom struct {
+	owner  string // instance ID
+	roomID string // "" if not known yet
+}
+
+var (
+	// ErrRoomIDTaken is returned when another instance owns a room with
+	// the requested ID.
+	ErrRoomIDTaken = errors.New("room ID already exists")
+	// ErrSeatedElsewhere is returned when the user took a seat in another
+	// room, on any instance, first.
+	ErrSeatedElsewhere = errors.New("you already have a seat in another room")
+)
+
+var (
+	// renewLease extends this instance's lease on a room, taking it back
+	// if it lapsed and nobody else claimed the room meanwhile.
+	renewLease = redis.NewScript(`
+local owner = redis.call("get", KEYS[1])
+if owner == ARGV[1] or not owner then
+	redis.call("set", KEYS[1], ARGV[1], "px", ARGV[2])
+	return 1
+end
+return 0`)
+	// setSeat records that user KEYS[1] sits in room ARGV[1], unless
+	// they already sit in another room.
+	setSeat = redis.NewScript(`
+local room = redis.call("get", KEYS[1])
+if room == ARGV[1] or not room then
+	redis.call("set", KEYS[1], ARGV[1], "px", ARGV[2])
+	return 1
+end
+return 0`)
+	// deleteIfValue deletes KEYS[1] if it's still set to ARGV[1]: a
+	// lease this instance holds, or a seat in a room it owns.
+	deleteIfValue = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+)
+
+// Backplane lets several server instances share rooms over Redis.
+//
+// Every room is owned by the instance it was created on, which holds a
+// lease on it in Redis and keeps the authoritative GameRoom. A player
+// connected to another instance (their home) is represented on the owner
+// by a proxy Client: the home forwards the player's commands to the
+// owner over pub/sub, the owner runs them against the proxy like any
+// local command, and whatever the room sends the proxy is relayed back
+// to the home and written to the connection.
+//
+// Instances publish their rooms to a shared lobby, so every lobby lists
+// every room, and lobby chat goes out to every instance.
+type Backplane struct {
+	InstanceID string
+	LeaseTTL   time.Duration
+
+	hub    *Hub
+	redis  *redis.Client
+	gm     *GameManager
+	pubsub *redis.PubSub
+	stop   chan struct{}
+
+	mu      sync.Mutex
+	remotes map[string]remoteRoom    // local client ID -> its room elsewhere
+	proxies map[string]*proxy        // client ID -> proxy for a remote connection
+	lobby   map[string][]RoomSummary // other live instances' rooms
+}
+
+// proxy is a connection on another instance that plays in a room here.
+type proxy struct {
+	client *Client
+	inbox  chan frame
+}
+
+// NewBackplane connects hub to the other instances through rdb. Call
+// Start before serving connections.
+func NewBackplane(hub *Hub, rdb *redis.Client, gm *GameManager, instanceID string) *Backplane {
+	return &Backplane{
+		InstanceID: instanceID,
+		LeaseTTL:   DefaultLeaseTTL,
+		hub:        hub,
+		redis:      rdb,
+		gm:         gm,
+		stop:       make(chan struct{}),
+		remotes:    make(map[string]remoteRoom),
+		proxies:    make(map[string]*proxy),
+		lobby:      make(map[string][]RoomSummary),
+	}
+}
+
+// Start subscribes to this instance's channel and the lobby's and hooks
+// the backplane into the hub.
+func (b *Backplane) Start() error {
+	pubsub := b.redis.Subscribe(instanceChannel(b.InstanceID), lobbyChannel)
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to backplane: %w", err)
+	}
+	b.pubsub = pubsub
+	b.hub.Backplane = b
+
+	b.publishRooms()
+	b.refreshLobby()
+	go b.listen(pubsub.Channel())
+	go b.maintain()
+	log.Printf("Backplane started for instance %s", b.InstanceID)
+	return nil
+}
+
+func (b *Backplane) publish(channel string, f frame) {
+	f.From = b.InstanceID
+	data, err := json.Marshal(f)
+	if err != nil {
+		log.Printf("Backplane: failed to encode %s frame: %v", f.Kind, err)
+		return
+	}
+	if err := b.redis.Publish(channel, data).Err(); err != nil {
+		log.Printf("Backplane: failed to publish %s frame: %v", f.Kind, err)
+	}
+}
+
+func (b *Backplane) listen(messages <-chan *redis.Message) {
+	for m := range messages {
+		var f frame
+		if err := json.Unmarshal([]byte(m.Payload), &f); err != nil {
+			log.Printf("Backplane: invalid frame: %v", err)
+			continue
+		}
+		switch f.Kind {
+		case frameRooms:
+			if f.From != b.InstanceID {
+				b.refreshLobby()
+				b.hub.broadcastRoomListLocally()
+			}
+		case frameLobby:
+			b.hub.broadcast <- []byte(f.Data)
+		case frameJoin, frameCommand, frameResume, frameDisconnect:
+			b.toProxy(f)
+		case frameDeliver:
+			b.deliver(f)
+		case frameDetach:
+			b.detached(f)
+		case frameReplace:
+			b.mu.Lock()
+			delete(b.remotes, f.ClientID)
+			b.mu.Unlock()
+			if client, ok := b.hub.GetClient(f.ClientID); ok {
+				client.replace()
+			}
+		}
+	}
+}
+
+// ── Home side ───────────────────────────────────────────────
+
+// route forwards msg to the instance that owns the client's room and
+// reports whether it did. Room list requests and mutes stay local: chat
+// from a room elsewhere is filtered on the way in (see deliver).
+func (b *Backplane) route(c *Client, msg wsMessage) bool {
+	switch msg.Type {
+	case MsgListRooms, MsgMuteUser, MsgUnmuteUser:
+		return false
+	}
+
+	b.mu.Lock()
+	remote, attached := b.remotes[c.ID]
+	b.mu.Unlock()
+	if attached {
+		b.forward(remote.owner, frameCommand, c, msg)
+		return true
+	}
+
+	var target remoteRoom
+	switch msg.Type {
+	case MsgJoinRoom, MsgSpectateRoom:
+		var p JoinRoomPayload
+		if json.Unmarshal(msg.Payload, &p) != nil {
+			return false
+		}
+		roomID, roomName := strings.TrimSpace(p.RoomID), strings.TrimSpace(p.RoomName)
+		if _, local := b.hub.lookupRoom(roomID, roomName); local {
+			return false
+		}
+		target = b.findRoom(roomID, roomName)
+	}
+	if msg.Type == MsgCreateRoom || msg.Type == MsgJoinRoom {
+		// Seated elsewhere: the owner gives the answer a second seat gets
+		if seat := b.seatOf(c.userID); seat.owner != "" {
+			target = seat
+		}
+	}
+	if target.owner == "" {
+		return false
+	}
+
+	b.mu.Lock()
+	b.remotes[c.ID] = target
+	b.mu.Unlock()
+	b.forward(target.owner, frameJoin, c, msg)
+	return true
+}
+
+func (b *Backplane) forward(owner, kind string, c *Client, msg wsMessage) {
+	f := frame{Kind: kind, ClientID: c.ID, UserID: c.userID, Username: c.username}
+	if kind != frameResume {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return
+		}
+		f.Data = data
+	}
+	b.publish(instanceChannel(owner), f)
+}
+
+// deliver writes what a room elsewhere sent a connection here, leaving
+// out chat from users it muted.
+func (b *Backplane) deliver(f frame) {
+	client, ok := b.hub.GetClient(f.ClientID)
+	if !ok {
+		return
+	}
+	if data, ok := b.hub.withoutMuted(client.userID, f.Data); ok {
+		b.hub.sendTo(f.ClientID, data)
+	}
+}
+
+// resume sends a connection whose user has a seat on another instance
+// back into it.
+func (b *Backplane) resume(c *Client) {
+	seat := b.seatOf(c.userID)
+	if seat.owner == "" {
+		return
+	}
+	b.mu.Lock()
+	b.remotes[c.ID] = seat
+	b.mu.Unlock()
+	b.forward(seat.owner, frameResume, c, wsMessage{})
+}
+
+// disconnected tells the owner of the client's room that its connection
+// closed, so it can hold or free the seat.
+func (b *Backplane) disconnected(c *Client) {
+	b.mu.Lock()
+	remote, attached := b.remotes[c.ID]
+	delete(b.remotes, c.ID)
+	b.mu.Unlock()
+	if attached {
+		b.publish(instanceChannel(remote.owner), frame{Kind: frameDisconnect, ClientID: c.ID})
+	}
+}
+
+// detached handles the owner giving up a proxy. A command it bounced
+// because the proxy was already gone is handled here instead.
+func (b *Backplane) detached(f frame) {
+	b.mu.Lock()
+	delete(b.remotes, f.ClientID)
+	b.mu.Unlock()
+
+	if len(f.Data) == 0 {
+		return
+	}
+	client, ok := b.hub.GetClient(f.ClientID)
+	if !ok {
+		return
+	}
+	var msg wsMessage
+	if err := json.Unmarshal(f.Data, &msg); err == nil {
+		go client.handleMessage(msg)
+	}
+}
+
+// seatOf finds the room another instance holds userID's seat in.
+func (b *Backplane) seatOf(userID string) remoteRoom {
+	roomID, err := b.redis.Get(seatKey(userID)).Result()
+	if err != nil {
+		return remoteRoom{}
+	}
+	return b.ownerOf(roomID)
+}
+
+// ownerOf looks up the instance with the lease on roomID, if it isn't
+// this one.
+func (b *Backplane) ownerOf(roomID string) remoteRoom {
+	owner, err := b.redis.Get(roomLeaseKey(roomID)).Result()
+	if err != nil || owner == b.InstanceID {
+		return remoteRoom{}
+	}
+	return remoteRoom{owner: owner, roomID: roomID}
+}
+
+// findRoom looks a room up in the other instances' lobbies, by ID or
+// else by name.
+func (b *Backplane) findRoom(roomID, roomName string) remoteRoom {
+	b.mu.Lock()
+	found := ""
+	for _, rooms := range b.lobby {
+		for _, room := range rooms {
+			if (roomID != "" && room.ID == roomID) || (roomName != "" && room.Name == roomName) {
+				found = room.ID
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	if found == "" {
+		return remoteRoom{}
+	}
+	return b.ownerOf(found)
+}
+
+// ── Owner side ──────────────────────────────────────────────
+
+// toProxy queues a frame for the proxy it's addressed to, making the
+// proxy for a join or resume. A command for a proxy that's gone is
+// bounced back to its home.
+func (b *Backplane) toProxy(f frame) {
+	b.mu.Lock()
+	p, ok := b.proxies[f.ClientID]
+	if !ok && (f.Kind == frameJoin || f.Kind == frameResume) {
+		p = b.newProxy(f)
+		ok = true
+	}
+	if ok {
+		select {
+		case p.inbox <- f:
+		default:
+			log.Printf("Backplane: inbox full for proxy %s, dropping %s", f.ClientID, f.Kind)
+		}
+	}
+	b.mu.Unlock()
+
+	if !ok && f.Kind == frameCommand {
+		b.publish(instanceChannel(f.From), frame{Kind: frameDetach, ClientID: f.ClientID, Data: f.Data})
+	}
+}
+
+// newProxy makes the stand-in for a remote connection. b.mu must be held.
+func (b *Backplane) newProxy(f frame) *proxy {
+	p := &proxy{
+		client: &Client{
+			hub:         b.hub,
+			send:        make(chan []byte, 256),
+			ID:          f.ClientID,
+			userID:      f.UserID,
+			username:    f.Username,
+			GameManager: b.gm,
+			home:        f.From,
+		},
+		inbox: make(chan frame, 64),
+	}
+	b.proxies[f.ClientID] = p
+	go b.relay(p.client)
+	go b.runProxy(p)
+	log.Printf("Proxy for client %s (user %s) from instance %s", f.ClientID, f.UserID, f.From)
+	return p
+}
+
+// relay sends what the room sends a proxy on to its connection's home.
+func (b *Backplane) relay(c *Client) {
+	for data := range c.send {
+		b.publish(instanceChannel(c.home), frame{Kind: frameDeliver, ClientID: c.ID, Data: data})
+	}
+}
+
+// runProxy handles a proxy's frames in order, as readPump does for a
+// local connection, until the proxy is in no room here any more.
+func (b *Backplane) runProxy(p *proxy) {
+	c := p.client
+	defer close(c.send)
+
+	live := true
+	for f := range p.inbox {
+		if !live {
+			if f.Kind == frameJoin || f.Kind == frameCommand {
+				b.publish(instanceChannel(c.home), frame{Kind: frameDetach, ClientID: c.ID, Data: f.Data})
+			}
+			continue
+		}
+
+		switch f.Kind {
+		case frameDisconnect:
+			b.hub.removeClientFromRooms(c)
+			b.dropProxy(p)
+			live = false
+			continue
+		case frameResume:
+			b.hub.attach(c)
+		case frameJoin, frameCommand:
+			if f.Kind == frameCommand && !b.hub.inRoom(c) {
+				// Left its room since: the home has to handle this one
+				b.dropProxy(p)
+				live = false
+				b.publish(instanceChannel(c.home), frame{Kind: frameDetach, ClientID: c.ID, Data: f.Data})
+				continue
+			}
+			var msg wsMessage
+			if err := json.Unmarshal(f.Data, &msg); err == nil {
+				c.handleMessage(msg)
+			}
+		}
+
+		if !b.hub.inRoom(c) {
+			b.dropProxy(p)
+			live = false
+			b.publish(instanceChannel(c.home), frame{Kind: frameDetach, ClientID: c.ID})
+		}
+	}
+}
+
+// dropProxy forgets p; runProxy bounces whatever is still queued.
+func (b *Backplane) dropProxy(p *proxy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.proxies[p.client.ID] == p {
+		delete(b.proxies, p.client.ID)
+		close(p.inbox)
+	}
+}
+
+// proxiesOf lists the proxies of userID's remote connections.
+func (b *Backplane) proxiesOf(userID string) []*Client {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var clients []*Client
+	for _, p := range b.proxies {
+		if p.client.userID == userID {
+			clients = append(clients, p.client)
+		}
+	}
+	return clients
+}
+
+// proxyClients lists every proxy.
+func (b *Backplane) proxyClients() []*Client {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clients := make([]*Client, 0, len(b.proxies))
+	for _, p := range b.proxies {
+		clients = append(clients, p.client)
+	}
+	return clients
+}
+
+// replaced tells a proxy's home that a newer connection took its seat.
+func (b *Backplane) replaced(c *Client) {
+	b.publish(instanceChannel(c.home), frame{Kind: frameReplace, ClientID: c.ID})
+}
+
+// ── Leases and the lobby ────────────────────────────────────
+
+// claim takes the lease on a new room.
+func (b *Backplane) claim(roomID string) error {
+	ok, err := b.redis.SetNX(roomLeaseKey(roomID), b.InstanceID, b.LeaseTTL).Result()
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to claim room %s: %w", roomID, err)
+	case !ok:
+		return ErrRoomIDTaken
+	}
+	return nil
+}
+
+// release gives up the lease on a deleted room.
+func (b *Backplane) release(roomID string) {
+	if err := deleteIfValue.Run(b.redis, []string{roomLeaseKey(roomID)}, b.InstanceID).Err(); err != nil {
+		log.Printf("Backplane: failed to release room %s: %v", roomID, err)
+	}
+}
+
+// takeSeat records userID's seat in roomID, failing with
+// ErrSeatedElsewhere if they have one in another room.
+func (b *Backplane) takeSeat(userID, roomID string) error {
+	ok, err := setSeat.Run(b.redis, []string{seatKey(userID)}, roomID, b.LeaseTTL.Milliseconds()).Int()
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to record seat of user %s: %w", userID, err)
+	case ok == 0:
+		return ErrSeatedElsewhere
+	}
+	return nil
+}
+
+// leftSeat forgets userID's seat in roomID, so their next room can be
+// on any instance.
+func (b *Backplane) leftSeat(userID, roomID string) {
+	if err := deleteIfValue.Run(b.redis, []string{seatKey(userID)}, roomID).Err(); err != nil {
+		log.Printf("Backplane: failed to clear seat of user %s: %v", userID, err)
+	}
+}
+
+// maintain renews leases and the lobby entry and notices instances that
+// went away, every third of LeaseTTL.
+func (b *Backplane) maintain() {
+	ticker := time.NewTicker(b.LeaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.stop:
+			return
+		}
+		b.renew()
+		b.writeLobbyEntry()
+		b.refreshLobby()
+		b.dropDeadRemotes()
+	}
+}
+
+// Stop leaves the backplane: the rooms here are released, so whichever
+// instance starts next can restore their games, and this instance drops
+// out of the lobby.
+func (b *Backplane) Stop() {
+	close(b.stop)
+	for _, room := range b.hub.roomList() {
+		b.release(room.ID)
+	}
+	if err := b.redis.HDel(lobbyKey, b.InstanceID).Err(); err != nil {
+		log.Printf("Backplane: failed to leave lobby: %v", err)
+	}
+	b.publish(lobbyChannel, frame{Kind: frameRooms})
+	b.pubsub.Close()
+	log.Printf("Backplane stopped for instance %s", b.InstanceID)
+}
+
+// renew extends the lease on every room here and on its players' seats.
+// A room another instance has taken over is closed.
+func (b *Backplane) renew() {
+	ttl := b.LeaseTTL.Milliseconds()
+	var lost []string
+	for _, room := range b.hub.roomList() {
+		held, err := renewLease.Run(b.redis, []string{roomLeaseKey(room.ID)}, b.InstanceID, ttl).Int()
+		if err != nil {
+			log.Printf("Backplane: failed to renew room %s: %v", room.ID, err)
+			continue
+		}
+		if held == 0 {
+			log.Printf("Backplane: lost the lease on room %s, closing it", room.ID)
+			room.Close()
+			lost = append(lost, room.ID)
+			continue
+		}
+
+		room.mu.RLock()
+		users := make([]string, 0, len(room.Players))
+		for _, p := range room.Players {
+			users = append(users, p.userID)
+		}
+		room.mu.RUnlock()
+		for _, userID := range users {
+			if err := b.takeSeat(userID, room.ID); err != nil {
+				log.Printf("Backplane: failed to renew seat in room %s: %v", room.ID, err)
+			}
+		}
+	}
+	b.hub.deleteRooms(lost)
+}
+
+// publishRooms updates this instance's lobby entry and tells the others.
+func (b *Backplane) publishRooms() {
+	b.writeLobbyEntry()
+	b.publish(lobbyChannel, frame{Kind: frameRooms})
+}
+
+func (b *Backplane) writeLobbyEntry() {
+	data, err := json.Marshal(lobbyEntry{Rooms: b.hub.ListRooms(), Updated: time.Now()})
+	if err != nil {
+		return
+	}
+	if err := b.redis.HSet(lobbyKey, b.InstanceID, data).Err(); err != nil {
+		log.Printf("Backplane: failed to write lobby entry: %v", err)
+	}
+}
+
+// refreshLobby reloads the other instances' rooms, clearing out entries
+// of instances that stopped renewing theirs.
+func (b *Backplane) refreshLobby() {
+	entries, err := b.redis.HGetAll(lobbyKey).Result()
+	if err != nil {
+		log.Printf("Backplane: failed to read lobby: %v", err)
+		return
+	}
+	lobby := make(map[string][]RoomSummary, len(entries))
+	for instanceID, data := range entries {
+		if instanceID == b.InstanceID {
+			continue
+		}
+		var entry lobbyEntry
+		if json.Unmarshal([]byte(data), &entry) != nil || time.Since(entry.Updated) > b.LeaseTTL {
+			b.redis.HDel(lobbyKey, instanceID)
+			continue
+		}
+		lobby[instanceID] = entry.Rooms
+	}
+
+	b.mu.Lock()
+	b.lobby = lobby
+	b.mu.Unlock()
+}
+
+// dropDeadRemotes closes the room for connections whose room's owner
+// stopped renewing its lobby entry.
+func (b *Backplane) dropDeadRemotes() {
+	b.mu.Lock()
+	dead := make(map[string]string)
+	for clientID, remote := range b.remotes {
+		if _, live := b.lobby[remote.owner]; !live {
+			dead[clientID] = remote.roomID
+			delete(b.remotes, clientID)
+		}
+	}
+	b.mu.Unlock()
+
+	for clientID, roomID := range dead {
+		log.Printf("Backplane: room %s of client %s went away with its instance", roomID, clientID)
+		b.hub.sendTo(clientID, encode(MsgRoomClosed, RoomEventPayload{RoomID: roomID}))
+	}
+}
+
+// remoteRooms lists the other instances' rooms.
+func (b *Backplane) remoteRooms() []RoomSummary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var rooms []RoomSummary
+	for _, r := range b.lobby {
+		rooms = append(rooms, r...)
+	}
+	return rooms
+}
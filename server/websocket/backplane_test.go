@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// twoInstances are two backplanes sharing one Redis, as two servers
+// behind a load balancer would.
+func twoInstances(t *testing.T) (a, b *Backplane, f *fakeRedis) {
+	t.Helper()
+	rdb, f := openFakeRedis(t)
+	a = NewBackplane(NewHub(nil), rdb, nil, "instance-a")
+	b = NewBackplane(NewHub(nil), rdb, nil, "instance-b")
+	a.hub.Backplane, b.hub.Backplane = a, b
+	return a, b, f
+}
+
+func TestBackplaneRoomLease(t *testing.T) {
+	a, b, _ := twoInstances(t)
+
+	if err := a.claim("room-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.claim("room-1"); !errors.Is(err, ErrRoomIDTaken) {
+		t.Errorf("claiming another instance's room = %v, want ErrRoomIDTaken", err)
+	}
+	if got := b.ownerOf("room-1"); got.owner != "instance-a" {
+		t.Errorf("owner seen from b = %+v, want instance-a", got)
+	}
+	if got := a.ownerOf("room-1"); got.owner != "" {
+		t.Errorf("a sees its own room as remote: %+v", got)
+	}
+
+	// Only the owner can give the lease up
+	b.release("room-1")
+	if err := b.claim("room-1"); !errors.Is(err, ErrRoomIDTaken) {
+		t.Errorf("claim after another instance's release = %v, want ErrRoomIDTaken", err)
+	}
+	a.release("room-1")
+	if err := b.claim("room-1"); err != nil {
+		t.Errorf("claim after the owner's release = %v", err)
+	}
+}
+
+// One seat per user holds across instances: the seat is recorded when
+// it's taken, not on the next lease renewal.
+func TestBackplaneOneSeatAcrossInstances(t *testing.T) {
+	a, b, f := twoInstances(t)
+
+	if err := a.takeSeat("7", "room-1"); err != nil {
+		t.Fatal(err)
+	}
+	if room, _ := f.get(seatKey("7")); room != "room-1" {
+		t.Errorf("seat recorded as %q, want room-1", room)
+	}
+	if err := b.takeSeat("7", "room-2"); !errors.Is(err, ErrSeatedElsewhere) {
+		t.Errorf("second seat on another instance = %v, want ErrSeatedElsewhere", err)
+	}
+	// Taking the same seat again, e.g. on renewal or a reconnect, is fine
+	if err := b.takeSeat("7", "room-1"); err != nil {
+		t.Errorf("retaking the same seat = %v", err)
+	}
+
+	// Leaving another room doesn't give up the seat
+	b.leftSeat("7", "room-2")
+	if err := b.takeSeat("7", "room-2"); !errors.Is(err, ErrSeatedElsewhere) {
+		t.Errorf("seat after leaving a room the user wasn't in = %v, want ErrSeatedElsewhere", err)
+	}
+	a.leftSeat("7", "room-1")
+	if err := b.takeSeat("7", "room-2"); err != nil {
+		t.Errorf("seat after leaving the first room = %v", err)
+	}
+}
+
+func TestCreateRoomSeatedOnAnotherInstance(t *testing.T) {
+	a, b, f := twoInstances(t)
+	if err := a.takeSeat("7", "room-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := testClient(b.hub, "7")
+	c.handleCreateRoom(CreateRoomPayload{RoomID: "room-2", RoomName: "Second"})
+	if e := nextError(t, c); e.Code != CodeAlreadyInRoom {
+		t.Errorf("create while seated on another instance = %s, want ALREADY_IN_ROOM", e.Code)
+	}
+	if _, claimed := f.get(roomLeaseKey("room-2")); claimed || len(b.hub.rooms) != 0 {
+		t.Error("room was claimed or created anyway")
+	}
+	if room, _ := f.get(seatKey("7")); room != "room-1" {
+		t.Errorf("seat is now %q, want room-1 untouched", room)
+	}
+}
+
+// Mutes are kept where the muting user is connected, so chat from a room
+// on another instance is filtered as it's delivered.
+func TestDeliverLeavesOutMutedChat(t *testing.T) {
+	_, home, _ := twoInstances(t)
+	c := testClient(home.hub, "7")
+	home.hub.addSession(c)
+	home.hub.Mute("7", "9")
+
+	chat := func(userID string) ChatMessage {
+		return ChatMessage{Channel: "room", RoomID: "room-1", UserID: userID, Text: "hi from " + userID}
+	}
+	for _, sender := range []string{"9", "8"} {
+		home.deliver(frame{Kind: frameDeliver, ClientID: c.ID, Data: encode(MsgChatMessage, chat(sender))})
+	}
+	home.deliver(frame{Kind: frameDeliver, ClientID: c.ID, Data: encode(MsgChatHistory, ChatHistoryPayload{
+		RoomID:   "room-1",
+		Messages: []ChatMessage{chat("9"), chat("8"), chat("9")},
+	})})
+	home.deliver(frame{Kind: frameDeliver, ClientID: c.ID, Data: encode(MsgTurnTimer, TurnTimerPayload{})})
+
+	var got []string
+	for _, data := range drain(c) {
+		var env struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(data), &env); err != nil {
+			t.Fatal(err)
+		}
+		switch env.Type {
+		case MsgChatMessage:
+			var msg ChatMessage
+			json.Unmarshal(env.Payload, &msg)
+			got = append(got, "chat "+msg.UserID)
+		case MsgChatHistory:
+			var p ChatHistoryPayload
+			json.Unmarshal(env.Payload, &p)
+			for _, msg := range p.Messages {
+				got = append(got, "history "+msg.UserID)
+			}
+		default:
+			got = append(got, env.Type)
+		}
+	}
+	want := []string{"chat 8", "history 8", MsgTurnTimer}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
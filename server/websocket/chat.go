@@ -0,0 +1,271 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxChatLength is the longest chat message accepted, in characters.
+	MaxChatLength = 280
+	// ChatHistorySize is how many messages a room keeps to replay to
+	// anyone who joins.
+	ChatHistorySize = 50
+)
+
+// ChatMessage is one chat line, in a room or the lobby.
+type ChatMessage struct {
+	ID       string    `json:"id"`
+	Channel  string    `json:"channel"` // "room" | "lobby"
+	RoomID   string    `json:"room_id,omitempty"`
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// ChatFilter masks words from a configurable list, whole words only and
+// ignoring case.
+type ChatFilter struct {
+	pattern *regexp.Regexp // nil when there are no words
+}
+
+// NewChatFilter builds a filter for words; blank entries are ignored.
+func NewChatFilter(words []string) *ChatFilter {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return &ChatFilter{}
+	}
+	return &ChatFilter{pattern: regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+// Clean replaces every listed word in text with asterisks.
+func (f *ChatFilter) Clean(text string) string {
+	if f == nil || f.pattern == nil {
+		return text
+	}
+	return f.pattern.ReplaceAllStringFunc(text, func(w string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(w))
+	})
+}
+
+// Mute hides chat from mutedID for userID, for the life of the server.
+func (h *Hub) Mute(userID, mutedID string) {
+	h.mutesMu.Lock()
+	defer h.mutesMu.Unlock()
+	if h.mutes[userID] == nil {
+		h.mutes[userID] = make(map[string]bool)
+	}
+	h.mutes[userID][mutedID] = true
+}
+
+func (h *Hub) Unmute(userID, mutedID string) {
+	h.mutesMu.Lock()
+	defer h.mutesMu.Unlock()
+	delete(h.mutes[userID], mutedID)
+}
+
+// Muted reports whether userID has muted senderID.
+func (h *Hub) Muted(userID, senderID string) bool {
+	h.mutesMu.RLock()
+	defer h.mutesMu.RUnlock()
+	return h.mutes[userID][senderID]
+}
+
+// MutedUsers lists the users userID has muted.
+func (h *Hub) MutedUsers(userID string) []string {
+	h.mutesMu.RLock()
+	defer h.mutesMu.RUnlock()
+	ids := make([]string, 0, len(h.mutes[userID]))
+	for id := range h.mutes[userID] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// withoutMuted filters room chat that userID's room on another instance
+// sent them: mutes live on the instance a user is connected to, so the
+// room's owner can't leave out muted senders itself. Returns false if
+// nothing is left to send.
+func (h *Hub) withoutMuted(userID string, data []byte) ([]byte, bool) {
+	var env struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if json.Unmarshal(data, &env) != nil {
+		return data, true
+	}
+	switch env.Type {
+	case MsgChatMessage:
+		var msg ChatMessage
+		if json.Unmarshal(env.Payload, &msg) == nil && h.Muted(userID, msg.UserID) {
+			return nil, false
+		}
+	case MsgChatHistory:
+		var p ChatHistoryPayload
+		if json.Unmarshal(env.Payload, &p) != nil {
+			return data, true
+		}
+		messages := make([]ChatMessage, 0, len(p.Messages))
+		for _, msg := range p.Messages {
+			if !h.Muted(userID, msg.UserID) {
+				messages = append(messages, msg)
+			}
+		}
+		if len(messages) < len(p.Messages) {
+			p.Messages = messages
+			return encode(MsgChatHistory, p), true
+		}
+	}
+	return data, true
+}
+
+// AddChat records msg in the room's history, dropping the oldest message
+// past ChatHistorySize.
+func (r *GameRoom) AddChat(msg ChatMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chat = append(r.chat, msg)
+	if len(r.chat) > ChatHistorySize {
+		r.chat = r.chat[len(r.chat)-ChatHistorySize:]
+	}
+}
+
+// BroadcastChat sends msg to the room's players and spectators, except
+// those who muted the sender.
+func (r *GameRoom) BroadcastChat(h *Hub, msg ChatMessage) {
+	data := encode(MsgChatMessage, msg)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for id, client := range r.Players {
+		if _, away := r.away[id]; away || h.Muted(client.userID, msg.UserID) {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+		}
+	}
+	for _, client := range r.mirrors {
+		if h.Muted(client.userID, msg.UserID) {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+		}
+	}
+	for _, client := range r.Spectators {
+		if h.Muted(client.userID, msg.UserID) {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+		}
+	}
+}
+
+// sendChatHistory replays the room's chat to client, leaving out
+// anyone it muted.
+func (c *Client) sendChatHistory(room *GameRoom) {
+	room.mu.RLock()
+	messages := make([]ChatMessage, 0, len(room.chat))
+	for _, msg := range room.chat {
+		if !c.hub.Muted(c.userID, msg.UserID) {
+			messages = append(messages, msg)
+		}
+	}
+	room.mu.RUnlock()
+
+	c.sendMessage(MsgChatHistory, ChatHistoryPayload{RoomID: room.ID, Messages: messages})
+}
+
+// handleChat posts a chat message to the client's room or the lobby.
+func (c *Client) handleChat(p SendChatPayload) {
+	text := strings.TrimSpace(p.Text)
+	if text == "" {
+		return
+	}
+	if utf8.RuneCountInString(text) > MaxChatLength {
+		c.sendError(CodeMessageTooLong, fmt.Sprintf("message is too long (max %d characters)", MaxChatLength))
+		return
+	}
+
+	var room *GameRoom
+	switch p.Channel {
+	case "", "room":
+		if c.spectating != "" {
+			// Spectators see the live chat, but a word from them could
+			// give a player the answer the board delay hides
+			c.sendError(CodeNotAPlayer, "spectators can't chat in the room")
+			return
+		}
+		var exists bool
+		if room, exists = c.hub.FindRoomByID(c.currentRoom); c.currentRoom == "" || !exists {
+			c.sendError(CodeNotInRoom, "not in a room")
+			return
+		}
+	case "lobby":
+	default:
+		c.sendError(CodeInvalidPayload, "unknown chat channel")
+		return
+	}
+
+	now := time.Now()
+	msg := ChatMessage{
+		ID:       uuid.New().String(),
+		Channel:  "lobby",
+		UserID:   c.userID,
+		Username: c.username,
+		Text:     c.hub.ChatFilter.Clean(text),
+		SentAt:   now,
+	}
+
+	if room == nil {
+		// Muting lobby chat is up to each client: the lobby goes to
+		// everyone, and clients hide senders listed in muted_users
+		c.hub.broadcastLobby(encode(MsgChatMessage, msg))
+		return
+	}
+	msg.Channel = "room"
+	msg.RoomID = room.ID
+	room.AddChat(msg)
+	room.BroadcastChat(c.hub, msg)
+}
+
+// handleMute adds or removes a user from the client's mute list and
+// sends back the updated list.
+func (c *Client) handleMute(p MutePayload, mute bool) {
+	target := strings.TrimSpace(p.UserID)
+	if target == "" {
+		c.sendError(CodeInvalidPayload, "user_id is required")
+		return
+	}
+	if target == c.userID {
+		c.sendError(CodeInvalidPayload, "you can't mute yourself")
+		return
+	}
+	if mute {
+		c.hub.Mute(c.userID, target)
+	} else {
+		c.hub.Unmute(c.userID, target)
+	}
+	c.sendMutedUsers()
+}
+
+func (c *Client) sendMutedUsers() {
+	c.sendMessage(MsgMutedUsers, MutedUsersPayload{UserIDs: c.hub.MutedUsers(c.userID)})
+}
@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestChatFilter(t *testing.T) {
+	f := NewChatFilter([]string{"darn", " heck ", "", "a.b"})
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"well darn it", "well **** it"},
+		{"DARN, Heck!", "****, ****!"},
+		{"darned hecks", "darned hecks"}, // whole words only
+		{"a.b axb", "*** axb"},           // listed words aren't patterns
+	}
+	for _, tt := range tests {
+		if got := f.Clean(tt.text); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	if got := NewChatFilter(nil).Clean("darn"); got != "darn" {
+		t.Errorf("empty filter changed %q to %q", "darn", got)
+	}
+	var nilFilter *ChatFilter
+	if got := nilFilter.Clean("darn"); got != "darn" {
+		t.Errorf("nil filter changed %q to %q", "darn", got)
+	}
+}
+
+// Chat goes through the same admission as every message, so a burst
+// past chat's limit is refused with a warning and never reaches the room.
+func TestChatRateLimit(t *testing.T) {
+	h := NewHub(nil)
+	limit, ok := h.Limits.PerType[MsgChatMessage]
+	if !ok {
+		t.Fatal("chat_message has no default limit")
+	}
+	room, alice, bob := playingRoom(t, h)
+	h.AddRoom(room)
+	alice.currentRoom, bob.currentRoom = room.ID, room.ID
+	drain(alice)
+	drain(bob)
+
+	chat := wsMessage{Type: MsgChatMessage, RequestID: "r", Payload: json.RawMessage(`{"text":"hi"}`)}
+	send := func(c *Client, now time.Time) bool {
+		ok, _ := c.admit(chat, now)
+		if ok {
+			c.dispatch(chat)
+		}
+		return ok
+	}
+
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < limit.Burst; i++ {
+		if !send(alice, now) {
+			t.Fatalf("chat message %d of a burst of %d refused", i+1, limit.Burst)
+		}
+	}
+	if got := len(drain(bob)); got != limit.Burst {
+		t.Errorf("bob got %d messages, want %d", got, limit.Burst)
+	}
+	drain(alice)
+
+	if send(alice, now) {
+		t.Error("chat message past the burst allowed")
+	}
+	if e := nextError(t, alice); e.Code != CodeRateLimited || e.RequestID != "r" {
+		t.Errorf("warning = %+v, want RATE_LIMITED", e)
+	}
+	if got := drain(bob); len(got) != 0 {
+		t.Errorf("a refused chat reached the room: %v", got)
+	}
+
+	if !send(bob, now) {
+		t.Error("another user's chat was limited")
+	}
+	if !send(alice, now.Add(time.Duration(float64(time.Second)/limit.Rate))) {
+		t.Error("chat still refused after a token refilled")
+	}
+}
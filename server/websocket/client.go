@@ -3,11 +3,13 @@ package websocket
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"trivia-server/game"
 	"trivia-server/grid"
@@ -38,32 +40,9 @@ var upgrader = websocket.Upgrader{
 }
 
 type wsMessage struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
-}
-
-type createRoomPayload struct {
-	RoomID     string `json:"room_id,omitempty"`
-	RoomName   string `json:"room_name,omitempty"`
-	Password   string `json:"password,omitempty"`
-	MaxPlayers int    `json:"max_Players"`
-	Difficulty string `json:"difficulty,omitempty"`
-}
-
-type joinRoomPayload struct {
-	RoomID   string `json:"room_id,omitempty"`
-	RoomName string `json:"room_name,omitempty"`
-	Password string `json:"password,omitempty"`
-}
-
-type makeMovePayload struct {
-	RoomID         string `json:"room_id"`
-	Row            int    `json:"row"`
-	Col            int    `json:"col"`
-	Answer         string `json:"answer"`
-	PlayerID       int    `json:"player_id"`
-	PlayerName     string `json:"player_name"`
-	PlayerHeadshot string `json:"player_headshot"`
+	Type      string          `json:"type"`
+	RequestID string          `json:"request_id,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
 }
 
 type Client struct {
@@ -75,6 +54,15 @@ type Client struct {
 	username    string
 	GameManager *GameManager
 	currentRoom string
+	spectating  string   // room being watched, see handleSpectateRoom
+	req         *request // command being handled, see handleMessage
+	abuse       abuseState
+
+	// For a proxy, the instance its connection is on; see Backplane
+	home string
+
+	handling sync.Mutex // serializes handleMessage
+	closing  bool       // readPump is done; set under handling
 }
 
 func NewClient(hub *Hub, conn *websocket.Conn, userID string, username string, gm *GameManager) *Client {
@@ -92,6 +80,12 @@ func NewClient(hub *Hub, conn *websocket.Conn, userID string, username string, g
 // readPump reads messages from the WebSocket connection and handles them.
 func (c *Client) readPump() {
 	defer func() {
+		// No command bounced back by another instance may run once the
+		// send channel can close
+		c.handling.Lock()
+		c.closing = true
+		c.handling.Unlock()
+
 		c.hub.unregister <- c
 		c.conn.Close()
 		c.hub.removeClientFromRooms(c)
@@ -105,6 +99,7 @@ func (c *Client) readPump() {
 	})
 
 	for {
+		c.throttle()
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
@@ -116,8 +111,15 @@ func (c *Client) readPump() {
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
 
 		var msg wsMessage
-		if err := json.Unmarshal(message, &msg); err != nil {
-			log.Println("invalid message format:", err)
+		jsonErr := json.Unmarshal(message, &msg)
+		if ok, kick := c.admit(msg, time.Now()); kick {
+			c.kick()
+			break
+		} else if !ok {
+			continue
+		}
+		if jsonErr != nil {
+			log.Println("invalid message format:", jsonErr)
 			continue
 		}
 		c.handleMessage(msg)
@@ -167,95 +169,142 @@ func (c *Client) writePump() {
 	}
 }
 
-// handleMessage processes incoming messages from the client.
-func (c *Client) handleMessage(msg wsMessage) {
+// dispatch routes one incoming message to its handler.
+func (c *Client) dispatch(msg wsMessage) {
 	switch msg.Type {
-	case "create_room":
-		var p createRoomPayload
-		if err := json.Unmarshal(msg.Payload, &p); err != nil {
-			c.sendError("invalid create_room payload")
-			return
+	case MsgCreateRoom:
+		var p CreateRoomPayload
+		if c.decode(msg, &p) {
+			c.handleCreateRoom(p)
 		}
-		c.handleCreateRoom(p)
-	case "join_room":
-		var p joinRoomPayload
-		if err := json.Unmarshal(msg.Payload, &p); err != nil {
-			c.sendError("invalid join_room payload")
-			return
+	case MsgJoinRoom:
+		var p JoinRoomPayload
+		if c.decodeRoomRef(msg, &p) {
+			c.handleJoinRoom(p)
 		}
-		p.RoomID = strings.TrimSpace(p.RoomID)
-		p.RoomName = strings.TrimSpace(p.RoomName)
-		if p.RoomID == "" && p.RoomName == "" {
-			c.sendError("room_id or room_name is required")
-			return
+	case MsgSpectateRoom:
+		var p JoinRoomPayload
+		if c.decodeRoomRef(msg, &p) {
+			c.handleSpectateRoom(p)
 		}
-		c.handleJoinRoom(p)
-	case "start_game":
+	case MsgStartGame:
 		c.handleStartGame()
-	case "make_move":
-		var p makeMovePayload
-		if err := json.Unmarshal(msg.Payload, &p); err != nil {
-			c.sendError("invalid make_move payload")
-			return
+	case MsgMakeMove:
+		var p MakeMovePayload
+		if c.decode(msg, &p) {
+			c.handleMakeMove(p)
 		}
-		c.handleMakeMove(p)
-	case "list_rooms":
-		rooms := c.hub.ListRooms()
+	case MsgListRooms:
+		rooms := c.hub.LobbyRooms()
 		log.Printf("list_rooms request %d rooms", len(rooms))
-		c.sendJSON(map[string]interface{}{"type": "rooms_list", "payload": map[string]interface{}{"rooms": rooms}})
-	case "leave_room":
+		c.sendMessage(MsgRoomsList, RoomsListPayload{Rooms: rooms})
+	case MsgLeaveRoom:
 		c.handleLeaveRoom()
-	case "player_ready":
-		var p struct {
-			Ready bool `json:"ready"`
-		}
-		if err := json.Unmarshal(msg.Payload, &p); err != nil {
-			c.sendError("invalid player_ready payload")
-			return
+	case MsgPlayerReady:
+		var p ReadyPayload
+		if c.decode(msg, &p) {
+			c.handlePlayerReady(p.Ready)
 		}
-		c.handlePlayerReady(p.Ready)
-	case "rematch":
+	case MsgRematch:
 		c.handleRematch()
+	case MsgChatMessage:
+		var p SendChatPayload
+		if c.decode(msg, &p) {
+			c.handleChat(p)
+		}
+	case MsgMuteUser, MsgUnmuteUser:
+		var p MutePayload
+		if c.decode(msg, &p) {
+			c.handleMute(p, msg.Type == MsgMuteUser)
+		}
 	default:
-		c.sendError("unknown message type")
+		c.sendError(CodeUnknownType, "unknown message type "+strconv.Quote(msg.Type))
 	}
 }
 
-func (c *Client) sendJSON(data interface{}) {
-	bytes, err := json.Marshal(data)
-	if err != nil {
-		log.Printf("sendJSON marshal error: %v", err)
-		return
+// decode unmarshals msg's payload into v, answering INVALID_PAYLOAD if
+// it doesn't fit. Messages sent without a payload decode as empty.
+func (c *Client) decode(msg wsMessage, v interface{}) bool {
+	if len(msg.Payload) == 0 {
+		return true
 	}
+	if err := json.Unmarshal(msg.Payload, v); err != nil {
+		c.sendError(CodeInvalidPayload, fmt.Sprintf("invalid %s payload: %v", msg.Type, err))
+		return false
+	}
+	return true
+}
+
+// decodeRoomRef decodes a payload that names a room, which must have an
+// ID or a name.
+func (c *Client) decodeRoomRef(msg wsMessage, p *JoinRoomPayload) bool {
+	if !c.decode(msg, p) {
+		return false
+	}
+	p.RoomID = strings.TrimSpace(p.RoomID)
+	p.RoomName = strings.TrimSpace(p.RoomName)
+	p.Password = strings.TrimSpace(p.Password)
+	if p.RoomID == "" && p.RoomName == "" {
+		c.sendError(CodeInvalidPayload, "room_id or room_name is required")
+		return false
+	}
+	return true
+}
+
+// sendMessage sends one message from the catalog in protocol.go to this
+// client.
+func (c *Client) sendMessage(msgType string, payload interface{}) {
+	c.sendRaw(encode(msgType, payload))
+}
+
+func (c *Client) sendRaw(message []byte) {
 	select {
-	case c.send <- bytes:
+	case c.send <- message:
 	default:
-		log.Printf("sendJSON DROPPED - send buffer full for client %s", c.ID)
+		log.Printf("send DROPPED - send buffer full for client %s", c.ID)
 	}
 }
 
-func (c *Client) sendError(msg string) {
-	c.sendJSON(map[string]interface{}{
-		"type":    "error",
-		"message": msg,
-	})
+// sendError reports a failed command, echoing its request_id.
+func (c *Client) sendError(code ErrorCode, msg string) {
+	c.sendErrorPayload(ErrorPayload{Code: code, Message: msg})
+}
+
+func (c *Client) sendErrorPayload(p ErrorPayload) {
+	if c.req == nil {
+		c.sendMessage(MsgError, p)
+		return
+	}
+	p.RequestID = c.req.id
+	data := encode(MsgError, p)
+	if c.req.response == nil {
+		c.req.response = data
+	}
+	c.sendRaw(data)
+}
+
+// sendFailure reports err, with the code errorCode picks for it.
+func (c *Client) sendFailure(err error) {
+	c.sendError(errorCode(err), err.Error())
 }
 
 // handleCreateRoom handles the creation of a new game room.
-func (c *Client) handleCreateRoom(p createRoomPayload) {
+func (c *Client) handleCreateRoom(p CreateRoomPayload) {
+	if c.hub.Draining() {
+		c.sendFailure(ErrShuttingDown)
+		return
+	}
+	c.stopSpectating()
 	if c.currentRoom != "" {
 		if existingRoom, exists := c.hub.GetRoom(c.currentRoom); exists {
-			existingRoom.RemovePlayer(c.ID)
-			existingRoom.Broadcast(mustMarshal(map[string]interface{}{
-				"type": "player_left",
-				"payload": map[string]interface{}{
-					"playerId": c.ID,
-					"roomId":   c.currentRoom,
-				},
-			}))
+			c.hub.leaveSeat(existingRoom, c)
 		}
 		c.currentRoom = ""
 	}
+	if _, seated := c.hub.seatedRoom(c.userID); seated {
+		c.sendError(CodeAlreadyInRoom, "you're already in a room in another tab")
+		return
+	}
 
 	requestedRoomID := strings.TrimSpace(p.RoomID)
 	if requestedRoomID == "" {
@@ -266,17 +315,21 @@ func (c *Client) handleCreateRoom(p createRoomPayload) {
 	roomPassword := strings.TrimSpace(p.Password)
 
 	if roomName == "" {
-		c.sendError("room_name is required")
+		c.sendError(CodeInvalidPayload, "room_name is required")
 		return
 	}
 
 	if _, exists := c.hub.GetRoom(requestedRoomID); exists {
-		c.sendError("room ID already exists")
+		c.sendError(CodeRoomExists, "room ID already exists")
 		return
 	}
 
 	if existingRoom, nameExists := c.hub.GetRoomByName(roomName); nameExists && existingRoom.ID != requestedRoomID {
-		c.sendError("room name already exists")
+		c.sendError(CodeRoomExists, "room name already exists")
+		return
+	}
+	if b := c.hub.Backplane; b != nil && b.findRoom("", roomName).owner != "" {
+		c.sendError(CodeRoomExists, "room name already exists")
 		return
 	}
 
@@ -284,108 +337,185 @@ func (c *Client) handleCreateRoom(p createRoomPayload) {
 		p.MaxPlayers = 2
 	}
 
-	room := NewGameRoom(requestedRoomID, roomName, roomPassword, c.userID)
-	room.State.MaxPlayers = p.MaxPlayers
-
 	difficulty := strings.ToLower(strings.TrimSpace(p.Difficulty))
 	switch difficulty {
 	case "easy", "regular", "hard":
-		room.Difficulty = difficulty
-		room.State.Difficulty = difficulty
 	default:
-		room.Difficulty = "regular"
-		room.State.Difficulty = "regular"
+		difficulty = "regular"
+	}
+
+	filters, err := p.Filters.Normalize()
+	if err != nil {
+		c.sendError(CodeInvalidPayload, err.Error())
+		return
+	}
+
+	custom := len(p.RowCriteria) > 0 || len(p.ColCriteria) > 0
+	var rowIDs, colIDs [3]int
+	if custom {
+		if roomPassword == "" {
+			c.sendError(CodeInvalidGrid, "custom grids are only available in private rooms")
+			return
+		}
+		if len(p.RowCriteria) != 3 || len(p.ColCriteria) != 3 {
+			c.sendError(CodeInvalidGrid, "row_criteria and col_criteria must each have 3 IDs")
+			return
+		}
+		copy(rowIDs[:], p.RowCriteria)
+		copy(colIDs[:], p.ColCriteria)
+	}
+
+	room := NewGameRoom(requestedRoomID, roomName, roomPassword, c.userID)
+	room.State.MaxPlayers = p.MaxPlayers
+
+	room.Difficulty = difficulty
+	room.State.Difficulty = difficulty
+	room.GridSeed = p.Seed
+	room.GridFilters = filters
+	if p.AllowSpectators != nil {
+		room.AllowSpectators = *p.AllowSpectators
+	}
+	room.SpectatorDelay = min(time.Duration(max(p.SpectatorDelay, 0))*time.Second, MaxSpectatorDelay)
+
+	if !c.takeSeat(room.ID) {
+		return
+	}
+	if err := c.hub.claimRoom(room.ID); errors.Is(err, ErrRoomIDTaken) {
+		c.hub.leftSeat(c.userID, room.ID)
+		c.sendFailure(err)
+		return
+	} else if err != nil {
+		c.hub.leftSeat(c.userID, room.ID)
+		log.Printf("Failed to claim room %s: %v", room.ID, err)
+		c.sendError(CodeInternal, "failed to create room")
+		return
+	}
+
+	// The custom grid is only saved once the room is ours, so a room
+	// that can't be created never leaves a template behind
+	if custom {
+		gt, ok := c.createCustomGrid(difficulty, rowIDs, colIDs)
+		if !ok {
+			c.hub.leftSeat(c.userID, room.ID)
+			c.hub.releaseRoom(room.ID)
+			return
+		}
+		room.CustomGridID = gt.ID
 	}
 
 	c.hub.AddRoom(room)
 	if err := room.AddPlayer(c); err != nil {
-		c.sendError(fmt.Sprintf("failed to join created room: %v", err))
+		c.hub.leftSeat(c.userID, room.ID)
+		c.sendError(errorCode(err), fmt.Sprintf("failed to join created room: %v", err))
 		return
 	}
 	c.currentRoom = requestedRoomID
-	c.sendJSON(map[string]interface{}{
-		"type": "room_created",
-		"payload": map[string]interface{}{
-			"room_id":   requestedRoomID,
-			"room_name": roomName,
-		},
-	})
-	c.sendJSON(map[string]interface{}{
-		"type": "joined_room",
-		"payload": map[string]interface{}{
-			"room_id":   requestedRoomID,
-			"room_name": roomName,
-		},
-	})
+	created := RoomPayload{RoomID: requestedRoomID, RoomName: roomName}
+	c.sendMessage(MsgRoomCreated, created)
+	c.sendMessage(MsgJoinedRoom, created)
+	c.setResult(created)
 
 	c.hub.BroadcastRoomList()
 }
 
 // handleJoinRoom handles a client joining an existing game room.
-func (c *Client) handleJoinRoom(p joinRoomPayload) {
+func (c *Client) handleJoinRoom(p JoinRoomPayload) {
+	if c.hub.Draining() {
+		c.sendFailure(ErrShuttingDown)
+		return
+	}
 	roomID := strings.TrimSpace(p.RoomID)
 	roomName := strings.TrimSpace(p.RoomName)
 	password := strings.TrimSpace(p.Password)
 
-	var room *GameRoom
-	var exists bool
-
-	if roomID != "" {
-		log.Printf("handleJoinRoom called for client %s by roomId=%s", c.ID, roomID)
-		room, exists = c.hub.GetRoom(roomID)
-	}
-
-	if !exists && roomName != "" {
-		log.Printf("handleJoinRoom called for client %s by roomName=%s", c.ID, roomName)
-		room, exists = c.hub.GetRoomByName(roomName)
-	}
+	c.stopSpectating()
 
+	log.Printf("handleJoinRoom called for client %s by roomId=%s roomName=%s", c.ID, roomID, roomName)
+	room, exists := c.hub.lookupRoom(roomID, roomName)
 	if !exists {
-		c.sendError("room not found")
+		c.sendError(CodeRoomNotFound, "room not found")
 		return
 	}
 
 	if room.Password != "" && room.Password != password {
-		c.sendError("incorrect room password")
+		c.sendError(CodeWrongPassword, "incorrect room password")
 		return
 	}
 
+	if _, seated := c.hub.seatedRoom(c.userID); seated {
+		c.sendError(CodeAlreadyInRoom, "you're already in a room — leave it first")
+		return
+	}
+	if !c.takeSeat(room.ID) {
+		return
+	}
 	if err := room.AddPlayer(c); err != nil {
-		c.sendError(err.Error())
+		c.hub.leftSeat(c.userID, room.ID)
+		c.sendFailure(err)
 		return
 	}
 
 	c.currentRoom = room.ID
-	c.sendJSON(map[string]interface{}{
-		"type": "joined_room",
-		"payload": map[string]interface{}{
-			"room_id":   room.ID,
-			"room_name": room.Name,
-		},
-	})
+	joined := RoomPayload{RoomID: room.ID, RoomName: room.Name}
+	c.sendMessage(MsgJoinedRoom, joined)
+	c.setResult(joined)
 
 	// Send existing players to the newly joined client
-	for _, existingClient := range room.GetOrderedClients() {
-		if existingClient.ID == c.ID {
-			continue
-		}
-		c.sendJSON(map[string]interface{}{
-			"type": "player_joined",
-			"payload": map[string]interface{}{
-				"roomId":      room.ID,
-				"playerId":    existingClient.ID,
-				"playerCount": room.State.PlayerCount,
-				"userId":      existingClient.userID,
-				"username":    existingClient.username,
-			},
-		})
-	}
+	c.sendOtherPlayers(room)
 
 	log.Printf("Client %s joined room %s", c.ID, room.ID)
 	c.hub.BroadcastRoomList()
+	c.sendChatHistory(room)
 
 	// Send ready status of existing players to the newly joined client
+	c.sendReadyStates(room)
+}
+
+// createCustomGrid saves a hand-picked grid for a private room. It
+// reports why and returns false if the grid isn't playable.
+func (c *Client) createCustomGrid(difficulty string, rowIDs, colIDs [3]int) (*grid.GridTemplate, bool) {
+	gt, err := grid.NewService(c.hub.DB).CreateCustomTemplate(difficulty, rowIDs, colIDs)
+	var shortErr *grid.ShortCellsError
+	switch {
+	case errors.As(err, &shortErr):
+		c.sendErrorPayload(ErrorPayload{
+			Code:       CodeShortCells,
+			Message:    shortErr.Error(),
+			ShortCells: shortErr.Cells,
+		})
+		return nil, false
+	case errors.Is(err, grid.ErrInvalidGrid):
+		c.sendFailure(err)
+		return nil, false
+	case err != nil:
+		log.Printf("Failed to create custom grid: %v", err)
+		c.sendError(CodeInternal, "failed to create custom grid")
+		return nil, false
+	}
+	return gt, true
+}
+
+// takeSeat records the client's user's seat in roomID before they get
+// it, so two instances can't each seat them. It reports the error and
+// returns false if it can't.
+func (c *Client) takeSeat(roomID string) bool {
+	err := c.hub.takeSeat(c.userID, roomID)
+	switch {
+	case errors.Is(err, ErrSeatedElsewhere):
+		c.sendFailure(err)
+		return false
+	case err != nil:
+		log.Printf("Failed to seat user %s in room %s: %v", c.userID, roomID, err)
+		c.sendError(CodeInternal, "failed to join room")
+		return false
+	}
+	return true
+}
+
+// sendReadyStates sends player_ready for every other seat in room.
+func (c *Client) sendReadyStates(room *GameRoom) {
 	room.mu.RLock()
+	defer room.mu.RUnlock()
 	for clientID, isReady := range room.readyPlayers {
 		if clientID == c.ID {
 			continue
@@ -394,26 +524,47 @@ func (c *Client) handleJoinRoom(p joinRoomPayload) {
 		if !exists {
 			continue
 		}
-		c.sendJSON(map[string]interface{}{
-			"type": "player_ready",
-			"payload": map[string]interface{}{
-				"playerId": clientID,
-				"username": existingClient.username,
-				"ready":    isReady,
-			},
+		c.sendMessage(MsgPlayerReady, PlayerReadyPayload{
+			PlayerID: clientID,
+			UserID:   existingClient.userID,
+			Username: existingClient.username,
+			Ready:    isReady,
+		})
+	}
+}
+
+// sendOtherPlayers sends player_joined for everyone else seated in room.
+func (c *Client) sendOtherPlayers(room *GameRoom) {
+	for _, other := range room.GetOrderedClients() {
+		if other.userID == c.userID {
+			continue
+		}
+		c.sendMessage(MsgPlayerJoined, PlayerJoinedPayload{
+			RoomID:      room.ID,
+			PlayerID:    other.ID,
+			PlayerCount: room.State.PlayerCount,
+			UserID:      other.userID,
+			Username:    other.username,
 		})
 	}
-	room.mu.RUnlock()
 }
 
 func (c *Client) handleStartGame() {
+	if c.hub.Draining() {
+		c.sendFailure(ErrShuttingDown)
+		return
+	}
 	if c.currentRoom == "" {
-		c.sendError("not in a room")
+		c.sendError(CodeNotInRoom, "not in a room")
 		return
 	}
 	room, exists := c.hub.GetRoom(c.currentRoom)
 	if !exists {
-		c.sendError("room not found")
+		c.sendError(CodeRoomNotFound, "room not found")
+		return
+	}
+	if room.playing() {
+		c.sendError(CodeGameInProgress, "a game is already being played in this room")
 		return
 	}
 
@@ -429,7 +580,7 @@ func (c *Client) handleStartGame() {
 	room.mu.RUnlock()
 
 	if readyCount < playerCount || playerCount < room.State.MaxPlayers {
-		c.sendError("not all players are ready")
+		c.sendError(CodeNotReady, "not all players are ready")
 		return
 	}
 
@@ -443,44 +594,106 @@ func (c *Client) handleStartGame() {
 	}
 
 	if len(players) < 2 {
-		c.sendError("need 2 players to start")
+		c.sendError(CodeNotReady, "need 2 players to start")
 		return
 	}
 
-	// Pick a grid based on room difficulty and players' favorite teams
+	// Custom grids are already built; everything else comes from the
+	// ready pool unless it needs favorite teams or a fixed seed
 	gridSvc := grid.NewService(c.hub.DB)
 
-	var gridTemplate *grid.GridTemplate
-	var err error
+	if room.CustomGridID != 0 {
+		t, err := gridSvc.GetTemplate(room.CustomGridID)
+		if err != nil {
+			log.Printf("Failed to load custom grid %d: %v", room.CustomGridID, err)
+			c.sendError(CodeGridFailed, "failed to load custom grid")
+			return
+		}
+		c.startGameWithGrid(room, players, &t.GridTemplate)
+		return
+	}
 
-	if room.Difficulty == "hard" {
-		gridTemplate, err = gridSvc.GenerateGrid("hard", nil, nil)
-	} else {
+	var p1Favs, p2Favs []int
+	if room.Difficulty != "hard" {
 		ordered := room.GetOrderedClients()
-		var p1Fav, p2Fav *int
-
 		if len(ordered) >= 1 {
 			if uid, convErr := strconv.Atoi(ordered[0].userID); convErr == nil {
-				p1Fav, _ = grid.GetFavoriteTeamCriteriaID(c.hub.DB, uid)
+				p1Favs, _ = grid.GetFavoriteTeamCriteriaIDs(c.hub.DB, uid)
 			}
 		}
 		if len(ordered) >= 2 {
 			if uid, convErr := strconv.Atoi(ordered[1].userID); convErr == nil {
-				p2Fav, _ = grid.GetFavoriteTeamCriteriaID(c.hub.DB, uid)
+				p2Favs, _ = grid.GetFavoriteTeamCriteriaIDs(c.hub.DB, uid)
 			}
 		}
+	}
 
-		gridTemplate, err = gridSvc.GenerateGrid(room.Difficulty, p1Fav, p2Fav)
+	if len(p1Favs) == 0 && len(p2Favs) == 0 && room.GridSeed == 0 && room.GridFilters.IsZero() && c.hub.GridPool != nil {
+		if gt, ok := c.hub.GridPool.Take(room.Difficulty); ok {
+			c.startGameWithGrid(room, players, gt)
+			return
+		}
 	}
 
-	if err != nil {
-		log.Printf("Failed to get grid template: %v", err)
-		c.sendError("failed to load grid — make sure the database is populated")
+	// Nothing ready — generate off the read loop and tell the room
+	if !room.BeginGridGeneration() {
+		c.sendError(CodeGridBusy, "grid is already being generated")
 		return
 	}
+	room.Broadcast(encode(MsgGridGenerating, RoomEventPayload{RoomID: room.ID}))
+
+	go func() {
+		defer room.EndGridGeneration()
+
+		opts := grid.GenerateOptions{Seed: room.GridSeed, Filters: room.GridFilters}
+		gt, err := gridSvc.GenerateGrid(room.Difficulty, p1Favs, p2Favs, opts)
+		if err != nil {
+			log.Printf("Failed to generate grid for room %s: %v", room.ID, err)
+			failure := ErrorPayload{
+				Code:    CodeGridFailed,
+				Message: "failed to generate a grid — try again or pick another difficulty",
+			}
+			if errors.Is(err, grid.ErrFiltersTooStrict) {
+				failure.Code = CodeFiltersTooStrict
+				failure.Message = "this room's filters are too strict to build a grid — create a room with looser filters"
+			}
+			room.Broadcast(encode(MsgError, failure))
+			return
+		}
+
+		// Someone may have left while we were generating
+		if _, exists := c.hub.GetRoom(room.ID); !exists || !samePlayers(room.GetOrderedClients(), players) {
+			return
+		}
+		// or the server started shutting down
+		if c.hub.Draining() || c.hub.stopped.Load() {
+			room.Broadcast(encode(MsgError, ErrorPayload{
+				Code:    CodeShuttingDown,
+				Message: ErrShuttingDown.Error(),
+			}))
+			return
+		}
+		c.startGameWithGrid(room, players, gt)
+	}()
+}
 
-	room.GridTemplateID = gridTemplate.ID
+// samePlayers reports whether the room still seats exactly the players a
+// game was started for, in the same order.
+func samePlayers(clients []*Client, players []models.GamePlayer) bool {
+	if len(clients) != len(players) {
+		return false
+	}
+	for i, cl := range clients {
+		if uid, _ := strconv.Atoi(cl.userID); uid != players[i].UserID {
+			return false
+		}
+	}
+	return true
+}
 
+// startGameWithGrid creates the game state for a ready room and tells
+// every player the grid.
+func (c *Client) startGameWithGrid(room *GameRoom, players []models.GamePlayer, gridTemplate *grid.GridTemplate) {
 	gameModel := models.Game{
 		Status:      models.GameStatusActive,
 		CurrentTurn: 0,
@@ -488,184 +701,270 @@ func (c *Client) handleStartGame() {
 
 	gs := game.NewGameState(gameModel, players)
 
+	if !room.startGameOn(gs, c.GameManager, gridTemplate) {
+		// Another start got there first
+		return
+	}
 	if c.GameManager != nil {
 		c.GameManager.Create(gs)
 	}
 
-	room.StartGame(gs, 0, c.GameManager)
-
 	// Tell each player their index and the grid template
 	for i, cl := range room.GetOrderedClients() {
-		cl.sendJSON(map[string]interface{}{
-			"type": "game_started",
-			"payload": map[string]interface{}{
-				"playerIndex":    i,
-				"roomId":         room.ID,
-				"rowCriteria":    gridTemplate.RowCriteria,
-				"colCriteria":    gridTemplate.ColCriteria,
-				"difficulty":     gridTemplate.Difficulty,
-				"roomDifficulty": room.Difficulty,
-			},
-		})
+		payload := gridPayload(room, gridTemplate)
+		payload.PlayerIndex = &i
+		cl.sendMessage(MsgGameStarted, payload)
+		for _, mirror := range room.mirrorsOf(cl.userID) {
+			mirror.sendMessage(MsgGameStarted, payload)
+		}
 	}
+	room.sendSpectators(encode(MsgGameStarted, spectatorGamePayload(room, gridTemplate)), 0)
 
 	// Broadcast initial game state
-	room.Broadcast(mustMarshal(map[string]interface{}{
-		"type":    "game_state",
-		"payload": room.GameModel,
-	}))
+	room.BroadcastWithSpectators(room.stateMessage(), true)
 
 	// Start the per-turn timer (no-op broadcast if difficulty is "easy")
-	room.StartTurnTimer(onTurnTimeout)
+	room.StartTurnTimer(c.hub.onTurnTimeout)
+	c.hub.saveGame(room)
+}
+
+// gridPayload describes the room's grid for game_started and the messages
+// that catch a client up on a game in progress.
+func gridPayload(room *GameRoom, gt *grid.GridTemplate) GameStartedPayload {
+	return GameStartedPayload{
+		RoomID:         room.ID,
+		RowCriteria:    gt.RowCriteria,
+		ColCriteria:    gt.ColCriteria,
+		Difficulty:     gt.Difficulty,
+		RoomDifficulty: room.Difficulty,
+		Seed:           gt.Seed,
+		DataVersion:    gt.DataVersion,
+	}
 }
 
-func (c *Client) handleMakeMove(p makeMovePayload) {
+func (c *Client) handleMakeMove(p MakeMovePayload) {
 	room, exists := c.hub.GetRoom(p.RoomID)
 	if !exists {
-		c.sendError("room not found")
+		c.sendError(CodeRoomNotFound, "room not found")
 		return
 	}
-	if room.GameModel == nil {
-		c.sendError("game not started")
+	if !room.IsPlayer(c.ID) {
+		c.sendError(CodeNotAPlayer, "only players in this room can make moves")
+		return
+	}
+	if p.Row < 0 || p.Row >= 3 || p.Col < 0 || p.Col >= 3 {
+		c.sendFailure(game.ErrInvalidPosition)
 		return
 	}
 
 	uid, err := strconv.Atoi(c.userID)
 	if err != nil {
-		c.sendError("invalid user id")
+		c.sendError(CodeInternal, "invalid user id")
 		return
 	}
 
-	// Validate the answer against the grid template
+	// Validate the answer against the grid template before locking the
+	// room, so the lookups don't hold up the turn timer or broadcasts
 	gridSvc := grid.NewService(c.hub.DB)
-	result, err := gridSvc.ValidateAnswer(room.GridTemplateID, p.Row, p.Col, p.PlayerID, p.Answer)
+	room.mu.RLock()
+	templateID := room.GridTemplateID
+	room.mu.RUnlock()
+	result, err := gridSvc.ValidateAnswer(templateID, p.Row, p.Col, p.PlayerID, p.Answer)
 	if err != nil {
 		log.Printf("Validation error: %v", err)
-		c.sendError("validation error")
+		c.sendError(CodeInternal, "validation error")
 		return
 	}
 
-	// Always make the move — turn advances regardless of answer validity
-	move, newTurn, err := game.MakeMove(room.GameModel, uid, p.Row, p.Col, p.Answer)
+	outcome, err := room.applyMove(gridSvc, uid, p, result)
 	if err != nil {
-		c.sendError(err.Error())
+		c.sendFailure(err)
 		return
 	}
 
-	if result.Valid {
-		move.IsValid = true
-		move.PlayerName = result.Answer.PlayerName
-		move.Headshot = result.Answer.HeadshotURL
+	switch {
+	case !result.Valid:
+		// Invalid answer — notify player, turn already advanced
+		log.Printf("Invalid move by user %d ('%s'), turn lost", uid, p.Answer)
+		c.sendMessage(MsgInvalidMove, InvalidMovePayload{Message: result.Message, Answer: p.Answer})
+	case outcome.overtakeFailed != nil:
+		// Valid answer but not rare enough — turn still lost
+		c.sendMessage(MsgOvertakeFailed, *outcome.overtakeFailed)
+	}
+
+	log.Printf("Move by user %d, valid=%v, new turn: %d", uid, result.Valid, outcome.turn)
 
-		existingMove := room.GameModel.Grid[p.Row][p.Col]
+	// Broadcast updated game state to both players regardless of outcome
+	room.BroadcastWithSpectators(outcome.state, true)
 
-		if existingMove == nil {
-			// Empty cell — place it and check for win
-			room.GameModel.Grid[p.Row][p.Col] = move
+	// Restart the turn timer for whoever's turn it is now, unless the
+	// game just ended
+	if !outcome.ended {
+		room.StartTurnTimer(c.hub.onTurnTimeout)
+	} else {
+		room.StopTurnTimer()
+	}
+	if outcome.overtaken == nil && !outcome.ended {
+		c.hub.saveGame(room)
+		return
+	}
 
-			if game.CheckWin(room.GameModel, uid) {
-				room.GameModel.Game.Status = models.GameStatusCompleted
-				room.GameModel.Game.WinnerID = &uid
+	// Explaining answers takes a query or two per criteria, so an
+	// overtake and the end of the game are announced off the read loop
+	go func() {
+		if outcome.overtaken != nil {
+			explanations, err := gridSvc.ExplainCell(templateID, p.Row, p.Col, result.Answer.MlbID)
+			if err != nil {
+				log.Printf("Failed to explain overtake in room %s: %v", room.ID, err)
+			} else {
+				outcome.overtaken.Explanations = explanations
 			}
+			room.Broadcast(encode(MsgCellOvertaken, *outcome.overtaken))
+		}
+		if outcome.ended {
+			room.EndGame(outcome.winnerID, answerSummary(gridSvc, room.ID, templateID, outcome.board))
+		}
+		c.hub.saveGame(room)
+	}()
+}
 
-		} else {
-			// Cell occupied — check rarity to determine overtake
-			existingResult, err := gridSvc.ValidateAnswer(
-				room.GridTemplateID, p.Row, p.Col,
-				*existingMove.PlayerID, existingMove.PlayerAnswer,
+// moveOutcome is what applyMove did to the game, for the caller to
+// announce once the room is unlocked.
+type moveOutcome struct {
+	turn           int
+	overtaken      *CellOvertakenPayload
+	overtakeFailed *OvertakeFailedPayload
+	state          []byte // game_state after the move
+	ended          bool
+	winnerID       int                    // 0 for a draw
+	board          [3][3]*models.GameMove // the final board, if ended
+}
+
+// applyMove makes a validated move under the room lock. The only lookup
+// it needs is the rarity of the answer already in the cell, which runs
+// unlocked; if the cell changes meanwhile it's looked up again.
+func (r *GameRoom) applyMove(gridSvc *grid.Service, uid int, p MakeMovePayload, result *grid.ValidationResult) (*moveOutcome, error) {
+	var existingResult *grid.ValidationResult
+	var existingErr error
+	for {
+		r.mu.RLock()
+		if r.GameModel == nil {
+			r.mu.RUnlock()
+			return nil, ErrGameNotStarted
+		}
+		existing := r.GameModel.Grid[p.Row][p.Col]
+		r.mu.RUnlock()
+
+		if result.Valid && existing != nil {
+			existingResult, existingErr = gridSvc.ValidateAnswer(
+				r.GridTemplateID, p.Row, p.Col,
+				*existing.PlayerID, existing.PlayerAnswer,
 			)
+		}
 
-			canOvertake := false
-			if err != nil || !existingResult.Valid {
-				canOvertake = true
-			} else {
-				// Lower rarity score = rarer = can overtake higher score
-				canOvertake = result.RarityScore < existingResult.RarityScore
-			}
+		r.mu.Lock()
+		if r.GameModel != nil && r.GameModel.Grid[p.Row][p.Col] == existing {
+			break // still locked
+		}
+		r.mu.Unlock()
+	}
+	defer r.mu.Unlock()
 
-			if canOvertake {
-				room.GameModel.Grid[p.Row][p.Col] = move
+	state := r.GameModel
+	existing := state.Grid[p.Row][p.Col]
 
-				if game.CheckWin(room.GameModel, uid) {
-					room.GameModel.Game.Status = models.GameStatusCompleted
-					room.GameModel.Game.WinnerID = &uid
-				}
+	// Always make the move — turn advances regardless of answer validity
+	move, newTurn, err := game.MakeMove(state, uid, p.Row, p.Col, p.Answer)
+	if err != nil {
+		return nil, err
+	}
+	outcome := &moveOutcome{turn: newTurn}
 
-				room.Broadcast(mustMarshal(map[string]interface{}{
-					"type": "cell_overtaken",
-					"payload": map[string]interface{}{
-						"row":         p.Row,
-						"col":         p.Col,
-						"newPlayer":   result.Answer.PlayerName,
-						"oldPlayer":   existingMove.PlayerName,
-						"rarityScore": result.RarityScore,
-					},
-				}))
-			} else {
-				// Valid answer but not rare enough — turn still lost
-				c.sendJSON(map[string]interface{}{
-					"type": "overtake_failed",
-					"payload": map[string]interface{}{
-						"message":        "Your answer isn't rarer than the existing one",
-						"yourRarity":     result.RarityScore,
-						"existingRarity": existingResult.RarityScore,
-					},
-				})
+	if result.Valid {
+		move.IsValid = true
+		move.PlayerName = result.Answer.PlayerName
+		move.Headshot = result.Answer.HeadshotURL
+		move.MLBPlayerID = result.Answer.MlbID
+
+		// An occupied cell is only taken by a rarer answer (lower rarity
+		// score = rarer)
+		place := existing == nil || existingErr != nil || !existingResult.Valid ||
+			result.RarityScore < existingResult.RarityScore
+		if place {
+			state.Grid[p.Row][p.Col] = move
+			if game.CheckWin(state, uid) {
+				state.Game.Status = models.GameStatusCompleted
+				state.Game.WinnerID = &uid
+			}
+			if existing != nil {
+				outcome.overtaken = &CellOvertakenPayload{
+					Row:         p.Row,
+					Col:         p.Col,
+					NewPlayer:   result.Answer.PlayerName,
+					OldPlayer:   existing.PlayerName,
+					RarityScore: result.RarityScore,
+				}
+			}
+		} else {
+			outcome.overtakeFailed = &OvertakeFailedPayload{
+				Message:        "Your answer isn't rarer than the existing one",
+				YourRarity:     result.RarityScore,
+				ExistingRarity: existingResult.RarityScore,
 			}
 		}
-	} else {
-		// Invalid answer — notify player, turn already advanced
-		log.Printf("Invalid move by user %d ('%s'), turn lost", uid, p.Answer)
-		c.sendJSON(map[string]interface{}{
-			"type": "invalid_move",
-			"payload": map[string]interface{}{
-				"message": result.Message,
-				"answer":  p.Answer,
-			},
-		})
 	}
 
-	log.Printf("Move by user %d, valid=%v, new turn: %d", uid, result.Valid, newTurn)
-
-	// Broadcast updated game state to both players regardless of outcome
-	room.Broadcast(mustMarshal(map[string]interface{}{
-		"type":    "game_state",
-		"payload": room.GameModel,
-	}))
+	outcome.state = encode(MsgGameState, state)
+	if state.Game.Status == models.GameStatusCompleted {
+		outcome.ended = true
+		outcome.board = state.Grid
+		if state.Game.WinnerID != nil {
+			outcome.winnerID = *state.Game.WinnerID
+		}
+	}
+	return outcome, nil
+}
 
-	// Restart the turn timer for whoever's turn it is now, unless the
-	// game just ended
-	if room.GameModel.Game.Status == models.GameStatusActive {
-		room.StartTurnTimer(onTurnTimeout)
-	} else {
-		room.StopTurnTimer()
+// answerSummary explains every valid answer left on the board, for the
+// post-game "why were these correct?" screen. Cells that can't be
+// explained are listed without explanations.
+func answerSummary(gridSvc *grid.Service, roomID string, templateID int, board [3][3]*models.GameMove) []AnswerSummary {
+	summary := []AnswerSummary{}
+	for r, row := range board {
+		for col, move := range row {
+			if move == nil || !move.IsValid || move.MLBPlayerID == 0 {
+				continue
+			}
+			cell := AnswerSummary{
+				Row:        r,
+				Col:        col,
+				PlayerName: move.PlayerName,
+				MlbID:      move.MLBPlayerID,
+			}
+			explanations, err := gridSvc.ExplainCell(templateID, r, col, move.MLBPlayerID)
+			if err != nil {
+				log.Printf("Failed to explain cell (%d,%d) in room %s: %v", r, col, roomID, err)
+			} else {
+				cell.Explanations = explanations
+			}
+			summary = append(summary, cell)
+		}
 	}
+	return summary
 }
 
 // handleLeaveRoom handles a client leaving a game room.
 func (c *Client) handleLeaveRoom() {
+	if c.spectating != "" {
+		c.stopSpectating()
+		return
+	}
 	if c.currentRoom == "" {
 		return
 	}
-	room, exists := c.hub.GetRoom(c.currentRoom)
-	if exists {
-		room.RemovePlayer(c.ID)
-		room.Broadcast(mustMarshal(map[string]interface{}{
-			"type":    "player_left",
-			"payload": map[string]interface{}{"playerId": c.ID},
-		}))
-
-		// Delete room from hub if empty
-		room.mu.RLock()
-		playerCount := len(room.Players)
-		room.mu.RUnlock()
-
-		if playerCount == 0 {
-			c.hub.mu.Lock()
-			delete(c.hub.rooms, c.currentRoom)
-			c.hub.mu.Unlock()
-			log.Printf("Room %s deleted after last player left", c.currentRoom)
-		}
+	// Leaving from any tab gives up the user's seat; the room goes if empty
+	if room, exists := c.hub.GetRoom(c.currentRoom); exists {
+		c.hub.leaveSeat(room, c)
 	}
 	c.currentRoom = ""
 	c.hub.BroadcastRoomList()
@@ -673,44 +972,45 @@ func (c *Client) handleLeaveRoom() {
 
 func (c *Client) handlePlayerReady(ready bool) {
 	if c.currentRoom == "" {
-		c.sendError("not in a room")
+		c.sendError(CodeNotInRoom, "not in a room")
 		return
 	}
 
 	room, exists := c.hub.GetRoom(c.currentRoom)
 	if !exists {
-		c.sendError("room not found")
+		c.sendError(CodeRoomNotFound, "room not found")
+		return
+	}
+
+	seatID := room.SeatID(c.ID)
+	if seatID == "" {
+		c.sendError(CodeNotInRoom, "not in this room")
 		return
 	}
 
-	room.Broadcast(mustMarshal(map[string]interface{}{
-		"type": "player_ready",
-		"payload": map[string]interface{}{
-			"playerId": c.ID,
-			"username": c.username,
-			"ready":    ready,
-		},
+	room.Broadcast(encode(MsgPlayerReady, PlayerReadyPayload{
+		PlayerID: seatID,
+		UserID:   c.userID,
+		Username: c.username,
+		Ready:    ready,
 	}))
 
-	allReady := room.SetReady(c.ID, ready)
+	allReady := room.SetReady(seatID, ready)
 	log.Printf("handlePlayerReady: client %s ready=%v allReady=%v", c.ID, ready, allReady)
 	if allReady {
-		room.Broadcast(mustMarshal(map[string]interface{}{
-			"type":    "room_ready",
-			"payload": map[string]interface{}{"roomId": room.ID},
-		}))
+		room.Broadcast(encode(MsgRoomReady, RoomEventPayload{RoomID: room.ID}))
 	}
 }
 
 func (c *Client) handleRematch() {
 	if c.currentRoom == "" {
-		c.sendError("not in a room")
+		c.sendError(CodeNotInRoom, "not in a room")
 		return
 	}
 
 	room, exists := c.hub.GetRoom(c.currentRoom)
 	if !exists {
-		c.sendError("room not found")
+		c.sendError(CodeRoomNotFound, "room not found")
 		return
 	}
 
@@ -727,10 +1027,8 @@ func (c *Client) handleRematch() {
 	room.mu.Unlock()
 
 	// Notify all players to go back to ready screen
-	room.Broadcast(mustMarshal(map[string]interface{}{
-		"type":    "rematch",
-		"payload": map[string]interface{}{"roomId": room.ID},
-	}))
+	room.Broadcast(encode(MsgRematch, RoomEventPayload{RoomID: room.ID}))
+	c.hub.saveGame(room)
 
 	log.Printf("Rematch requested in room %s", room.ID)
 }
@@ -738,8 +1036,3 @@ func (c *Client) handleRematch() {
 func (c *Client) Close() {
 	close(c.send)
 }
-
-func mustMarshal(v interface{}) []byte {
-	b, _ := json.Marshal(v)
-	return b
-}
@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"trivia-server/grid"
+	"trivia-server/models"
+)
+
+func testClient(h *Hub, userID string) *Client {
+	return &Client{hub: h, send: make(chan []byte, 16), ID: "client-" + userID, userID: userID}
+}
+
+// nextError reads the client's next message, which must be an error.
+func nextError(t *testing.T, c *Client) ErrorPayload {
+	t.Helper()
+	var env struct {
+		Type    string       `json:"type"`
+		Payload ErrorPayload `json:"payload"`
+	}
+	select {
+	case data := <-c.send:
+		if err := json.Unmarshal(data, &env); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatal("nothing was sent")
+	}
+	if env.Type != MsgError {
+		t.Fatalf("got a %s, want an error", env.Type)
+	}
+	return env.Payload
+}
+
+// A custom grid that can't be used is refused before anything is
+// claimed or saved.
+func TestCreateRoomRejectsCustomGrid(t *testing.T) {
+	tests := []struct {
+		name string
+		p    CreateRoomPayload
+	}{
+		{"public room", CreateRoomPayload{RoomName: "open", RowCriteria: []int{1, 2, 3}, ColCriteria: []int{4, 5, 6}}},
+		{"too few columns", CreateRoomPayload{RoomName: "closed", Password: "pw", RowCriteria: []int{1, 2, 3}, ColCriteria: []int{4, 5}}},
+		{"no rows", CreateRoomPayload{RoomName: "closed", Password: "pw", ColCriteria: []int{4, 5, 6}}},
+	}
+	for _, tt := range tests {
+		h := NewHub(nil)
+		c := testClient(h, "1")
+		c.handleCreateRoom(tt.p)
+
+		if e := nextError(t, c); e.Code != CodeInvalidGrid {
+			t.Errorf("%s: error %s, want INVALID_GRID", tt.name, e.Code)
+		}
+		if len(h.rooms) != 0 || c.currentRoom != "" {
+			t.Errorf("%s: a room was created", tt.name)
+		}
+	}
+}
+
+func TestStartGameRefusedMidGame(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+	h.AddRoom(room)
+	alice.currentRoom = room.ID
+	game := room.GameModel
+	drain(alice)
+
+	alice.handleStartGame()
+	if e := nextError(t, alice); e.Code != CodeGameInProgress {
+		t.Errorf("start_game mid-game = %s, want GAME_IN_PROGRESS", e.Code)
+	}
+	if room.GameModel != game {
+		t.Error("the game in progress was replaced")
+	}
+}
+
+// Two starts racing, e.g. both players pressing start with grids ready in
+// the pool, start one game; readers see its grid whole.
+func TestStartGameOnce(t *testing.T) {
+	room := NewGameRoom("room-1", "Room", "", "1")
+	gm := NewGameManager()
+
+	var wg sync.WaitGroup
+	started := make(chan int, 2)
+	for _, id := range []int{1, 2} {
+		wg.Add(2)
+		go func(gt *grid.GridTemplate) {
+			defer wg.Done()
+			gs := &models.GameState{Game: models.Game{Status: models.GameStatusActive}}
+			if room.startGameOn(gs, gm, gt) {
+				started <- gt.ID
+			}
+		}(&grid.GridTemplate{ID: id})
+		go func() {
+			defer wg.Done()
+			room.currentGrid(nil)
+		}()
+	}
+	wg.Wait()
+	close(started)
+
+	var winners []int
+	for id := range started {
+		winners = append(winners, id)
+	}
+	if len(winners) != 1 {
+		t.Fatalf("%d games started, want 1", len(winners))
+	}
+	if gt := room.currentGrid(nil); gt == nil || gt.ID != winners[0] || room.GridTemplateID != winners[0] {
+		t.Errorf("room is on grid %v (template %d), want %d", gt, room.GridTemplateID, winners[0])
+	}
+}
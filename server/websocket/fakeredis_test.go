@@ -0,0 +1,220 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis"
+)
+
+// fakeRedis speaks just enough of the Redis protocol for the backplane's
+// leases and seats and for game snapshots: GET, SET (NX), DEL, the set
+// commands, PUBLISH and the Lua scripts, which it recognizes by their
+// SHA1 and runs in Go. Expiry is ignored.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	sets map[string]map[string]bool
+}
+
+// openFakeRedis starts a fakeRedis and returns a client for it.
+func openFakeRedis(t *testing.T) (*redis.Client, *fakeRedis) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{data: make(map[string]string), sets: make(map[string]map[string]bool)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String()})
+	t.Cleanup(func() {
+		rdb.Close()
+		ln.Close()
+	})
+	return rdb, f
+}
+
+func (f *fakeRedis) get(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.data[key]
+	return v, ok
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.run(args)); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads one command, an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, fmt.Errorf("bad command header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("bad bulk header %q", header)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func bulk(v string, ok bool) string {
+	if !ok {
+		return "$-1\r\n"
+	}
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+}
+
+func integer(n int) string { return fmt.Sprintf(":%d\r\n", n) }
+
+func (f *fakeRedis) run(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToLower(args[0]) {
+	case "ping":
+		return "+PONG\r\n"
+	case "get":
+		v, ok := f.data[args[1]]
+		return bulk(v, ok)
+	case "set":
+		if _, exists := f.data[args[1]]; exists && containsFold(args[3:], "nx") {
+			return "$-1\r\n"
+		}
+		f.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "del":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				n++
+			}
+		}
+		return integer(n)
+	case "sadd":
+		f.sadd(args[1], args[2:]...)
+		return integer(len(args) - 2)
+	case "srem":
+		for _, member := range args[2:] {
+			delete(f.sets[args[1]], member)
+		}
+		return integer(len(args) - 2)
+	case "smembers":
+		reply := fmt.Sprintf("*%d\r\n", len(f.sets[args[1]]))
+		for member := range f.sets[args[1]] {
+			reply += bulk(member, true)
+		}
+		return reply
+	case "publish":
+		return integer(0)
+	case "evalsha":
+		return "-NOSCRIPT No matching script\r\n"
+	case "eval":
+		sum := sha1.Sum([]byte(args[1]))
+		numKeys, _ := strconv.Atoi(args[2])
+		return f.script(hex.EncodeToString(sum[:]), args[3:3+numKeys], args[3+numKeys:])
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+func (f *fakeRedis) sadd(key string, members ...string) {
+	if f.sets[key] == nil {
+		f.sets[key] = make(map[string]bool)
+	}
+	for _, member := range members {
+		f.sets[key][member] = true
+	}
+}
+
+// script runs one of the Lua scripts on keys. f.mu must be held.
+func (f *fakeRedis) script(hash string, keys []string, argv []string) string {
+	key := keys[0]
+	current, exists := f.data[key]
+	switch hash {
+	case saveSnapshot.Hash():
+		stored, _ := strconv.ParseInt(f.data[keys[1]], 10, 64)
+		version, _ := strconv.ParseInt(argv[0], 10, 64)
+		if stored >= version {
+			return integer(0)
+		}
+		f.data[key], f.data[keys[1]] = argv[1], argv[0]
+		f.sadd(keys[2], argv[3])
+		return integer(1)
+	case deleteSnapshot.Hash():
+		stored, _ := strconv.ParseInt(f.data[keys[1]], 10, 64)
+		version, _ := strconv.ParseInt(argv[0], 10, 64)
+		if stored > version {
+			return integer(0)
+		}
+		delete(f.data, key)
+		f.data[keys[1]] = argv[0]
+		delete(f.sets[keys[2]], argv[2])
+		return integer(1)
+	case renewLease.Hash(), setSeat.Hash():
+		if exists && current != argv[0] {
+			return integer(0)
+		}
+		f.data[key] = argv[0]
+		return integer(1)
+	case deleteIfValue.Hash():
+		if !exists || current != argv[0] {
+			return integer(0)
+		}
+		delete(f.data, key)
+		return integer(1)
+	}
+	return "-NOSCRIPT unknown script\r\n"
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
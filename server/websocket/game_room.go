@@ -6,14 +6,16 @@ import (
 	"strconv"
 	"sync"
 	"time"
+	"trivia-server/grid"
 	"trivia-server/models"
 )
 
 var (
-	ErrRoomNotFound = errors.New("room not found")
-	ErrRoomFull     = errors.New("room is full")
-	ErrPlayerExists = errors.New("player already exists in the room")
-	ErrRoomClosed   = errors.New("room is closed")
+	ErrRoomNotFound   = errors.New("room not found")
+	ErrRoomFull       = errors.New("room is full")
+	ErrPlayerExists   = errors.New("player already exists in the room")
+	ErrRoomClosed     = errors.New("room is closed")
+	ErrGameNotStarted = errors.New("game not started")
 )
 
 type GameRoom struct {
@@ -35,13 +37,37 @@ type GameRoom struct {
 	GameStatus     string
 	GridTemplateID int
 	Difficulty     string // "easy" | "regular" | "hard"
+	GridSeed       int64  // 0 = a new random grid every game
+	CustomGridID   int    // host-built template played every game, 0 if none
+	GridFilters    grid.GridFilters
+	gridPending    bool // a grid is being generated for the next game
 
 	RematchRequests map[string]bool // playerID -> accepted
 	rematchMu       sync.Mutex
 
+	// Seats held for players whose connection dropped mid-game, by the
+	// dropped client's ID; see HoldSeat
+	away map[string]*awaySeat
+
+	// Other connections of seated users, by client ID, when the hub's
+	// SessionPolicy is SessionMirror
+	mirrors map[string]*Client
+
+	chat []ChatMessage // the last ChatHistorySize messages
+
+	// Read-only observers by client ID; see AddSpectator
+	Spectators      map[string]*Client
+	AllowSpectators bool
+	SpectatorDelay  time.Duration // how far behind the players spectators see the board
+
 	// Turn timer
-	turnTimer   *time.Timer
-	turnTimerMu sync.Mutex
+	turnTimer    *time.Timer
+	turnDeadline time.Time // zero when no timer is running
+	turnTimerMu  sync.Mutex
+
+	gridTemplate *grid.GridTemplate // the current game's grid, for resuming players
+
+	snapshotVersion int64 // of the last snapshot taken; see snapshot
 }
 
 type GameState struct {
@@ -65,6 +91,9 @@ func NewGameRoom(id, name, password, creatorID string) *GameRoom {
 		Players:      make(map[string]*Client),
 		playerOrder:  make([]string, 0),
 		readyPlayers: make(map[string]bool),
+		away:         make(map[string]*awaySeat),
+		mirrors:      make(map[string]*Client),
+		Spectators:   make(map[string]*Client),
 		Difficulty:   "regular",
 		State: GameState{
 			Status:      "waiting",
@@ -74,6 +103,7 @@ func NewGameRoom(id, name, password, creatorID string) *GameRoom {
 		},
 		CreatedAt:       time.Now(),
 		RematchRequests: make(map[string]bool),
+		AllowSpectators: true,
 	}
 }
 
@@ -96,6 +126,23 @@ func turnDurationForDifficulty(difficulty string) time.Duration {
 // being made — the caller is responsible for verifying the turn is
 // still the same one the timer was started for (turnAtStart).
 func (r *GameRoom) StartTurnTimer(onTimeout func(room *GameRoom, turnAtStart int)) {
+	r.startTurnTimer(turnDurationForDifficulty(r.Difficulty), onTimeout)
+}
+
+// resumeTurnTimer restarts the timer of a restored game so the turn
+// still ends at deadline, or at once if that has passed. A zero deadline
+// means the turn wasn't timed.
+func (r *GameRoom) resumeTurnTimer(deadline time.Time, onTimeout func(room *GameRoom, turnAtStart int)) {
+	if deadline.IsZero() {
+		r.StartTurnTimer(onTimeout)
+		return
+	}
+	r.startTurnTimer(max(time.Until(deadline), 0), onTimeout)
+}
+
+// startTurnTimer runs the turn timer for left, the whole turn unless a
+// restored game picks up part way through one.
+func (r *GameRoom) startTurnTimer(left time.Duration, onTimeout func(room *GameRoom, turnAtStart int)) {
 	duration := turnDurationForDifficulty(r.Difficulty)
 
 	r.turnTimerMu.Lock()
@@ -103,20 +150,18 @@ func (r *GameRoom) StartTurnTimer(onTimeout func(room *GameRoom, turnAtStart int
 		r.turnTimer.Stop()
 		r.turnTimer = nil
 	}
+	r.turnDeadline = time.Time{}
 
 	if duration <= 0 {
 		r.turnTimerMu.Unlock()
 		// No timer for this difficulty — tell clients to hide any UI
-		r.Broadcast(mustMarshal(map[string]interface{}{
-			"type":    "turn_timer",
-			"payload": map[string]interface{}{"duration": 0},
-		}))
+		r.BroadcastWithSpectators(encode(MsgTurnTimer, TurnTimerPayload{}), false)
 		return
 	}
 
 	r.mu.RLock()
 	turnAtStart := 0
-	active := r.GameModel != nil && r.GameModel.Game.Status == models.GameStatusActive
+	active := r.playingLocked()
 	if r.GameModel != nil {
 		turnAtStart = r.GameModel.Game.CurrentTurn
 	}
@@ -127,19 +172,17 @@ func (r *GameRoom) StartTurnTimer(onTimeout func(room *GameRoom, turnAtStart int
 		return
 	}
 
-	deadline := time.Now().Add(duration)
-	r.turnTimer = time.AfterFunc(duration, func() {
+	deadline := time.Now().Add(left)
+	r.turnTimer = time.AfterFunc(left, func() {
 		onTimeout(r, turnAtStart)
 	})
+	r.turnDeadline = deadline
 	r.turnTimerMu.Unlock()
 
-	r.Broadcast(mustMarshal(map[string]interface{}{
-		"type": "turn_timer",
-		"payload": map[string]interface{}{
-			"deadline": deadline.UnixMilli(),
-			"duration": int(duration.Seconds()),
-		},
-	}))
+	r.BroadcastWithSpectators(encode(MsgTurnTimer, TurnTimerPayload{
+		Deadline: deadline.UnixMilli(),
+		Duration: int(duration.Seconds()),
+	}), false)
 }
 
 // StopTurnTimer cancels any active turn timer for this room.
@@ -150,6 +193,7 @@ func (r *GameRoom) StopTurnTimer() {
 		r.turnTimer.Stop()
 		r.turnTimer = nil
 	}
+	r.turnDeadline = time.Time{}
 }
 
 func (r *GameRoom) AddPlayer(client *Client) error {
@@ -160,7 +204,8 @@ func (r *GameRoom) AddPlayer(client *Client) error {
 		return ErrRoomFull
 	}
 
-	if _, exists := r.Players[client.ID]; exists {
+	// One seat per user, however many connections they have
+	if _, exists := r.Players[client.ID]; exists || r.hasUserLocked(client.userID) {
 		r.mu.Unlock()
 		return ErrPlayerExists
 	}
@@ -175,25 +220,20 @@ func (r *GameRoom) AddPlayer(client *Client) error {
 
 	r.mu.Unlock() // release BEFORE broadcasting
 
-	joinMsg := Message{
-		Type: "player_joined",
-		Payload: map[string]interface{}{
-			"roomId":      r.ID,
-			"playerId":    client.ID,
-			"playerCount": r.State.PlayerCount,
-			"userId":      client.userID,
-			"username":    client.username,
+	joinMsg := Envelope{
+		Type: MsgPlayerJoined,
+		Payload: PlayerJoinedPayload{
+			RoomID:      r.ID,
+			PlayerID:    client.ID,
+			PlayerCount: r.State.PlayerCount,
+			UserID:      client.userID,
+			Username:    client.username,
 		},
 	}
 	r.Broadcast(joinMsg.ToJSON())
 
 	if isFull {
-		readyMsg := Message{
-			Type: "room_ready",
-			Payload: map[string]interface{}{
-				"roomId": r.ID,
-			},
-		}
+		readyMsg := Envelope{Type: MsgRoomReady, Payload: RoomEventPayload{RoomID: r.ID}}
 		r.Broadcast(readyMsg.ToJSON())
 	}
 
@@ -202,16 +242,75 @@ func (r *GameRoom) AddPlayer(client *Client) error {
 
 func (r *GameRoom) StartGame(gameState *models.GameState, gameID int, gm *GameManager) {
 	r.mu.Lock()
+	r.setGameLocked(gameState, gameID, gm)
+	r.mu.Unlock()
+
+	// Remove the startMsg broadcast — handleStartGame sends game_started
+	// with playerIndex to each client individually instead
+	r.GameManager.AddGameRoom(r.GameID, r)
+}
+
+// startGameOn starts a new game on gt, unless one is already being
+// played: two players can both press start, and a grid from the pool
+// arrives at once. Returns false, changing nothing, if so.
+func (r *GameRoom) startGameOn(gameState *models.GameState, gm *GameManager, gt *grid.GridTemplate) bool {
+	r.mu.Lock()
+	if r.playingLocked() {
+		r.mu.Unlock()
+		return false
+	}
+	r.GridTemplateID = gt.ID
+	r.gridTemplate = gt
+	r.setGameLocked(gameState, 0, gm)
+	r.mu.Unlock()
+
+	r.GameManager.AddGameRoom(r.GameID, r)
+	return true
+}
+
+func (r *GameRoom) setGameLocked(gameState *models.GameState, gameID int, gm *GameManager) {
 	r.GameModel = gameState
 	r.GameID = gameID
 	r.GameManager = gm
 	r.State.Status = "active"
 	r.GameStatus = "active"
-	r.mu.Unlock()
+}
 
-	// Remove the startMsg broadcast — handleStartGame sends game_started
-	// with playerIndex to each client individually instead
-	r.GameManager.AddGameRoom(r.GameID, r)
+// playing reports whether a game is being played in the room.
+func (r *GameRoom) playing() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.playingLocked()
+}
+
+func (r *GameRoom) playingLocked() bool {
+	return r.GameModel != nil && r.GameModel.Game.Status == models.GameStatusActive
+}
+
+// stateMessage encodes game_state for the room's game. Moves change the
+// model in place under r.mu, so it's encoded under the lock.
+func (r *GameRoom) stateMessage() []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return encode(MsgGameState, r.GameModel)
+}
+
+// BeginGridGeneration marks the room as waiting on a grid. Returns false
+// if a generation is already running, so a double start is ignored.
+func (r *GameRoom) BeginGridGeneration() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gridPending {
+		return false
+	}
+	r.gridPending = true
+	return true
+}
+
+func (r *GameRoom) EndGridGeneration() {
+	r.mu.Lock()
+	r.gridPending = false
+	r.mu.Unlock()
 }
 
 func (r *GameRoom) RemovePlayer(clientID string) bool {
@@ -222,6 +321,7 @@ func (r *GameRoom) RemovePlayer(clientID string) bool {
 		return false
 	}
 
+	userID := r.Players[clientID].userID
 	delete(r.Players, clientID)
 	delete(r.readyPlayers, clientID)
 	r.State.PlayerCount = len(r.Players)
@@ -241,12 +341,13 @@ func (r *GameRoom) RemovePlayer(clientID string) bool {
 
 	r.mu.Unlock() // release BEFORE broadcasting
 
-	leaveMsg := Message{
-		Type: "player_left",
-		Payload: map[string]interface{}{
-			"roomId":      r.ID,
-			"playerId":    clientID,
-			"playerCount": r.State.PlayerCount,
+	leaveMsg := Envelope{
+		Type: MsgPlayerLeft,
+		Payload: PlayerLeftPayload{
+			RoomID:      r.ID,
+			PlayerID:    clientID,
+			UserID:      userID,
+			PlayerCount: r.State.PlayerCount,
 		},
 	}
 	r.Broadcast(leaveMsg.ToJSON())
@@ -278,12 +379,13 @@ func (r *GameRoom) SetReady(clientID string, ready bool) bool {
 	return readyCount == playerCount && playerCount == r.State.MaxPlayers
 }
 
-func (r *GameRoom) EndGame(winnerID int) {
+func (r *GameRoom) EndGame(winnerID int, summary []AnswerSummary) {
 	r.StopTurnTimer()
 
 	r.mu.Lock()
 	r.GameStatus = "completed"
 	r.State.Status = "completed"
+	finalState := r.GameModel
 
 	var winnerUsername string
 	var isDraw bool
@@ -304,21 +406,19 @@ func (r *GameRoom) EndGame(winnerID int) {
 	r.mu.Unlock()
 
 	// Broadcast game ended
-	payload := map[string]interface{}{
-		"room_id":     r.ID,
-		"final_state": r.GameModel,
-		"is_draw":     isDraw,
+	payload := GameEndedPayload{
+		RoomID:     r.ID,
+		FinalState: finalState,
+		IsDraw:     isDraw,
+		Summary:    summary,
 	}
 
 	if !isDraw {
-		payload["winner_id"] = winnerID
-		payload["winner_username"] = winnerUsername
+		payload.WinnerID = winnerID
+		payload.WinnerUsername = winnerUsername
 	}
 
-	r.Broadcast(mustMarshal(map[string]interface{}{
-		"type":    "game_ended",
-		"payload": payload,
-	}))
+	r.BroadcastWithSpectators(encode(MsgGameEnded, payload), true)
 
 	if isDraw {
 		log.Printf("Game ended in draw in room %s", r.ID)
@@ -331,13 +431,22 @@ func (r *GameRoom) Broadcast(message []byte) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for _, client := range r.Players {
+	for id, client := range r.Players {
+		if _, away := r.away[id]; away {
+			continue
+		}
 		select {
 		case client.send <- message:
 		default:
 			// Client's send channel is full, skip
 		}
 	}
+	for _, client := range r.mirrors {
+		select {
+		case client.send <- message:
+		default:
+		}
+	}
 }
 
 func (r *GameRoom) GetOrderedClients() []*Client {
@@ -359,18 +468,23 @@ func (r *GameRoom) Close() {
 	r.mu.Lock()
 	r.State.Status = "closed"
 
-	closeMsg := Message{
-		Type: "room_closed",
-		Payload: map[string]interface{}{
-			"roomId": r.ID,
-		},
-	}
+	closeMsg := Envelope{Type: MsgRoomClosed, Payload: RoomEventPayload{RoomID: r.ID}}
 
 	// Get client list before broadcasting
 	clients := make([]*Client, 0, len(r.Players))
-	for _, client := range r.Players {
+	for id, client := range r.Players {
+		if _, away := r.away[id]; !away {
+			clients = append(clients, client)
+		}
+	}
+	for _, client := range r.mirrors {
+		clients = append(clients, client)
+	}
+	for _, client := range r.Spectators {
 		clients = append(clients, client)
 	}
+	r.Spectators = make(map[string]*Client)
+	r.mirrors = make(map[string]*Client)
 	r.mu.Unlock()
 
 	// Broadcast without holding lock
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"strconv"
@@ -37,6 +38,13 @@ func Handler(hub *Hub, jwtService *sessions.JWTService, gm *GameManager) http.Ha
 		userID := strconv.Itoa(claims.UserID)
 		username := claims.Username
 
+		// While draining, only players coming back to a seat get in: the
+		// games being waited on can still finish
+		if hub.stopped.Load() || (hub.Draining() && !hub.seated(userID)) {
+			http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Println(err)
@@ -52,6 +60,16 @@ func Handler(hub *Hub, jwtService *sessions.JWTService, gm *GameManager) http.Ha
 		hub.register <- client
 
 		go client.writePump()
+
+		// Back into the room this user has a seat in, per the session
+		// policy, before reading anything
+		hub.attach(client)
 		go client.readPump()
 	}
 }
+
+// SchemaHandler serves the protocol schema (GET /api/protocol/schema).
+func SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	json.NewEncoder(w).Encode(Schema())
+}
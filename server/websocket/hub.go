@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
+	"trivia-server/grid"
 )
 
 type Hub struct {
@@ -15,11 +18,53 @@ type Hub struct {
 	register chan *Client
 	//Unregister requests from the clients
 	unregister chan *Client
+	// Closed by Shutdown to end Run
+	quit chan struct{}
+
+	// Set by Shutdown: no new rooms or games, then no more changes to
+	// the rooms once they're saved
+	draining atomic.Bool
+	stopped  atomic.Bool
 
 	//Game rooms
 	rooms map[string]*GameRoom
 	mu    sync.RWMutex
 	DB    *sql.DB
+
+	// Ready-made grids for games without favorite teams; may be nil
+	GridPool *grid.Pool
+
+	// How long a seat is held for a player who drops mid-game; 0 removes
+	// them straight away
+	ReconnectGrace time.Duration
+
+	// What a user's second connection does; see SessionPolicy
+	SessionPolicy SessionPolicy
+	sessionsMu    sync.RWMutex
+	byID          map[string]*Client   // client ID -> connection
+	byUser        map[string][]*Client // user ID -> connections, oldest first
+
+	// Chat moderation: masked words and who has muted whom (user ID ->
+	// muted user IDs). Chat's rate limit is in Limits.PerType.
+	ChatFilter *ChatFilter
+
+	// Outcomes of recent make_move and create_room requests, so retries
+	// don't run twice
+	requests *requestCache
+
+	// Shares rooms and the lobby with other instances; nil when this is
+	// the only one
+	Backplane *Backplane
+
+	// Saves games in progress so a restart can restore them; nil to
+	// keep them in memory only
+	Games *GameStore
+
+	// Rate limits and room caps; see Limits
+	Limits     Limits
+	userLimits *userLimiter
+	mutes      map[string]map[string]bool
+	mutesMu    sync.RWMutex
 }
 
 // Creates a new WebSocket hub instance
@@ -28,41 +73,64 @@ func NewHub(db *sql.DB) *Hub {
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		quit:       make(chan struct{}),
 		clients:    make(map[*Client]bool),
+		byID:       make(map[string]*Client),
+		byUser:     make(map[string][]*Client),
 		rooms:      make(map[string]*GameRoom),
 		DB:         db,
+
+		ReconnectGrace: DefaultReconnectGrace,
+		SessionPolicy:  SessionTakeover,
+		ChatFilter:     NewChatFilter(nil),
+		requests:       newRequestCache(),
+		Limits:         DefaultLimits(),
+		userLimits:     newUserLimiter(),
+		mutes:          make(map[string]map[string]bool),
 	}
 }
 
 // Run starts the hub and handles client registration, unregistration, and message broadcasting.
+// It returns once Shutdown is done.
 func (h *Hub) Run() {
 	for {
 		select {
+		case <-h.quit:
+			return
+
 		case client := <-h.register:
 			h.clients[client] = true
+			h.addSession(client)
 			log.Printf("Client registered: %s", client.ID)
 
 			//Send welcome message
-			welcomeMsg := Message{
-				Type: "connected",
-				Payload: map[string]interface{}{
-					"message":  "Connected to server",
-					"clientId": client.ID,
-				},
-			}
-			client.send <- welcomeMsg.ToJSON()
+			client.send <- encode(MsgConnected, ConnectedPayload{
+				Message:         "Connected to server",
+				ClientID:        client.ID,
+				UserID:          client.userID,
+				ProtocolVersion: ProtocolVersion,
+			})
 
 			// Send current room list to newly connected client
-			msg := &Message{Type: "rooms_list", Payload: map[string]interface{}{"rooms": h.ListRooms()}}
-			client.send <- msg.ToJSON()
+			client.send <- encode(MsgRoomsList, RoomsListPayload{Rooms: h.LobbyRooms()})
+
+			// Clients hide lobby chat from users they muted
+			if muted := h.MutedUsers(client.userID); len(muted) > 0 {
+				client.send <- encode(MsgMutedUsers, MutedUsersPayload{UserIDs: muted})
+			}
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
+				h.removeSession(client)
+				if h.Backplane != nil {
+					h.Backplane.disconnected(client)
+				}
+				// Out of every room before the channel closes, so no
+				// broadcast can send on it
+				h.removeClientFromRooms(client)
 				close(client.send)
 				log.Printf("Client unregistered: %s", client.ID)
-
-				h.removeClientFromRooms(client)
 			}
 
 		case message := <-h.broadcast:
@@ -70,16 +138,23 @@ func (h *Hub) Run() {
 				select {
 				case client.send <- message:
 				default:
-					close(client.send)
 					delete(h.clients, client)
+					h.removeSession(client)
+					close(client.send)
 				}
 			}
 		}
 	}
 }
 
-// removeClientFromRooms removes a client from all game rooms they are part of.
+// removeClientFromRooms removes a client from all game rooms they are
+// part of. A seat in a game that's still being played is held instead,
+// so the user can reconnect and carry on.
 func (h *Hub) removeClientFromRooms(client *Client) {
+	if h.stopped.Load() {
+		// Shutting down: the rooms stay as they were saved
+		return
+	}
 	// Get rooms to check first (avoid holding lock during room operations)
 	h.mu.RLock()
 	roomsToCheck := make([]*GameRoom, 0)
@@ -91,48 +166,92 @@ func (h *Hub) removeClientFromRooms(client *Client) {
 	// Process rooms without holding hub lock
 	emptyRoomIDs := make([]string, 0)
 
+	spectated := false
 	for _, room := range roomsToCheck {
-		if room.RemovePlayer(client.ID) {
-			leaveMsg := Message{
-				Type: "player_left",
-				Payload: map[string]interface{}{
-					"roomId":   room.ID,
-					"playerId": client.ID,
-				},
-			}
-			room.Broadcast(leaveMsg.ToJSON())
+		if room.RemoveSpectator(client.ID) {
+			spectated = true
+		}
+		// Another tab of the same user carries on in the seat
+		if room.RemoveMirror(client.ID) || room.HandOverSeat(client.ID) {
+			continue
+		}
+		if room.HoldSeat(client, h.ReconnectGrace, func() { h.releaseSeat(room, client.ID) }) {
+			continue
+		}
+		if h.leaveRoom(room, client.ID) {
+			emptyRoomIDs = append(emptyRoomIDs, room.ID)
+		}
+	}
 
-			// Check if room is now empty
-			room.mu.RLock()
-			playerCount := len(room.Players)
-			room.mu.RUnlock()
+	h.deleteRooms(emptyRoomIDs)
+	if spectated && len(emptyRoomIDs) == 0 {
+		h.BroadcastRoomList()
+	}
+}
 
-			log.Printf("Room %s has %d players after removal", room.ID, playerCount)
+// releaseSeat removes a player whose held seat expired.
+func (h *Hub) releaseSeat(room *GameRoom, clientID string) {
+	if h.stopped.Load() {
+		return
+	}
+	if h.leaveRoom(room, clientID) {
+		h.deleteRooms([]string{room.ID})
+	}
+}
 
-			if playerCount == 0 {
-				emptyRoomIDs = append(emptyRoomIDs, room.ID)
-				log.Printf("Room %s is empty, marking for deletion", room.ID)
-			}
-		}
+// leaveRoom removes a client from one room and tells the others. Returns
+// true if the room is now empty.
+func (h *Hub) leaveRoom(room *GameRoom, clientID string) bool {
+	room.mu.RLock()
+	player, seated := room.Players[clientID]
+	room.mu.RUnlock()
+	if !seated || !room.RemovePlayer(clientID) {
+		return false
 	}
+	h.leftSeat(player.userID, room.ID)
+	h.saveGame(room)
 
-	// Remove empty rooms
-	if len(emptyRoomIDs) > 0 {
-		h.mu.Lock()
-		for _, roomID := range emptyRoomIDs {
-			if room, exists := h.rooms[roomID]; exists {
-				delete(h.rooms, roomID)
-				log.Printf("Room %s deleted (no players)", roomID)
+	// Check if room is now empty
+	room.mu.RLock()
+	playerCount := len(room.Players)
+	room.mu.RUnlock()
 
-				// Clean up from GameManager if needed
-				if room.GameManager != nil && room.GameID > 0 {
-					room.GameManager.RemoveGameRoom(room.GameID)
-				}
+	log.Printf("Room %s has %d players after removal", room.ID, playerCount)
+
+	if playerCount == 0 {
+		log.Printf("Room %s is empty, marking for deletion", room.ID)
+		return true
+	}
+	return false
+}
+
+// deleteRooms removes empty rooms from the hub.
+func (h *Hub) deleteRooms(roomIDs []string) {
+	if len(roomIDs) == 0 {
+		return
+	}
+	h.mu.Lock()
+	deleted := make([]string, 0, len(roomIDs))
+	for _, roomID := range roomIDs {
+		if room, exists := h.rooms[roomID]; exists {
+			delete(h.rooms, roomID)
+			deleted = append(deleted, roomID)
+			log.Printf("Room %s deleted (no players)", roomID)
+
+			// Clean up from GameManager if needed
+			if room.GameManager != nil && room.GameID > 0 {
+				room.GameManager.RemoveGameRoom(room.GameID)
 			}
 		}
-		h.mu.Unlock()
-		h.BroadcastRoomList()
 	}
+	h.mu.Unlock()
+	for _, roomID := range deleted {
+		h.forgetGame(roomID)
+		if h.Backplane != nil {
+			h.Backplane.release(roomID)
+		}
+	}
+	h.BroadcastRoomList()
 }
 
 func (h *Hub) AddRoom(room *GameRoom) {
@@ -153,6 +272,12 @@ type RoomSummary struct {
 	Status      string `json:"status"`
 	HasPassword bool   `json:"has_password"`
 	Difficulty  string `json:"difficulty"`
+	CustomGrid  bool   `json:"custom_grid"`
+
+	SpectatorCount  int  `json:"spectator_count"`
+	AllowSpectators bool `json:"allow_spectators"`
+
+	Filters *grid.GridFilters `json:"filters,omitempty"`
 }
 
 func (h *Hub) GetRoom(roomID string) (*GameRoom, bool) {
@@ -167,6 +292,19 @@ func (h *Hub) GetRoom(roomID string) (*GameRoom, bool) {
 	return room, exists
 }
 
+// lookupRoom finds a room by ID, falling back to its name.
+func (h *Hub) lookupRoom(roomID, roomName string) (*GameRoom, bool) {
+	if roomID != "" {
+		if room, exists := h.GetRoom(roomID); exists {
+			return room, true
+		}
+	}
+	if roomName != "" {
+		return h.GetRoomByName(roomName)
+	}
+	return nil, false
+}
+
 func (h *Hub) GetRoomByName(name string) (*GameRoom, bool) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -191,22 +329,120 @@ func (h *Hub) ListRooms() []RoomSummary {
 			Status:      room.State.Status,
 			HasPassword: room.Password != "",
 			Difficulty:  room.Difficulty,
+			CustomGrid:  room.CustomGridID != 0,
+			Filters:     roomFilters(room),
+
+			SpectatorCount:  room.SpectatorCount(),
+			AllowSpectators: room.AllowSpectators,
 		})
 	}
 	return rooms
 }
 
+// roomFilters returns the room's grid filters for the lobby, or nil if
+// it has none.
+func roomFilters(room *GameRoom) *grid.GridFilters {
+	if room.GridFilters.IsZero() {
+		return nil
+	}
+	f := room.GridFilters
+	return &f
+}
+
+// LobbyRooms lists the rooms on this instance and, with a Backplane,
+// on all the others.
+func (h *Hub) LobbyRooms() []RoomSummary {
+	rooms := h.ListRooms()
+	if h.Backplane != nil {
+		rooms = append(rooms, h.Backplane.remoteRooms()...)
+	}
+	return rooms
+}
+
+// BroadcastRoomList sends the lobby to every connection, on every
+// instance.
 func (h *Hub) BroadcastRoomList() {
 	go func() {
-		rooms := h.ListRooms()
-		msg := Message{
-			Type:    "rooms_list",
-			Payload: map[string]interface{}{"rooms": rooms},
+		if h.Backplane != nil {
+			h.Backplane.publishRooms()
 		}
-		h.broadcast <- msg.ToJSON()
+		h.broadcast <- encode(MsgRoomsList, RoomsListPayload{Rooms: h.LobbyRooms()})
+	}()
+}
+
+// broadcastRoomListLocally sends the lobby to this instance's
+// connections only, for when another instance's rooms changed.
+func (h *Hub) broadcastRoomListLocally() {
+	go func() {
+		h.broadcast <- encode(MsgRoomsList, RoomsListPayload{Rooms: h.LobbyRooms()})
 	}()
 }
 
+// broadcastLobby sends message to every connection, on every instance.
+func (h *Hub) broadcastLobby(message []byte) {
+	if h.Backplane != nil {
+		h.Backplane.publish(lobbyChannel, frame{Kind: frameLobby, Data: message})
+		return
+	}
+	h.broadcast <- message
+}
+
+// claimRoom reserves roomID for a room on this instance.
+func (h *Hub) claimRoom(roomID string) error {
+	if h.Backplane == nil {
+		return nil
+	}
+	return h.Backplane.claim(roomID)
+}
+
+// releaseRoom gives up the claim on a room that was never added.
+func (h *Hub) releaseRoom(roomID string) {
+	if h.Backplane != nil {
+		h.Backplane.release(roomID)
+	}
+}
+
+// takeSeat records userID's seat in roomID where every instance sees it.
+// Without a Backplane the hub's own rooms are the record.
+func (h *Hub) takeSeat(userID, roomID string) error {
+	if h.Backplane == nil {
+		return nil
+	}
+	return h.Backplane.takeSeat(userID, roomID)
+}
+
+// leftSeat undoes takeSeat.
+func (h *Hub) leftSeat(userID, roomID string) {
+	if h.Backplane != nil {
+		h.Backplane.leftSeat(userID, roomID)
+	}
+}
+
+// sendTo sends message to the local connection clientID, if it's still
+// open.
+func (h *Hub) sendTo(clientID string, message []byte) {
+	h.sessionsMu.RLock()
+	defer h.sessionsMu.RUnlock()
+	if client, ok := h.byID[clientID]; ok {
+		client.sendRaw(message)
+	}
+}
+
+// inRoom reports whether c has or mirrors a seat in a room here, or is
+// watching one.
+func (h *Hub) inRoom(c *Client) bool {
+	if room, ok := h.FindRoomByID(c.currentRoom); ok && room.SeatID(c.ID) != "" {
+		return true
+	}
+	if room, ok := h.FindRoomByID(c.spectating); ok {
+		room.mu.RLock()
+		_, watching := room.Spectators[c.ID]
+		room.mu.RUnlock()
+		return watching
+	}
+	return false
+}
+
 func (h *Hub) FindRoomByID(roomID string) (*GameRoom, bool) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -226,12 +462,8 @@ func (h *Hub) FindRoomByName(roomName string) (*GameRoom, bool) {
 }
 
 func (h *Hub) GetClient(clientID string) (*Client, bool) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	for client := range h.clients {
-		if client.ID == clientID {
-			return client, true
-		}
-	}
-	return nil, false
+	h.sessionsMu.RLock()
+	defer h.sessionsMu.RUnlock()
+	client, ok := h.byID[clientID]
+	return client, ok
 }
@@ -1,13 +1,26 @@
 package websocket
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"log"
+)
 
-type Message struct {
-	Type    string                 `json:"type"`
-	Payload map[string]interface{} `json:"payload"`
+// Envelope is every message on the wire: a type from protocol.go and its
+// payload.
+type Envelope struct {
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload"`
 }
 
-func (m *Message) ToJSON() []byte {
-	data, _ := json.Marshal(m)
+func (e Envelope) ToJSON() []byte {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("failed to encode %s message: %v", e.Type, err)
+	}
 	return data
 }
+
+// encode builds a message ready for Broadcast or a send channel.
+func encode(msgType string, payload interface{}) []byte {
+	return Envelope{Type: msgType, Payload: payload}.ToJSON()
+}
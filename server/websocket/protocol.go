@@ -0,0 +1,433 @@
+package websocket
+
+import (
+	"errors"
+	"trivia-server/criteria"
+	"trivia-server/game"
+	"trivia-server/grid"
+	"trivia-server/models"
+)
+
+// ProtocolVersion is announced in "connected". Bump it whenever a message
+// clients already understand changes shape.
+//
+// Version 2 made every payload field snake_case, moved error text into
+// the payload next to a stable code and folded custom_grid_invalid into
+// error.
+const ProtocolVersion = 2
+
+// ── Message types ──────────────────────────────────────────
+
+// Client → server
+const (
+	MsgCreateRoom   = "create_room"
+	MsgJoinRoom     = "join_room"
+	MsgSpectateRoom = "spectate_room"
+	MsgLeaveRoom    = "leave_room"
+	MsgListRooms    = "list_rooms"
+	MsgPlayerReady  = "player_ready"
+	MsgStartGame    = "start_game"
+	MsgMakeMove     = "make_move"
+	MsgRematch      = "rematch"
+	MsgChatMessage  = "chat_message" // also server → client
+	MsgMuteUser     = "mute_user"
+	MsgUnmuteUser   = "unmute_user"
+)
+
+// Server → client
+const (
+	MsgConnected          = "connected"
+	MsgError              = "error"
+	MsgRoomsList          = "rooms_list"
+	MsgRoomCreated        = "room_created"
+	MsgJoinedRoom         = "joined_room"
+	MsgPlayerJoined       = "player_joined"
+	MsgPlayerLeft         = "player_left"
+	MsgRoomReady          = "room_ready"
+	MsgRoomClosed         = "room_closed"
+	MsgGridGenerating     = "grid_generating"
+	MsgGameStarted        = "game_started"
+	MsgGameResumed        = "game_resumed"
+	MsgGameState          = "game_state"
+	MsgInvalidMove        = "invalid_move"
+	MsgCellOvertaken      = "cell_overtaken"
+	MsgOvertakeFailed     = "overtake_failed"
+	MsgTurnTimer          = "turn_timer"
+	MsgTurnTimeout        = "turn_timeout"
+	MsgGameEnded          = "game_ended"
+	MsgPlayerDisconnected = "player_disconnected"
+	MsgPlayerReconnected  = "player_reconnected"
+	MsgSpectating         = "spectating"
+	MsgSpectatorsChanged  = "spectators_changed"
+	MsgChatHistory        = "chat_history"
+	MsgMutedUsers         = "muted_users"
+	MsgAck                = "ack"
+	MsgServerShutdown     = "server_shutdown"
+	// MsgPlayerReady and MsgRematch are echoed to the room as well
+)
+
+// ── Error codes ────────────────────────────────────────────
+
+// ErrorCode identifies an error for clients. The message that comes
+// with it is for people and may change; codes don't.
+type ErrorCode string
+
+const (
+	CodeInvalidPayload     ErrorCode = "INVALID_PAYLOAD"
+	CodeUnknownType        ErrorCode = "UNKNOWN_TYPE"
+	CodeRoomNotFound       ErrorCode = "ROOM_NOT_FOUND"
+	CodeRoomFull           ErrorCode = "ROOM_FULL"
+	CodeRoomExists         ErrorCode = "ROOM_EXISTS"
+	CodeAlreadyInRoom      ErrorCode = "ALREADY_IN_ROOM"
+	CodeWrongPassword      ErrorCode = "WRONG_PASSWORD"
+	CodeNotInRoom          ErrorCode = "NOT_IN_ROOM"
+	CodeNotAPlayer         ErrorCode = "NOT_A_PLAYER"
+	CodeNotReady           ErrorCode = "NOT_READY"
+	CodeGameNotStarted     ErrorCode = "GAME_NOT_STARTED"
+	CodeGameNotActive      ErrorCode = "GAME_NOT_ACTIVE"
+	CodeGameInProgress     ErrorCode = "GAME_IN_PROGRESS"
+	CodeNotYourTurn        ErrorCode = "NOT_YOUR_TURN"
+	CodeInvalidCell        ErrorCode = "INVALID_CELL"
+	CodeGridBusy           ErrorCode = "GRID_BUSY"
+	CodeGridFailed         ErrorCode = "GRID_FAILED"
+	CodeFiltersTooStrict   ErrorCode = "FILTERS_TOO_STRICT"
+	CodeInvalidGrid        ErrorCode = "INVALID_GRID"
+	CodeShortCells         ErrorCode = "SHORT_CELLS"
+	CodeSpectatingDisabled ErrorCode = "SPECTATING_DISABLED"
+	CodeSpectatorIsPlayer  ErrorCode = "SPECTATOR_IS_PLAYER"
+	CodeMessageTooLong     ErrorCode = "MESSAGE_TOO_LONG"
+	CodeRateLimited        ErrorCode = "RATE_LIMITED"
+	CodeRequestPending     ErrorCode = "REQUEST_PENDING"
+	CodeShuttingDown       ErrorCode = "SHUTTING_DOWN"
+	CodeInternal           ErrorCode = "INTERNAL"
+)
+
+// ErrorCodes lists every code, for the schema.
+var ErrorCodes = []ErrorCode{
+	CodeInvalidPayload, CodeUnknownType, CodeRoomNotFound, CodeRoomFull,
+	CodeRoomExists, CodeAlreadyInRoom, CodeWrongPassword, CodeNotInRoom,
+	CodeNotAPlayer, CodeNotReady, CodeGameNotStarted, CodeGameNotActive,
+	CodeGameInProgress, CodeNotYourTurn, CodeInvalidCell, CodeGridBusy, CodeGridFailed,
+	CodeFiltersTooStrict, CodeInvalidGrid, CodeShortCells,
+	CodeSpectatingDisabled, CodeSpectatorIsPlayer, CodeMessageTooLong,
+	CodeRateLimited, CodeRequestPending, CodeShuttingDown,
+	CodeInternal,
+}
+
+// errorCode maps the errors handlers pass through to clients onto codes.
+func errorCode(err error) ErrorCode {
+	switch {
+	case errors.Is(err, ErrRoomNotFound):
+		return CodeRoomNotFound
+	case errors.Is(err, ErrRoomFull):
+		return CodeRoomFull
+	case errors.Is(err, ErrPlayerExists):
+		return CodeAlreadyInRoom
+	case errors.Is(err, ErrRoomIDTaken):
+		return CodeRoomExists
+	case errors.Is(err, ErrSeatedElsewhere):
+		return CodeAlreadyInRoom
+	case errors.Is(err, ErrShuttingDown):
+		return CodeShuttingDown
+	case errors.Is(err, ErrSpectatingDisabled):
+		return CodeSpectatingDisabled
+	case errors.Is(err, ErrSpectatorIsPlayer):
+		return CodeSpectatorIsPlayer
+	case errors.Is(err, ErrGameNotStarted):
+		return CodeGameNotStarted
+	case errors.Is(err, game.ErrGameNotActive):
+		return CodeGameNotActive
+	case errors.Is(err, game.ErrNotYourTurn):
+		return CodeNotYourTurn
+	case errors.Is(err, game.ErrInvalidPosition):
+		return CodeInvalidCell
+	case errors.Is(err, grid.ErrInvalidGrid):
+		return CodeInvalidGrid
+	case errors.Is(err, grid.ErrFiltersTooStrict):
+		return CodeFiltersTooStrict
+	}
+	return CodeInternal
+}
+
+// ── Client → server payloads ───────────────────────────────
+
+type CreateRoomPayload struct {
+	RoomID     string `json:"room_id,omitempty"`
+	RoomName   string `json:"room_name"`
+	Password   string `json:"password,omitempty"`
+	MaxPlayers int    `json:"max_players,omitempty"` // default 2
+	Difficulty string `json:"difficulty,omitempty"`  // "easy" | "regular" (default) | "hard"
+	Seed       int64  `json:"seed,omitempty"`        // fixed grid seed, e.g. for a daily puzzle
+	// Optional host-built grid for private rooms: 3 criteria IDs each,
+	// picked from GET /api/criteria
+	RowCriteria []int `json:"row_criteria,omitempty"`
+	ColCriteria []int `json:"col_criteria,omitempty"`
+	// Optional limits on the criteria generated grids may use
+	Filters grid.GridFilters `json:"filters"`
+	// Spectating is allowed unless this is false
+	AllowSpectators *bool `json:"allow_spectators,omitempty"`
+	// Seconds spectators see the board behind the players, up to MaxSpectatorDelay
+	SpectatorDelay int `json:"spectator_delay,omitempty"`
+}
+
+// JoinRoomPayload names a room by ID or, failing that, by name. Used by
+// join_room and spectate_room.
+type JoinRoomPayload struct {
+	RoomID   string `json:"room_id,omitempty"`
+	RoomName string `json:"room_name,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type MakeMovePayload struct {
+	RoomID         string `json:"room_id"`
+	Row            int    `json:"row"`
+	Col            int    `json:"col"`
+	Answer         string `json:"answer"`
+	PlayerID       int    `json:"player_id,omitempty"` // MLB ID picked from search, if any
+	PlayerName     string `json:"player_name,omitempty"`
+	PlayerHeadshot string `json:"player_headshot,omitempty"`
+}
+
+type ReadyPayload struct {
+	Ready bool `json:"ready"`
+}
+
+type SendChatPayload struct {
+	Channel string `json:"channel,omitempty"` // "room" (default) | "lobby"
+	Text    string `json:"text"`
+}
+
+type MutePayload struct {
+	UserID string `json:"user_id"`
+}
+
+// EmptyPayload is for messages that carry nothing.
+type EmptyPayload struct{}
+
+// ── Server → client payloads ───────────────────────────────
+
+type ConnectedPayload struct {
+	Message         string `json:"message"`
+	ClientID        string `json:"client_id"`
+	UserID          string `json:"user_id"`
+	ProtocolVersion int    `json:"protocol_version"`
+}
+
+type ErrorPayload struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"request_id,omitempty"` // of the command that failed
+	// Cells with too few answers, for SHORT_CELLS
+	ShortCells []grid.ShortCell `json:"short_cells,omitempty"`
+}
+
+type RoomsListPayload struct {
+	Rooms []RoomSummary `json:"rooms"`
+}
+
+// RoomPayload is for room_created and joined_room.
+type RoomPayload struct {
+	RoomID   string `json:"room_id"`
+	RoomName string `json:"room_name"`
+}
+
+// RoomEventPayload is for room_ready, room_closed, grid_generating,
+// turn_timeout and rematch.
+type RoomEventPayload struct {
+	RoomID string `json:"room_id"`
+}
+
+type PlayerJoinedPayload struct {
+	RoomID      string `json:"room_id"`
+	PlayerID    string `json:"player_id"` // connection ID
+	PlayerCount int    `json:"player_count"`
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+}
+
+type PlayerLeftPayload struct {
+	RoomID      string `json:"room_id"`
+	PlayerID    string `json:"player_id"`
+	UserID      string `json:"user_id"`
+	PlayerCount int    `json:"player_count"`
+}
+
+type PlayerReadyPayload struct {
+	PlayerID string `json:"player_id"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Ready    bool   `json:"ready"`
+}
+
+// GameStartedPayload is the grid a game is played on: game_started for
+// players (with player_index) and spectators (with spectator), and
+// game_resumed for a player catching up after a reconnect.
+type GameStartedPayload struct {
+	RoomID         string          `json:"room_id"`
+	RoomName       string          `json:"room_name,omitempty"`
+	PlayerIndex    *int            `json:"player_index,omitempty"`
+	Spectator      bool            `json:"spectator,omitempty"`
+	RowCriteria    []grid.Criteria `json:"row_criteria"`
+	ColCriteria    []grid.Criteria `json:"col_criteria"`
+	Difficulty     string          `json:"difficulty"`
+	RoomDifficulty string          `json:"room_difficulty"`
+	Seed           int64           `json:"seed,omitempty"`
+	DataVersion    string          `json:"data_version,omitempty"`
+}
+
+type InvalidMovePayload struct {
+	Message string `json:"message"`
+	Answer  string `json:"answer"`
+}
+
+type CellOvertakenPayload struct {
+	Row          int                     `json:"row"`
+	Col          int                     `json:"col"`
+	NewPlayer    string                  `json:"new_player"`
+	OldPlayer    string                  `json:"old_player"`
+	RarityScore  float64                 `json:"rarity_score"`
+	Explanations []*criteria.Explanation `json:"explanations,omitempty"`
+}
+
+type OvertakeFailedPayload struct {
+	Message        string  `json:"message"`
+	YourRarity     float64 `json:"your_rarity"`
+	ExistingRarity float64 `json:"existing_rarity"`
+}
+
+// TurnTimerPayload announces the turn countdown; duration 0 means the
+// room is untimed.
+type TurnTimerPayload struct {
+	Deadline int64 `json:"deadline,omitempty"` // Unix milliseconds
+	Duration int   `json:"duration"`           // seconds per turn
+}
+
+type GameEndedPayload struct {
+	RoomID         string            `json:"room_id"`
+	FinalState     *models.GameState `json:"final_state"`
+	IsDraw         bool              `json:"is_draw"`
+	WinnerID       int               `json:"winner_id,omitempty"`
+	WinnerUsername string            `json:"winner_username,omitempty"`
+	Summary        []AnswerSummary   `json:"summary"`
+}
+
+// AnswerSummary explains one answer left on the board at the end.
+type AnswerSummary struct {
+	Row          int                     `json:"row"`
+	Col          int                     `json:"col"`
+	PlayerName   string                  `json:"player_name"`
+	MlbID        int                     `json:"mlb_id"`
+	Explanations []*criteria.Explanation `json:"explanations,omitempty"`
+}
+
+type PlayerDisconnectedPayload struct {
+	RoomID       string `json:"room_id"`
+	PlayerID     string `json:"player_id"`
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	GraceSeconds int    `json:"grace_seconds"`
+}
+
+type PlayerReconnectedPayload struct {
+	RoomID           string `json:"room_id"`
+	PlayerID         string `json:"player_id"`
+	PreviousPlayerID string `json:"previous_player_id"`
+	UserID           string `json:"user_id"`
+	Username         string `json:"username"`
+}
+
+type SpectatingPayload struct {
+	RoomID         string `json:"room_id"`
+	RoomName       string `json:"room_name"`
+	SpectatorCount int    `json:"spectator_count"`
+	DelaySeconds   int    `json:"delay_seconds"`
+}
+
+type SpectatorsChangedPayload struct {
+	RoomID         string `json:"room_id"`
+	SpectatorCount int    `json:"spectator_count"`
+}
+
+type ChatHistoryPayload struct {
+	RoomID   string        `json:"room_id"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+type MutedUsersPayload struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// AckPayload answers a command sent with a request_id that didn't fail.
+// Retries of make_move and create_room with the same request_id get the
+// first attempt's ack or error back and aren't run again.
+type AckPayload struct {
+	RequestID string      `json:"request_id"`
+	Type      string      `json:"type"`             // the command's type
+	Result    interface{} `json:"result,omitempty"` // the room, for create_room and join_room
+}
+
+// ServerShutdownPayload warns that the server is going down for a
+// restart. Games still going then are saved and resumed after it.
+type ServerShutdownPayload struct {
+	Deadline int64 `json:"deadline"` // Unix milliseconds
+	Seconds  int   `json:"seconds"`  // until the deadline
+}
+
+// ── Catalog ────────────────────────────────────────────────
+
+// MessageSpec describes one message type for the schema.
+type MessageSpec struct {
+	Type        string
+	Payload     interface{} // zero value of the payload type
+	Description string
+}
+
+// ClientMessages are the messages clients send.
+var ClientMessages = []MessageSpec{
+	{MsgCreateRoom, CreateRoomPayload{}, "Create a room and take its first seat."},
+	{MsgJoinRoom, JoinRoomPayload{}, "Take a seat in a room."},
+	{MsgSpectateRoom, JoinRoomPayload{}, "Watch a room read-only."},
+	{MsgLeaveRoom, EmptyPayload{}, "Leave the room you're in or watching."},
+	{MsgListRooms, EmptyPayload{}, "Ask for rooms_list."},
+	{MsgPlayerReady, ReadyPayload{}, "Mark yourself ready or not."},
+	{MsgStartGame, EmptyPayload{}, "Start the game once everyone is ready."},
+	{MsgMakeMove, MakeMovePayload{}, "Answer a cell on your turn."},
+	{MsgRematch, EmptyPayload{}, "Go back to the ready screen after a game."},
+	{MsgChatMessage, SendChatPayload{}, "Say something in your room or the lobby."},
+	{MsgMuteUser, MutePayload{}, "Hide a user's chat from you."},
+	{MsgUnmuteUser, MutePayload{}, "Show a muted user's chat again."},
+}
+
+// ServerMessages are the messages the server sends.
+var ServerMessages = []MessageSpec{
+	{MsgConnected, ConnectedPayload{}, "First message on every connection."},
+	{MsgError, ErrorPayload{}, "A request failed."},
+	{MsgRoomsList, RoomsListPayload{}, "The lobby's rooms."},
+	{MsgRoomCreated, RoomPayload{}, "Your room was created."},
+	{MsgJoinedRoom, RoomPayload{}, "You took a seat."},
+	{MsgPlayerJoined, PlayerJoinedPayload{}, "Someone is in the room."},
+	{MsgPlayerLeft, PlayerLeftPayload{}, "Someone left the room."},
+	{MsgPlayerReady, PlayerReadyPayload{}, "A player's ready state changed."},
+	{MsgRoomReady, RoomEventPayload{}, "Every seat is ready."},
+	{MsgRoomClosed, RoomEventPayload{}, "The room is gone."},
+	{MsgGridGenerating, RoomEventPayload{}, "A grid is being built for the game."},
+	{MsgGameStarted, GameStartedPayload{}, "A game started on this grid."},
+	{MsgGameResumed, GameStartedPayload{}, "You're back in the game you dropped out of."},
+	{MsgGameState, models.GameState{}, "The whole game after every change."},
+	{MsgInvalidMove, InvalidMovePayload{}, "Your answer was wrong; you lost the turn."},
+	{MsgCellOvertaken, CellOvertakenPayload{}, "A rarer answer took over a cell."},
+	{MsgOvertakeFailed, OvertakeFailedPayload{}, "Your answer wasn't rarer than the one in the cell."},
+	{MsgTurnTimer, TurnTimerPayload{}, "The countdown for the current turn."},
+	{MsgTurnTimeout, RoomEventPayload{}, "A turn ran out of time and was skipped."},
+	{MsgGameEnded, GameEndedPayload{}, "The game is over."},
+	{MsgRematch, RoomEventPayload{}, "The room is going back to the ready screen."},
+	{MsgPlayerDisconnected, PlayerDisconnectedPayload{}, "A player dropped; their seat is held."},
+	{MsgPlayerReconnected, PlayerReconnectedPayload{}, "A dropped player is back."},
+	{MsgSpectating, SpectatingPayload{}, "You're watching a room."},
+	{MsgSpectatorsChanged, SpectatorsChangedPayload{}, "Someone started or stopped watching."},
+	{MsgChatMessage, ChatMessage{}, "A chat message."},
+	{MsgChatHistory, ChatHistoryPayload{}, "The room's recent chat."},
+	{MsgMutedUsers, MutedUsersPayload{}, "Who you've muted."},
+	{MsgAck, AckPayload{}, "A command sent with a request_id was accepted."},
+	{MsgServerShutdown, ServerShutdownPayload{}, "The server is restarting; connections close at the deadline."},
+}
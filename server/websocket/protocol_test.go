@@ -0,0 +1,163 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"trivia-server/game"
+	"trivia-server/grid"
+)
+
+// schemaJSON is Schema() the way clients fetch it.
+func schemaJSON(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s map[string]interface{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// refs collects every $ref under v.
+func refs(v interface{}, found map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if k == "$ref" {
+				found[child.(string)] = true
+			}
+			refs(child, found)
+		}
+	case []interface{}:
+		for _, child := range v {
+			refs(child, found)
+		}
+	}
+}
+
+func TestSchemaRefsResolve(t *testing.T) {
+	s := schemaJSON(t)
+	defs := s["$defs"].(map[string]interface{})
+
+	found := map[string]bool{}
+	refs(s, found)
+	if len(found) == 0 {
+		t.Fatal("schema has no $refs")
+	}
+	for ref := range found {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		if def, ok := defs[name]; !ok || def == nil {
+			t.Errorf("%s doesn't resolve", ref)
+		}
+	}
+	if s["version"].(float64) != ProtocolVersion {
+		t.Errorf("version = %v, want %d", s["version"], ProtocolVersion)
+	}
+}
+
+func TestSchemaErrorPayload(t *testing.T) {
+	def := schemaJSON(t)["$defs"].(map[string]interface{})["ErrorPayload"].(map[string]interface{})
+	props := def["properties"].(map[string]interface{})
+
+	// omitempty fields are optional, the rest required
+	required := fmt.Sprint(def["required"])
+	if required != "[code message]" {
+		t.Errorf("required = %s, want [code message]", required)
+	}
+	codes := props["code"].(map[string]interface{})["enum"].([]interface{})
+	if len(codes) != len(ErrorCodes) {
+		t.Errorf("code enum has %d codes, want %d", len(codes), len(ErrorCodes))
+	}
+	if def["additionalProperties"] != false {
+		t.Error("ErrorPayload allows properties it doesn't have")
+	}
+
+	// What the server sends only uses properties the schema lists
+	var sent map[string]interface{}
+	data := encode(MsgError, ErrorPayload{Code: CodeShortCells, Message: "m", RequestID: "r", ShortCells: []grid.ShortCell{{}}})
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatal(err)
+	}
+	for key := range sent["payload"].(map[string]interface{}) {
+		if _, ok := props[key]; !ok {
+			t.Errorf("sent property %q isn't in the schema", key)
+		}
+	}
+}
+
+func TestMessageCatalog(t *testing.T) {
+	for side, specs := range map[string][]MessageSpec{"client": ClientMessages, "server": ServerMessages} {
+		seen := map[string]bool{}
+		for _, spec := range specs {
+			if seen[spec.Type] {
+				t.Errorf("%s message %s listed twice", side, spec.Type)
+			}
+			seen[spec.Type] = true
+			if spec.Description == "" || spec.Payload == nil {
+				t.Errorf("%s message %s has no description or payload", side, spec.Type)
+			}
+		}
+	}
+
+	codes := map[ErrorCode]bool{}
+	for _, code := range ErrorCodes {
+		if codes[code] {
+			t.Errorf("error code %s listed twice", code)
+		}
+		codes[code] = true
+	}
+}
+
+// Every client message in the catalog is handled; anything else is
+// UNKNOWN_TYPE.
+func TestDispatchKnowsTheCatalog(t *testing.T) {
+	h := NewHub(nil)
+	for _, spec := range ClientMessages {
+		c := testClient(h, "1")
+		// Invalid payloads are refused before anything touches the database
+		c.dispatch(wsMessage{Type: spec.Type, Payload: json.RawMessage(`"not an object"`)})
+		for _, msg := range drain(c) {
+			if strings.Contains(msg, string(CodeUnknownType)) {
+				t.Errorf("%s is in the catalog but not handled", spec.Type)
+			}
+		}
+	}
+
+	c := testClient(h, "1")
+	c.dispatch(wsMessage{Type: "teleport"})
+	if e := nextError(t, c); e.Code != CodeUnknownType {
+		t.Errorf("unknown type = %s, want UNKNOWN_TYPE", e.Code)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCode
+	}{
+		{ErrRoomNotFound, CodeRoomNotFound},
+		{ErrRoomFull, CodeRoomFull},
+		{ErrPlayerExists, CodeAlreadyInRoom},
+		{ErrSeatedElsewhere, CodeAlreadyInRoom},
+		{ErrRoomIDTaken, CodeRoomExists},
+		{ErrShuttingDown, CodeShuttingDown},
+		{ErrSpectatingDisabled, CodeSpectatingDisabled},
+		{ErrGameNotStarted, CodeGameNotStarted},
+		{game.ErrNotYourTurn, CodeNotYourTurn},
+		{fmt.Errorf("move: %w", game.ErrInvalidPosition), CodeInvalidCell},
+		{&grid.ShortCellsError{}, CodeInvalidGrid},
+		{grid.ErrFiltersTooStrict, CodeFiltersTooStrict},
+		{errors.New("disk on fire"), CodeInternal},
+	}
+	for _, tt := range tests {
+		if got := errorCode(tt.err); got != tt.want {
+			t.Errorf("errorCode(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package websocket
+
+import (
+	"expvar"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// rateLimitMetrics counts what the limits turned away, served with the
+// rest of expvar. Keys: limited.connection, limited.user, limited.<type>,
+// warned, throttled and disconnected.
+var rateLimitMetrics = expvar.NewMap("websocket_rate_limits")
+
+// RateLimit is a token bucket: up to Burst messages at once, refilled at
+// Rate a second. A zero Rate means no limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Limits protects the hub from clients that send too much. Every message
+// a client sends must fit the bucket for its connection, the one for its
+// user across all connections, and, if its type has one, the user's
+// bucket for that type.
+//
+// Each message that doesn't fit is a violation. Violations inside
+// ViolationWindow escalate: the first ones are answered with a
+// RATE_LIMITED error, from ThrottleAfter on the connection stops being
+// read for ThrottleFor, and at DisconnectAfter it is closed with 1008
+// (policy violation).
+type Limits struct {
+	Connection RateLimit
+	User       RateLimit
+	PerType    map[string]RateLimit
+
+	ViolationWindow time.Duration
+	ThrottleAfter   int
+	ThrottleFor     time.Duration
+	DisconnectAfter int
+}
+
+// DefaultLimits are generous enough for any real player.
+func DefaultLimits() Limits {
+	return Limits{
+		Connection: RateLimit{Rate: 10, Burst: 20},
+		User:       RateLimit{Rate: 20, Burst: 40},
+		PerType: map[string]RateLimit{
+			MsgCreateRoom:   {Rate: 0.2, Burst: 3}, // one every 5s
+			MsgJoinRoom:     {Rate: 1, Burst: 5},
+			MsgSpectateRoom: {Rate: 1, Burst: 5},
+			MsgMakeMove:     {Rate: 2, Burst: 4},
+			MsgListRooms:    {Rate: 1, Burst: 5},
+			MsgChatMessage:  {Rate: 0.5, Burst: 5}, // one every 2s
+		},
+
+		ViolationWindow: time.Minute,
+		ThrottleAfter:   5,
+		ThrottleFor:     5 * time.Second,
+		DisconnectAfter: 20,
+	}
+}
+
+// ParseTypeLimits reads per-type limits written as
+// "create_room=0.2:3,make_move=2:4" (type=rate:burst).
+func ParseTypeLimits(s string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		msgType, spec, ok := strings.Cut(entry, "=")
+		rate, burst, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid limit %q: want type=rate:burst", entry)
+		}
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r < 0 {
+			return nil, fmt.Errorf("invalid rate in %q", entry)
+		}
+		b, err := strconv.Atoi(burst)
+		if err != nil || b < 1 {
+			return nil, fmt.Errorf("invalid burst in %q", entry)
+		}
+		limits[strings.TrimSpace(msgType)] = RateLimit{Rate: r, Burst: b}
+	}
+	return limits, nil
+}
+
+// bucket is one token bucket's state.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// ready refills the bucket and reports whether it has a token to spend.
+func (b *bucket) ready(l RateLimit, now time.Time) bool {
+	if l.Rate <= 0 {
+		return true
+	}
+	if b.last.IsZero() {
+		b.tokens = float64(l.Burst)
+	} else {
+		b.tokens = min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	}
+	b.last = now
+	return b.tokens >= 1
+}
+
+// spend takes the token ready found.
+func (b *bucket) spend(l RateLimit) {
+	if l.Rate > 0 {
+		b.tokens--
+	}
+}
+
+// userLimiter holds the buckets shared by all of a user's connections.
+type userLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket // user ID, or user ID + type
+	lastSweep time.Time
+}
+
+// userCheck is one of a user's buckets a message must fit.
+type userCheck struct {
+	key   string
+	limit RateLimit
+	scope string // reported when the bucket is empty
+}
+
+func newUserLimiter() *userLimiter {
+	return &userLimiter{buckets: make(map[string]*bucket)}
+}
+
+// takeAll spends a token from every bucket in checks, or from none of
+// them if one is empty. It returns the first empty one.
+func (l *userLimiter) takeAll(now time.Time, checks ...userCheck) *userCheck {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Buckets idle long enough to have refilled are the same as new ones
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > time.Minute {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	buckets := make([]*bucket, len(checks))
+	for i, chk := range checks {
+		b := l.buckets[chk.key]
+		if b == nil {
+			b = &bucket{}
+			l.buckets[chk.key] = b
+		}
+		if !b.ready(chk.limit, now) {
+			return &checks[i]
+		}
+		buckets[i] = b
+	}
+	for i, b := range buckets {
+		b.spend(checks[i].limit)
+	}
+	return nil
+}
+
+// abuseState is a connection's bucket and record of violations. Only
+// readPump touches it.
+type abuseState struct {
+	conn          bucket
+	violations    int
+	lastViolation time.Time
+	throttleUntil time.Time
+}
+
+// admit checks one incoming message against the limits. It returns false
+// if the message must be dropped, and kick if the connection has to be
+// closed as well.
+func (c *Client) admit(msg wsMessage, now time.Time) (ok, kick bool) {
+	lim := c.hub.Limits
+
+	// A message that doesn't fit one bucket spends from none of them,
+	// so a user held back by one limit isn't also drained of the others
+	scope := ""
+	if !c.abuse.conn.ready(lim.Connection, now) {
+		scope = "connection"
+	} else {
+		checks := []userCheck{{key: c.userID, limit: lim.User, scope: "user"}}
+		if typeLimit, limited := lim.PerType[msg.Type]; limited {
+			checks = append(checks, userCheck{key: c.userID + "\x00" + msg.Type, limit: typeLimit, scope: msg.Type})
+		}
+		if empty := c.hub.userLimits.takeAll(now, checks...); empty != nil {
+			scope = empty.scope
+		} else {
+			c.abuse.conn.spend(lim.Connection)
+		}
+	}
+	if scope == "" {
+		return true, false
+	}
+	return false, c.violate(scope, msg, now)
+}
+
+// violate records a rejected message and escalates. Returns true once
+// the connection has to go.
+func (c *Client) violate(scope string, msg wsMessage, now time.Time) bool {
+	lim := c.hub.Limits
+	rateLimitMetrics.Add("limited."+scope, 1)
+
+	if now.Sub(c.abuse.lastViolation) > lim.ViolationWindow {
+		c.abuse.violations = 0
+	}
+	c.abuse.violations++
+	c.abuse.lastViolation = now
+
+	switch {
+	case lim.DisconnectAfter > 0 && c.abuse.violations >= lim.DisconnectAfter:
+		rateLimitMetrics.Add("disconnected", 1)
+		log.Printf("Disconnecting client %s (user %s): rate limit exceeded %d times", c.ID, c.userID, c.abuse.violations)
+		return true
+	case lim.ThrottleAfter > 0 && c.abuse.violations >= lim.ThrottleAfter:
+		rateLimitMetrics.Add("throttled", 1)
+		c.abuse.throttleUntil = now.Add(lim.ThrottleFor)
+	default:
+		rateLimitMetrics.Add("warned", 1)
+		c.sendMessage(MsgError, ErrorPayload{
+			Code:      CodeRateLimited,
+			Message:   "you're sending messages too fast — slow down",
+			RequestID: msg.RequestID,
+		})
+	}
+	return false
+}
+
+// throttle holds up reading while the connection is being throttled.
+func (c *Client) throttle() {
+	if wait := time.Until(c.abuse.throttleUntil); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// kick closes the connection for breaking the limits.
+func (c *Client) kick() {
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "rate limit exceeded")
+	_ = c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+}
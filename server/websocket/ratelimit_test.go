@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTakeAll(t *testing.T) {
+	limit := RateLimit{Rate: 2, Burst: 3}
+	now := time.Unix(1700000000, 0)
+	l := newUserLimiter()
+	take := func(at time.Time) bool {
+		return l.takeAll(at, userCheck{key: "1", limit: limit}) == nil
+	}
+
+	for i := 0; i < limit.Burst; i++ {
+		if !take(now) {
+			t.Fatalf("token %d of a burst of %d refused", i+1, limit.Burst)
+		}
+	}
+	if take(now) {
+		t.Error("token past the burst allowed")
+	}
+	if !take(now.Add(500 * time.Millisecond)) {
+		t.Error("no token after half a second at 2/s")
+	}
+	if take(now.Add(500 * time.Millisecond)) {
+		t.Error("refill gave more than one token")
+	}
+	// Refilling stops at the burst
+	later := now.Add(30 * time.Second)
+	for i := 0; i < limit.Burst; i++ {
+		take(later)
+	}
+	if take(later) {
+		t.Error("bucket refilled past its burst")
+	}
+
+	// An empty bucket is returned, and the others aren't spent
+	full := userCheck{key: "2", limit: RateLimit{Rate: 1, Burst: 1}, scope: "user"}
+	empty := userCheck{key: "1", limit: limit, scope: "chat"}
+	if got := l.takeAll(later, full, empty); got == nil || got.scope != "chat" {
+		t.Fatalf("takeAll = %v, want the chat bucket", got)
+	}
+	if l.takeAll(later, full) != nil {
+		t.Error("a refused takeAll spent the other bucket")
+	}
+
+	for i := 0; i < 100; i++ {
+		if l.takeAll(now, userCheck{key: "3"}) != nil {
+			t.Fatal("a zero rate limited")
+		}
+	}
+}
+
+func TestParseTypeLimits(t *testing.T) {
+	got, err := ParseTypeLimits(" create_room=0.2:3, make_move=2:4,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]RateLimit{
+		MsgCreateRoom: {Rate: 0.2, Burst: 3},
+		MsgMakeMove:   {Rate: 2, Burst: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTypeLimits = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"make_move", "make_move=2", "make_move=x:4", "make_move=-1:4", "make_move=2:0", "make_move=2:x"} {
+		if _, err := ParseTypeLimits(bad); err == nil {
+			t.Errorf("ParseTypeLimits(%q) accepted", bad)
+		}
+	}
+}
+
+func limitedHub(lim Limits) *Hub {
+	h := NewHub(nil)
+	h.Limits = lim
+	return h
+}
+
+func TestAdmitSpendsNothingWhenRefused(t *testing.T) {
+	h := limitedHub(Limits{
+		Connection: RateLimit{Rate: 1, Burst: 5},
+		User:       RateLimit{Rate: 1, Burst: 5},
+		PerType:    map[string]RateLimit{MsgChatMessage: {Rate: 1, Burst: 1}},
+	})
+	c := testClient(h, "1")
+	now := time.Unix(1700000000, 0)
+	chat := wsMessage{Type: MsgChatMessage}
+
+	if ok, _ := c.admit(chat, now); !ok {
+		t.Fatal("first chat refused")
+	}
+	for i := 0; i < 3; i++ {
+		if ok, _ := c.admit(chat, now); ok {
+			t.Fatal("chat past its per-type burst allowed")
+		}
+	}
+	// The refused chats didn't spend the connection or user tokens
+	for i := 0; i < 4; i++ {
+		if ok, _ := c.admit(wsMessage{Type: MsgListRooms}, now); !ok {
+			t.Fatalf("message %d refused after refused chats drained the shared buckets", i+1)
+		}
+	}
+	if ok, _ := c.admit(wsMessage{Type: MsgListRooms}, now); ok {
+		t.Error("message past the connection burst allowed")
+	}
+}
+
+func TestAdmitEscalation(t *testing.T) {
+	h := limitedHub(Limits{
+		Connection:      RateLimit{Rate: 1, Burst: 1},
+		ViolationWindow: time.Minute,
+		ThrottleAfter:   2,
+		ThrottleFor:     5 * time.Second,
+		DisconnectAfter: 3,
+	})
+	c := testClient(h, "1")
+	now := time.Unix(1700000000, 0)
+	msg := wsMessage{Type: MsgListRooms, RequestID: "r"}
+
+	c.admit(msg, now)
+
+	// First violation: a warning
+	if ok, kick := c.admit(msg, now); ok || kick {
+		t.Fatalf("first violation = %v, %v; want refused", ok, kick)
+	}
+	if e := nextError(t, c); e.Code != CodeRateLimited || e.RequestID != "r" {
+		t.Errorf("warning = %+v, want RATE_LIMITED", e)
+	}
+
+	// Second: throttled, without another error
+	if ok, kick := c.admit(msg, now); ok || kick {
+		t.Fatalf("second violation = %v, %v; want refused", ok, kick)
+	}
+	if !c.abuse.throttleUntil.Equal(now.Add(5 * time.Second)) {
+		t.Errorf("throttled until %v, want 5s from now", c.abuse.throttleUntil)
+	}
+	if len(c.send) != 0 {
+		t.Error("throttling sent a message")
+	}
+
+	// Third: disconnected
+	if ok, kick := c.admit(msg, now); ok || !kick {
+		t.Errorf("third violation = %v, %v; want kicked", ok, kick)
+	}
+}
+
+func TestAdmitViolationWindow(t *testing.T) {
+	h := limitedHub(Limits{
+		Connection:      RateLimit{Rate: 1, Burst: 1},
+		ViolationWindow: time.Minute,
+		DisconnectAfter: 2,
+	})
+	c := testClient(h, "1")
+	now := time.Unix(1700000000, 0)
+	msg := wsMessage{Type: MsgListRooms}
+
+	c.admit(msg, now)
+	c.admit(msg, now)
+	// Violations older than the window are forgotten
+	later := now.Add(2 * time.Minute)
+	c.admit(msg, later)
+	if _, kick := c.admit(msg, later); kick {
+		t.Error("kicked for violations outside the window")
+	}
+}
@@ -0,0 +1,139 @@
+package websocket
+
+import (
+	"log"
+	"time"
+	"trivia-server/grid"
+	"trivia-server/models"
+)
+
+// DefaultReconnectGrace is how long a dropped player's seat is held when
+// the hub isn't configured otherwise.
+const DefaultReconnectGrace = 60 * time.Second
+
+// awaySeat is a seat whose connection dropped mid-game.
+type awaySeat struct {
+	userID string
+	timer  *time.Timer // releases the seat when the grace period is up
+}
+
+// HoldSeat keeps client's seat for grace after its connection drops in
+// the middle of a game, so the same user can pick it back up with
+// ClaimSeat. The turn timer keeps running meanwhile — a player who is
+// away just has their turns time out. onExpire runs if nobody resumes
+// the seat in time.
+//
+// Returns false, holding nothing, when the client has no seat here or
+// no game is in progress; the caller should remove the player instead.
+func (r *GameRoom) HoldSeat(client *Client, grace time.Duration, onExpire func()) bool {
+	r.mu.Lock()
+	if _, seated := r.Players[client.ID]; !seated {
+		r.mu.Unlock()
+		return false
+	}
+	if _, held := r.away[client.ID]; held {
+		r.mu.Unlock()
+		return true
+	}
+	if grace <= 0 || r.GameModel == nil || r.GameModel.Game.Status != models.GameStatusActive {
+		r.mu.Unlock()
+		return false
+	}
+	r.away[client.ID] = &awaySeat{
+		userID: client.userID,
+		timer:  time.AfterFunc(grace, func() { r.expireSeat(client.ID, onExpire) }),
+	}
+	r.mu.Unlock() // release BEFORE broadcasting
+
+	log.Printf("Holding seat of user %s in room %s for %s", client.userID, r.ID, grace)
+	r.Broadcast(encode(MsgPlayerDisconnected, PlayerDisconnectedPayload{
+		RoomID:       r.ID,
+		PlayerID:     client.ID,
+		UserID:       client.userID,
+		Username:     client.username,
+		GraceSeconds: int(grace.Seconds()),
+	}))
+	return true
+}
+
+// expireSeat gives up a held seat unless it was resumed first.
+func (r *GameRoom) expireSeat(clientID string, onExpire func()) {
+	r.mu.Lock()
+	_, held := r.away[clientID]
+	delete(r.away, clientID)
+	r.mu.Unlock()
+
+	if held {
+		log.Printf("Seat of client %s in room %s expired", clientID, r.ID)
+		onExpire()
+	}
+}
+
+// catchUp brings a connection that just took or mirrors a seat in room
+// up to date: before a game, the room, its players and who is ready; in
+// a game, its player index and the grid, the other players, the full
+// game state and the turn timer. Then the room's chat.
+func (h *Hub) catchUp(client *Client, room *GameRoom, index int) {
+	room.mu.RLock()
+	inGame := room.GameModel != nil
+	room.mu.RUnlock()
+
+	if !inGame {
+		client.sendMessage(MsgJoinedRoom, RoomPayload{RoomID: room.ID, RoomName: room.Name})
+		client.sendOtherPlayers(room)
+		client.sendReadyStates(room)
+		client.sendChatHistory(room)
+		return
+	}
+
+	payload := GameStartedPayload{RoomID: room.ID, RoomDifficulty: room.Difficulty}
+	if gt := room.currentGrid(h); gt != nil {
+		payload = gridPayload(room, gt)
+	}
+	payload.PlayerIndex = &index
+	payload.RoomName = room.Name
+	client.sendMessage(MsgGameResumed, payload)
+
+	client.sendOtherPlayers(room)
+
+	room.mu.RLock()
+	client.sendMessage(MsgGameState, room.GameModel)
+	room.mu.RUnlock()
+
+	client.sendMessage(MsgTurnTimer, room.turnTimerPayload())
+	client.sendChatHistory(room)
+}
+
+// currentGrid returns the template the room is playing, loading it if
+// the room doesn't have it to hand.
+func (r *GameRoom) currentGrid(h *Hub) *grid.GridTemplate {
+	r.mu.RLock()
+	gt, id := r.gridTemplate, r.GridTemplateID
+	r.mu.RUnlock()
+	if gt != nil || id == 0 {
+		return gt
+	}
+
+	t, err := grid.NewService(h.DB).GetTemplate(id)
+	if err != nil {
+		log.Printf("Failed to load grid %d for room %s: %v", id, r.ID, err)
+		return nil
+	}
+	return &t.GridTemplate
+}
+
+// turnTimerPayload describes the running turn timer the way StartTurnTimer
+// announces it, so a resuming player's countdown matches everyone else's.
+func (r *GameRoom) turnTimerPayload() TurnTimerPayload {
+	r.turnTimerMu.Lock()
+	deadline := r.turnDeadline
+	r.turnTimerMu.Unlock()
+
+	if deadline.IsZero() {
+		return TurnTimerPayload{}
+	}
+	return TurnTimerPayload{
+		Deadline: deadline.UnixMilli(),
+		Duration: int(turnDurationForDifficulty(r.Difficulty).Seconds()),
+	}
+}
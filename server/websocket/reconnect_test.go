@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+	"trivia-server/models"
+)
+
+// playingRoom is a two-player room with a game in progress.
+func playingRoom(t *testing.T, h *Hub) (*GameRoom, *Client, *Client) {
+	t.Helper()
+	room := NewGameRoom("room-1", "Room", "", "1")
+	alice, bob := testClient(h, "1"), testClient(h, "2")
+	for _, c := range []*Client{alice, bob} {
+		if err := room.AddPlayer(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	room.GameModel = &models.GameState{Game: models.Game{Status: models.GameStatusActive}}
+	return room, alice, bob
+}
+
+func TestHoldSeat(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+
+	if room.HoldSeat(testClient(h, "3"), time.Minute, func() {}) {
+		t.Error("held a seat for a client without one")
+	}
+	if room.HoldSeat(alice, 0, func() {}) {
+		t.Error("held a seat without a grace period")
+	}
+
+	expired := make(chan struct{})
+	if !room.HoldSeat(alice, 10*time.Millisecond, func() { close(expired) }) {
+		t.Fatal("seat not held mid-game")
+	}
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("held seat never expired")
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if len(room.away) != 0 {
+		t.Error("expired seat still held")
+	}
+}
+
+func TestHoldSeatBeforeGame(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+	room.GameModel = nil
+	if room.HoldSeat(alice, time.Minute, func() {}) {
+		t.Error("held a seat with no game in progress")
+	}
+}
+
+func TestClaimSeatResumesHeldSeat(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+
+	expired := make(chan struct{}, 1)
+	room.HoldSeat(alice, 20*time.Millisecond, func() { expired <- struct{}{} })
+
+	back := testClient(h, "1")
+	back.ID = "client-1-again"
+	// A held seat is resumed even without takeover
+	index, mirror, ok := room.ClaimSeat(back, false)
+	if !ok || mirror || index != 0 {
+		t.Fatalf("ClaimSeat = %d, %v, %v; want seat 0", index, mirror, ok)
+	}
+	if room.SeatID(back.ID) != back.ID || room.SeatID(alice.ID) != "" {
+		t.Error("seat didn't move to the new connection")
+	}
+	if order := room.GetOrderedClients(); order[0] != back {
+		t.Error("resumed seat lost its place in the turn order")
+	}
+
+	select {
+	case <-expired:
+		t.Error("resumed seat expired anyway")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, _, ok := room.ClaimSeat(testClient(h, "3"), true); ok {
+		t.Error("claimed a seat for a user without one")
+	}
+}
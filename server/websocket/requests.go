@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// MaxRequestIDLength caps the request_id a client may attach.
+	MaxRequestIDLength = 64
+	// requestTTL is how long the outcome of a make_move or create_room is
+	// kept to answer a retry of the same request_id.
+	requestTTL = 2 * time.Minute
+	// requestPendingTTL is how long a request_id stays claimed by an
+	// attempt that never finished, e.g. one whose handler panicked.
+	requestPendingTTL = 30 * time.Second
+	// requestSweepInterval is how often expired outcomes are dropped.
+	requestSweepInterval = time.Minute
+)
+
+// idempotentTypes are the commands a retried request_id must never run
+// twice: a double-tap or a resend after a reconnect gets the first
+// outcome back instead.
+var idempotentTypes = map[string]bool{
+	MsgCreateRoom: true,
+	MsgMakeMove:   true,
+}
+
+// request is the command a client is handling, so replies can be
+// correlated with it.
+type request struct {
+	id       string
+	msgType  string
+	result   interface{} // optional, sent back in the ack
+	response []byte      // the ack or error that answered it
+}
+
+// requestCache remembers recent outcomes of idempotent commands per user,
+// across all of their connections.
+type requestCache struct {
+	mu        sync.Mutex
+	entries   map[string]*requestEntry // user ID + type + request ID
+	lastSweep time.Time
+}
+
+type requestEntry struct {
+	response []byte    // nil while the first attempt is still running
+	expires  time.Time // after which the key can be claimed again
+}
+
+func newRequestCache() *requestCache {
+	return &requestCache{entries: make(map[string]*requestEntry)}
+}
+
+// begin claims key for a new attempt. If it was already claimed, it
+// returns the earlier attempt's response instead, which is nil while
+// that attempt is still running.
+func (rc *requestCache) begin(key string, now time.Time) (response []byte, claimed bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if now.Sub(rc.lastSweep) > requestSweepInterval {
+		for k, e := range rc.entries {
+			if now.After(e.expires) {
+				delete(rc.entries, k)
+			}
+		}
+		rc.lastSweep = now
+	}
+	if e, ok := rc.entries[key]; ok && !now.After(e.expires) {
+		return e.response, false
+	}
+	rc.entries[key] = &requestEntry{expires: now.Add(requestPendingTTL)}
+	return nil, true
+}
+
+// finish records the response that answered key.
+func (rc *requestCache) finish(key string, response []byte, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries[key] = &requestEntry{response: response, expires: now.Add(requestTTL)}
+}
+
+// handleMessage runs one command, echoing its request_id, if any, in the
+// ack or error that answers it. An ack means the command was accepted;
+// its effects arrive as the usual messages.
+//
+// With a Backplane, commands for a room another instance owns go to
+// that instance instead.
+func (c *Client) handleMessage(msg wsMessage) {
+	c.handling.Lock()
+	defer c.handling.Unlock()
+	if c.closing || c.hub.stopped.Load() {
+		// Nothing may change the rooms once Shutdown saved them
+		return
+	}
+	if b := c.hub.Backplane; b != nil && c.home == "" && b.route(c, msg) {
+		return
+	}
+
+	if len(msg.RequestID) > MaxRequestIDLength {
+		c.sendError(CodeInvalidPayload, "request_id is too long")
+		return
+	}
+	if msg.RequestID == "" {
+		c.dispatch(msg)
+		return
+	}
+
+	c.req = &request{id: msg.RequestID, msgType: msg.Type}
+	defer func() { c.req = nil }()
+
+	var key string
+	if idempotentTypes[msg.Type] {
+		key = c.userID + "\x00" + msg.Type + "\x00" + msg.RequestID
+		response, claimed := c.hub.requests.begin(key, time.Now())
+		if !claimed {
+			if response == nil {
+				c.sendError(CodeRequestPending, "this request is still being handled")
+				return
+			}
+			// A retry: answer it the way the first attempt was answered
+			c.sendRaw(response)
+			return
+		}
+	}
+
+	c.dispatch(msg)
+
+	if c.req.response == nil {
+		c.req.response = encode(MsgAck, AckPayload{
+			RequestID: c.req.id,
+			Type:      c.req.msgType,
+			Result:    c.req.result,
+		})
+		c.sendRaw(c.req.response)
+	}
+	if key != "" {
+		c.hub.requests.finish(key, c.req.response, time.Now())
+	}
+}
+
+// setResult attaches result to the ack for the request being handled.
+func (c *Client) setResult(result interface{}) {
+	if c.req != nil {
+		c.req.result = result
+	}
+}
@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRequestCache(t *testing.T) {
+	rc := newRequestCache()
+	now := time.Unix(1700000000, 0)
+
+	if _, claimed := rc.begin("1\x00make_move\x00a", now); !claimed {
+		t.Fatal("first attempt not claimed")
+	}
+	if response, claimed := rc.begin("1\x00make_move\x00a", now); claimed || response != nil {
+		t.Errorf("retry while pending = %q, %v; want pending", response, claimed)
+	}
+
+	rc.finish("1\x00make_move\x00a", []byte("ack"), now)
+	if response, claimed := rc.begin("1\x00make_move\x00a", now.Add(time.Second)); claimed || string(response) != "ack" {
+		t.Errorf("retry = %q, %v; want the first response", response, claimed)
+	}
+	if _, claimed := rc.begin("1\x00make_move\x00a", now.Add(requestTTL+time.Second)); !claimed {
+		t.Error("request_id still taken after its outcome expired")
+	}
+}
+
+func TestRequestCachePendingExpires(t *testing.T) {
+	rc := newRequestCache()
+	now := time.Unix(1700000000, 0)
+
+	rc.begin("1\x00create_room\x00a", now)
+	if _, claimed := rc.begin("1\x00create_room\x00a", now.Add(requestPendingTTL/2)); claimed {
+		t.Error("pending request_id claimed twice")
+	}
+	if _, claimed := rc.begin("1\x00create_room\x00a", now.Add(requestPendingTTL+time.Second)); !claimed {
+		t.Error("an attempt that never finished kept its request_id")
+	}
+}
+
+func TestRequestCacheSweep(t *testing.T) {
+	rc := newRequestCache()
+	now := time.Unix(1700000000, 0)
+
+	rc.begin("a", now)
+	rc.finish("a", []byte("ack"), now)
+	rc.begin("b", now)
+	rc.begin("c", now.Add(requestTTL+requestSweepInterval))
+	if len(rc.entries) != 1 {
+		t.Errorf("%d entries after the sweep, want only the new one", len(rc.entries))
+	}
+
+	// Between sweeps, other keys are left alone
+	rc.begin("d", now.Add(requestTTL+requestSweepInterval+time.Second))
+	if len(rc.entries) != 2 {
+		t.Errorf("%d entries, want 2", len(rc.entries))
+	}
+}
+
+func TestHandleMessageRetry(t *testing.T) {
+	h := NewHub(nil)
+	c := testClient(h, "1")
+	msg := wsMessage{Type: MsgMakeMove, RequestID: "move-1", Payload: json.RawMessage(`{"room_id":"nope"}`)}
+
+	c.handleMessage(msg)
+	first := <-c.send
+	c.handleMessage(msg)
+	if retry := <-c.send; !bytes.Equal(retry, first) {
+		t.Errorf("retry answered %s, want the first response %s", retry, first)
+	}
+	if len(c.send) != 0 {
+		t.Error("retry sent more than the first response")
+	}
+}
+
+func TestHandleMessageConcurrentRetry(t *testing.T) {
+	h := NewHub(nil)
+	c := testClient(h, "1")
+	msg := wsMessage{Type: MsgMakeMove, RequestID: "move-1", Payload: json.RawMessage(`{"room_id":"nope"}`)}
+
+	// Another connection of the same user is still handling it
+	h.requests.begin("1\x00"+MsgMakeMove+"\x00move-1", time.Now())
+	c.handleMessage(msg)
+
+	if e := nextError(t, c); e.Code != CodeRequestPending || e.RequestID != "move-1" {
+		t.Errorf("concurrent retry = %+v, want REQUEST_PENDING", e)
+	}
+}
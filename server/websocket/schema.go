@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema describes the protocol as JSON Schema, generated from the
+// payload structs in protocol.go so it can't drift from what the server
+// actually sends. Every struct lands in $defs under its Go name, with
+// its package prepended if two packages use the same name.
+func Schema() map[string]interface{} {
+	b := &schemaBuilder{
+		defs:  make(map[string]interface{}),
+		names: make(map[reflect.Type]string),
+		taken: make(map[string]reflect.Type),
+	}
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Trivia websocket protocol",
+		"description": `Every message is {"type": ..., "payload": ...}. Client messages may add a "request_id" (up to 64 characters), echoed in the "ack" or "error" that answers them. Errors carry a code from error_codes.`,
+		"version":     ProtocolVersion,
+		"error_codes": ErrorCodes,
+		"messages": map[string]interface{}{
+			"client": b.messages(ClientMessages),
+			"server": b.messages(ServerMessages),
+		},
+		"$defs": b.defs,
+	}
+}
+
+type schemaBuilder struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+	taken map[string]reflect.Type // def name -> the type that has it
+}
+
+func (b *schemaBuilder) messages(specs []MessageSpec) map[string]interface{} {
+	out := make(map[string]interface{}, len(specs))
+	for _, spec := range specs {
+		out[spec.Type] = map[string]interface{}{
+			"description": spec.Description,
+			"payload":     b.schema(reflect.TypeOf(spec.Payload)),
+		}
+	}
+	return out
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	errorCodeType = reflect.TypeOf(ErrorCode(""))
+)
+
+// schema returns the JSON Schema for values of type t.
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case errorCodeType:
+		return map[string]interface{}{"type": "string", "enum": ErrorCodes}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{
+			"anyOf": []interface{}{b.schema(t.Elem()), map[string]interface{}{"type": "null"}},
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Array:
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    b.schema(t.Elem()),
+			"minItems": t.Len(),
+			"maxItems": t.Len(),
+		}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + b.define(t)}
+	}
+	return map[string]interface{}{} // interface{}: anything
+}
+
+// define adds a named struct to $defs once and returns its def name.
+func (b *schemaBuilder) define(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if other, clash := b.taken[name]; clash && other != t {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	b.names[t] = name
+	b.taken[name] = t
+	b.defs[name] = nil // reserve it, in case the struct refers to itself
+	b.defs[name] = b.object(t)
+	return name
+}
+
+// object describes a struct the way encoding/json writes it.
+func (b *schemaBuilder) object(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	required := []string{}
+	b.fields(t, props, &required)
+
+	obj := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+func (b *schemaBuilder) fields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.fields(ft, props, required) // promoted fields
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = b.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// SessionPolicy decides what happens when a user who is already
+// connected opens another connection, e.g. a second tab.
+type SessionPolicy string
+
+const (
+	// SessionTakeover gives the newest connection the user's seat and
+	// closes the older ones with CloseSessionReplaced.
+	SessionTakeover SessionPolicy = "takeover"
+	// SessionMirror keeps every connection open. One holds the user's
+	// seat and the others mirror it: they get the room's messages and
+	// can act for the seat.
+	SessionMirror SessionPolicy = "mirror"
+)
+
+// CloseSessionReplaced is the close code for a connection a newer one
+// from the same user took over.
+const CloseSessionReplaced = 4001
+
+// addSession indexes a newly registered connection.
+func (h *Hub) addSession(c *Client) {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	h.byID[c.ID] = c
+	h.byUser[c.userID] = append(h.byUser[c.userID], c)
+}
+
+func (h *Hub) removeSession(c *Client) {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	delete(h.byID, c.ID)
+	sessions := h.byUser[c.userID]
+	for i, s := range sessions {
+		if s == c {
+			sessions = append(sessions[:i], sessions[i+1:]...)
+			break
+		}
+	}
+	if len(sessions) == 0 {
+		delete(h.byUser, c.userID)
+	} else {
+		h.byUser[c.userID] = sessions
+	}
+}
+
+// Sessions returns userID's connections, oldest first.
+func (h *Hub) Sessions(userID string) []*Client {
+	h.sessionsMu.RLock()
+	defer h.sessionsMu.RUnlock()
+	return append([]*Client(nil), h.byUser[userID]...)
+}
+
+// attach applies the SessionPolicy to a new connection and puts it in
+// the room its user has a seat in, if any: resuming a held seat, taking
+// over a seat another connection has, or mirroring it.
+func (h *Hub) attach(client *Client) {
+	takeover := h.SessionPolicy != SessionMirror
+
+	claimed := false
+	for _, room := range h.roomList() {
+		index, mirror, ok := room.ClaimSeat(client, takeover)
+		if !ok {
+			continue
+		}
+		client.currentRoom = room.ID
+		if err := h.takeSeat(client.userID, room.ID); err != nil {
+			log.Printf("Failed to record seat of user %s in room %s: %v", client.userID, room.ID, err)
+		}
+		h.catchUp(client, room, index)
+		if mirror {
+			log.Printf("Client %s mirrors user %s's seat in room %s", client.ID, client.userID, room.ID)
+		}
+		claimed = true
+		break
+	}
+	if !claimed && h.Backplane != nil && client.home == "" {
+		// The seat may be on another instance
+		h.Backplane.resume(client)
+	}
+
+	if !takeover {
+		return
+	}
+	others := h.Sessions(client.userID)
+	if h.Backplane != nil {
+		others = append(others, h.Backplane.proxiesOf(client.userID)...)
+	}
+	for _, old := range others {
+		if old != client {
+			log.Printf("Client %s replaced by %s for user %s", old.ID, client.ID, client.userID)
+			old.replace()
+		}
+	}
+}
+
+// seated reports whether userID has a seat, held or not, in a room here
+// or on another instance.
+func (h *Hub) seated(userID string) bool {
+	for _, room := range h.roomList() {
+		if room.hasUser(userID) {
+			return true
+		}
+	}
+	return h.Backplane != nil && h.Backplane.seatOf(userID).owner != ""
+}
+
+// roomList snapshots the hub's rooms.
+func (h *Hub) roomList() []*GameRoom {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	rooms := make([]*GameRoom, 0, len(h.rooms))
+	for _, room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// seatedRoom returns the room userID has a seat in, on any connection.
+func (h *Hub) seatedRoom(userID string) (*GameRoom, bool) {
+	for _, room := range h.roomList() {
+		if room.hasUser(userID) {
+			return room, true
+		}
+	}
+	return nil, false
+}
+
+// leaveSeat gives up the seat c's user has in room, whichever of their
+// connections holds it. Their other tabs get the same player_left as
+// everyone else and stop mirroring the room.
+func (h *Hub) leaveSeat(room *GameRoom, c *Client) {
+	seatID := room.SeatID(c.ID)
+	if seatID == "" {
+		return
+	}
+	h.releaseSeat(room, seatID)
+	room.dropMirrors(c.userID)
+}
+
+// replace closes a connection a newer one took over.
+func (c *Client) replace() {
+	if c.home != "" {
+		c.hub.Backplane.replaced(c)
+		return
+	}
+	msg := websocket.FormatCloseMessage(CloseSessionReplaced, "replaced by a newer connection")
+	_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+	c.conn.Close()
+}
+
+// ClaimSeat gives client the seat its user has in the room, if any. A
+// held seat is always resumed; a seat another connection holds is taken
+// over, or mirrored if takeover is false. Returns the seat's player
+// index and whether client only mirrors it.
+func (r *GameRoom) ClaimSeat(client *Client, takeover bool) (index int, mirror, ok bool) {
+	r.mu.Lock()
+	oldID := ""
+	for id, p := range r.Players {
+		if p.userID == client.userID && id != client.ID {
+			oldID = id
+			break
+		}
+	}
+	if oldID == "" {
+		r.mu.Unlock()
+		return 0, false, false
+	}
+
+	if seat, held := r.away[oldID]; held {
+		seat.timer.Stop()
+		delete(r.away, oldID)
+	} else if !takeover {
+		r.mirrors[client.ID] = client
+		index = r.seatIndexLocked(oldID)
+		r.mu.Unlock()
+		return index, true, true
+	}
+	index = r.swapSeatLocked(oldID, client)
+	r.mu.Unlock()
+	r.swapRematch(oldID, client.ID)
+
+	log.Printf("User %s took seat %d in room %s", client.userID, index, r.ID)
+	r.Broadcast(encode(MsgPlayerReconnected, PlayerReconnectedPayload{
+		RoomID:           r.ID,
+		PlayerID:         client.ID,
+		PreviousPlayerID: oldID,
+		UserID:           client.userID,
+		Username:         client.username,
+	}))
+	return index, false, true
+}
+
+// HandOverSeat passes the seat clientID holds to one of its user's
+// mirroring connections, for when clientID's connection drops. Returns
+// false if there is nobody to hand it to.
+func (r *GameRoom) HandOverSeat(clientID string) bool {
+	r.mu.Lock()
+	seat, seated := r.Players[clientID]
+	if !seated {
+		r.mu.Unlock()
+		return false
+	}
+	var heir *Client
+	for _, m := range r.mirrors {
+		if m.userID == seat.userID {
+			heir = m
+			break
+		}
+	}
+	if heir == nil {
+		r.mu.Unlock()
+		return false
+	}
+	delete(r.mirrors, heir.ID)
+	r.swapSeatLocked(clientID, heir)
+	r.mu.Unlock()
+	r.swapRematch(clientID, heir.ID)
+
+	log.Printf("Seat of user %s in room %s handed to client %s", seat.userID, r.ID, heir.ID)
+	return true
+}
+
+// swapSeatLocked moves the seat oldID holds to client, keeping its place
+// in the turn order and its ready state. r.mu must be held.
+func (r *GameRoom) swapSeatLocked(oldID string, client *Client) int {
+	delete(r.Players, oldID)
+	delete(r.mirrors, client.ID)
+	r.Players[client.ID] = client
+	if ready, ok := r.readyPlayers[oldID]; ok {
+		delete(r.readyPlayers, oldID)
+		r.readyPlayers[client.ID] = ready
+	}
+	for i, id := range r.playerOrder {
+		if id == oldID {
+			r.playerOrder[i] = client.ID
+			return i
+		}
+	}
+	return 0
+}
+
+// swapRematch carries a rematch vote over to the seat's new connection.
+func (r *GameRoom) swapRematch(oldID, newID string) {
+	r.rematchMu.Lock()
+	defer r.rematchMu.Unlock()
+	if accepted, ok := r.RematchRequests[oldID]; ok {
+		delete(r.RematchRequests, oldID)
+		r.RematchRequests[newID] = accepted
+	}
+}
+
+func (r *GameRoom) seatIndexLocked(seatID string) int {
+	for i, id := range r.playerOrder {
+		if id == seatID {
+			return i
+		}
+	}
+	return 0
+}
+
+// RemoveMirror stops clientID mirroring a seat. Returns false if it
+// wasn't.
+func (r *GameRoom) RemoveMirror(clientID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, mirroring := r.mirrors[clientID]; !mirroring {
+		return false
+	}
+	delete(r.mirrors, clientID)
+	return true
+}
+
+// dropMirrors detaches all of userID's mirroring connections.
+func (r *GameRoom) dropMirrors(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, m := range r.mirrors {
+		if m.userID == userID {
+			delete(r.mirrors, id)
+		}
+	}
+}
+
+// mirrorsOf lists the connections mirroring userID's seat.
+func (r *GameRoom) mirrorsOf(userID string) []*Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var mirrors []*Client
+	for _, m := range r.mirrors {
+		if m.userID == userID {
+			mirrors = append(mirrors, m)
+		}
+	}
+	return mirrors
+}
+
+// SeatID returns the ID of the seat clientID holds or mirrors, or "" if
+// neither.
+func (r *GameRoom) SeatID(clientID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, seated := r.Players[clientID]; seated {
+		return clientID
+	}
+	m, mirroring := r.mirrors[clientID]
+	if !mirroring {
+		return ""
+	}
+	for id, p := range r.Players {
+		if p.userID == m.userID {
+			return id
+		}
+	}
+	return ""
+}
+
+// hasUser reports whether userID has a seat in the room.
+func (r *GameRoom) hasUser(userID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hasUserLocked(userID)
+}
+
+func (r *GameRoom) hasUserLocked(userID string) bool {
+	for _, p := range r.Players {
+		if p.userID == userID {
+			return true
+		}
+	}
+	return false
+}
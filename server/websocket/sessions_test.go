@@ -0,0 +1,125 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSessionsIndex(t *testing.T) {
+	h := NewHub(nil)
+	first, second, other := testClient(h, "1"), testClient(h, "1"), testClient(h, "2")
+	second.ID = "client-1-tab-2"
+	for _, c := range []*Client{first, second, other} {
+		h.addSession(c)
+	}
+
+	if got := h.Sessions("1"); len(got) != 2 || got[0] != first || got[1] != second {
+		t.Errorf("sessions of user 1 = %v, want both tabs oldest first", got)
+	}
+	h.removeSession(first)
+	if got := h.Sessions("1"); len(got) != 1 || got[0] != second {
+		t.Errorf("sessions after closing a tab = %v", got)
+	}
+	h.removeSession(second)
+	if _, left := h.byUser["1"]; left || len(h.Sessions("1")) != 0 {
+		t.Error("user without connections still indexed")
+	}
+	if h.byID[other.ID] != other {
+		t.Error("another user's connection was dropped")
+	}
+}
+
+func TestClaimSeatMirror(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+	tab := testClient(h, "1")
+	tab.ID = "client-1-tab-2"
+
+	index, mirror, ok := room.ClaimSeat(tab, false)
+	if !ok || !mirror || index != 0 {
+		t.Fatalf("ClaimSeat = %d, %v, %v; want to mirror seat 0", index, mirror, ok)
+	}
+	if room.SeatID(tab.ID) != alice.ID || !room.IsPlayer(tab.ID) {
+		t.Error("mirror doesn't act for the seat")
+	}
+
+	// Mirrors get what the seat gets
+	drain(tab)
+	room.Broadcast([]byte("hello"))
+	if got := drain(tab); len(got) != 1 {
+		t.Errorf("mirror got %q, want the broadcast", got)
+	}
+
+	// When the seat's connection drops, the mirror inherits it
+	if !room.HandOverSeat(alice.ID) {
+		t.Fatal("seat not handed over")
+	}
+	if room.SeatID(tab.ID) != tab.ID || room.SeatID(alice.ID) != "" {
+		t.Error("seat didn't move to the mirror")
+	}
+	if room.HandOverSeat(tab.ID) {
+		t.Error("handed over a seat with no mirror to take it")
+	}
+}
+
+func TestClaimSeatTakeover(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+	room.readyPlayers[alice.ID] = true
+	tab := testClient(h, "1")
+	tab.ID = "client-1-tab-2"
+
+	index, mirror, ok := room.ClaimSeat(tab, true)
+	if !ok || mirror || index != 0 {
+		t.Fatalf("ClaimSeat = %d, %v, %v; want seat 0", index, mirror, ok)
+	}
+	if room.SeatID(alice.ID) != "" || !room.readyPlayers[tab.ID] {
+		t.Error("takeover didn't move the seat and its ready state")
+	}
+}
+
+// A user seated in one tab can't take a second seat from another.
+func TestOneSeatPerUser(t *testing.T) {
+	h := NewHub(nil)
+	room, _, _ := playingRoom(t, h)
+	h.AddRoom(room)
+
+	if got, seated := h.seatedRoom("1"); !seated || got != room {
+		t.Fatal("seated user not found")
+	}
+	if _, seated := h.seatedRoom("3"); seated {
+		t.Error("found a seat for a user without one")
+	}
+
+	tab := testClient(h, "1")
+	tab.ID = "client-1-tab-2"
+	tab.handleCreateRoom(CreateRoomPayload{RoomName: "Second room"})
+	if e := nextError(t, tab); e.Code != CodeAlreadyInRoom || !strings.Contains(e.Message, "another tab") {
+		t.Errorf("second room = %+v, want ALREADY_IN_ROOM", e)
+	}
+	room.State.MaxPlayers = 3
+	if err := room.AddPlayer(tab); err != ErrPlayerExists {
+		t.Errorf("second seat in the same room = %v, want ErrPlayerExists", err)
+	}
+}
+
+func TestAttachMirror(t *testing.T) {
+	h := NewHub(nil)
+	h.SessionPolicy = SessionMirror
+	room := NewGameRoom("room-1", "Room", "", "1")
+	alice := testClient(h, "1")
+	if err := room.AddPlayer(alice); err != nil {
+		t.Fatal(err)
+	}
+	h.AddRoom(room)
+
+	tab := testClient(h, "1")
+	tab.ID = "client-1-tab-2"
+	h.attach(tab)
+	if tab.currentRoom != room.ID || room.SeatID(tab.ID) != alice.ID {
+		t.Errorf("new tab in %q mirroring %q, want the user's room and seat", tab.currentRoom, room.SeatID(tab.ID))
+	}
+	if got := drain(tab); len(got) == 0 || !strings.Contains(got[0], MsgJoinedRoom) {
+		t.Errorf("new tab got %q, want to be caught up on the room", got)
+	}
+}
@@ -0,0 +1,147 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrShuttingDown is returned for new rooms and games while the server
+// drains before a restart.
+var ErrShuttingDown = errors.New("the server is restarting — try again in a minute")
+
+// shutdownMargin is kept back from the shutdown deadline for saving the
+// games still going and closing connections.
+const shutdownMargin = 3 * time.Second
+
+// Draining reports whether Shutdown has begun.
+func (h *Hub) Draining() bool {
+	return h.draining.Load()
+}
+
+// Shutdown drains the hub before the server stops, within ctx's
+// deadline: no new rooms, games or connections (but players can come
+// back to their seats), a server_shutdown countdown to every
+// connection, then a wait for the games in progress to finish. Games
+// that don't are snapshotted for RestoreGames with their timers
+// stopped, every connection is closed with 1001 (going away), and the
+// Run loop ends.
+func (h *Hub) Shutdown(ctx context.Context) {
+	h.draining.Store(true)
+
+	drainUntil := time.Now().Add(30 * time.Second)
+	if deadline, ok := ctx.Deadline(); ok {
+		drainUntil = deadline.Add(-shutdownMargin)
+	}
+	h.announceShutdown(drainUntil)
+	h.waitForGames(ctx, drainUntil)
+
+	h.freezeRooms()
+	h.closeConnections(ctx)
+	if h.Backplane != nil {
+		h.Backplane.Stop()
+	}
+	close(h.quit)
+	log.Printf("Websocket hub stopped")
+}
+
+// announceShutdown tells every connection, and every remote player in a
+// room here, when the server goes down.
+func (h *Hub) announceShutdown(at time.Time) {
+	msg := encode(MsgServerShutdown, ServerShutdownPayload{
+		Deadline: at.UnixMilli(),
+		Seconds:  int(time.Until(at).Round(time.Second).Seconds()),
+	})
+	h.broadcast <- msg
+	if h.Backplane != nil {
+		for _, p := range h.Backplane.proxyClients() {
+			p.sendRaw(msg)
+		}
+	}
+	log.Printf("Shutdown announced for %s", at.Format(time.RFC3339))
+}
+
+// waitForGames returns once no game is being played here, or at until.
+func (h *Hub) waitForGames(ctx context.Context, until time.Time) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.NewTimer(time.Until(until))
+	defer timeout.Stop()
+
+	for {
+		active := h.activeGames()
+		if active == 0 {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-timeout.C:
+			log.Printf("Shutdown: %d games still in progress", active)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Hub) activeGames() int {
+	active := 0
+	for _, room := range h.roomList() {
+		if room.playing() {
+			active++
+		}
+	}
+	return active
+}
+
+// freezeRooms saves every game in progress and stops the timers that
+// would change it, so the rooms stay as saved while connections close.
+func (h *Hub) freezeRooms() {
+	h.stopped.Store(true)
+	for _, room := range h.roomList() {
+		h.saveGame(room)
+		room.StopTurnTimer()
+		room.mu.Lock()
+		for _, seat := range room.away {
+			seat.timer.Stop()
+		}
+		room.mu.Unlock()
+	}
+}
+
+// closeConnections closes every connection with 1001 and waits for the
+// Run loop to unregister them.
+func (h *Hub) closeConnections(ctx context.Context) {
+	h.sessionsMu.RLock()
+	clients := make([]*Client, 0, len(h.byID))
+	for _, c := range h.byID {
+		clients = append(clients, c)
+	}
+	h.sessionsMu.RUnlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, c := range clients {
+		_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+		c.conn.Close()
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		h.sessionsMu.RLock()
+		left := len(h.byID)
+		h.sessionsMu.RUnlock()
+		if left == 0 {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Printf("Shutdown: %d connections didn't unregister in time", left)
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"trivia-server/models"
+	"trivia-server/sessions"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDrainingRefusesNewRoomsAndGames(t *testing.T) {
+	h := NewHub(nil)
+	h.draining.Store(true)
+
+	c := testClient(h, "1")
+	c.handleCreateRoom(CreateRoomPayload{RoomID: "room-1", RoomName: "Room"})
+	if e := nextError(t, c); e.Code != CodeShuttingDown {
+		t.Errorf("create_room while draining = %s, want SHUTTING_DOWN", e.Code)
+	}
+	if len(h.rooms) != 0 {
+		t.Error("created a room while draining")
+	}
+
+	c.handleStartGame()
+	if e := nextError(t, c); e.Code != CodeShuttingDown {
+		t.Errorf("start_game while draining = %s, want SHUTTING_DOWN", e.Code)
+	}
+
+	c.handleJoinRoom(JoinRoomPayload{RoomID: "room-1"})
+	if e := nextError(t, c); e.Code != CodeShuttingDown {
+		t.Errorf("join_room while draining = %s, want SHUTTING_DOWN", e.Code)
+	}
+}
+
+// A player whose connection drops during the countdown can come back to
+// the game being waited on; nobody else gets in.
+func TestDrainingAdmitsSeatedPlayers(t *testing.T) {
+	rdb, _ := openFakeRedis(t)
+	jwtService := sessions.NewJWTService("secret", rdb)
+	h := NewHub(nil)
+	go h.Run()
+	t.Cleanup(func() { close(h.quit) })
+
+	room, alice, _ := playingRoom(t, h)
+	h.AddRoom(room)
+	room.HoldSeat(alice, time.Minute, func() {})
+	h.draining.Store(true)
+
+	srv := httptest.NewServer(Handler(h, jwtService, NewGameManager()))
+	defer srv.Close()
+	dial := func(userID int) (*websocket.Conn, *http.Response, error) {
+		token, err := jwtService.GenerateToken(&models.User{ID: userID, Username: "user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"?token="+token, nil)
+	}
+
+	if _, resp, err := dial(3); err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("a seatless connection while draining got %v, want 503", resp)
+	}
+
+	conn, _, err := dial(1)
+	if err != nil {
+		t.Fatalf("a player with a held seat couldn't reconnect: %v", err)
+	}
+	defer conn.Close()
+	// The seat is claimed once the upgrade is done
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		room.mu.RLock()
+		_, held := room.away[alice.ID]
+		room.mu.RUnlock()
+		if !held {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the reconnected player's seat is still held")
+		}
+	}
+}
+
+func TestActiveGames(t *testing.T) {
+	h := NewHub(nil)
+	room, _, _ := playingRoom(t, h)
+	h.AddRoom(room)
+	h.AddRoom(NewGameRoom("room-2", "Waiting", "", "3"))
+
+	if n := h.activeGames(); n != 1 {
+		t.Errorf("activeGames = %d, want 1", n)
+	}
+}
+
+func TestWaitForGamesEndsWithTheLastGame(t *testing.T) {
+	h := NewHub(nil)
+	room, _, _ := playingRoom(t, h)
+	h.AddRoom(room)
+
+	time.AfterFunc(50*time.Millisecond, func() {
+		room.mu.Lock()
+		room.GameModel.Game.Status = models.GameStatusCompleted
+		room.mu.Unlock()
+	})
+	start := time.Now()
+	h.waitForGames(context.Background(), start.Add(time.Minute))
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("waited %s for a game that finished after 50ms", waited)
+	}
+}
+
+// A game still going at the deadline is saved with its turn timer, and
+// the room stays as saved while connections close.
+func TestShutdownSavesGamesInProgress(t *testing.T) {
+	rdb, _ := openFakeRedis(t)
+	h := NewHub(nil)
+	h.Games = NewGameStore(rdb)
+	room, alice, _ := playingRoom(t, h)
+	room.Difficulty = "hard"
+	h.AddRoom(room)
+	room.StartTurnTimer(h.onTurnTimeout)
+
+	stopped := make(chan struct{})
+	go func() {
+		h.Run()
+		close(stopped)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownMargin+100*time.Millisecond)
+	defer cancel()
+	h.Shutdown(ctx)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return after Shutdown")
+	}
+	if !h.Draining() {
+		t.Error("not draining after Shutdown")
+	}
+
+	snaps, err := h.Games.loadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 1 || snaps[0].RoomID != "room-1" {
+		t.Fatalf("saved %+v, want room-1's game", snaps)
+	}
+	if snaps[0].TurnDeadline.IsZero() {
+		t.Error("saved without its turn deadline")
+	}
+	room.turnTimerMu.Lock()
+	running := room.turnTimer != nil
+	room.turnTimerMu.Unlock()
+	if running {
+		t.Error("turn timer still running after Shutdown")
+	}
+
+	h.removeClientFromRooms(alice)
+	if _, seated := room.Players[alice.ID]; !seated {
+		t.Error("a connection closing during shutdown gave up its seat")
+	}
+}
@@ -0,0 +1,307 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"trivia-server/grid"
+	"trivia-server/models"
+
+	"github.com/go-redis/redis"
+)
+
+// gameSnapshotTTL is how long a saved game outlives its last change, so
+// games a crash left behind don't pile up.
+const gameSnapshotTTL = 24 * time.Hour
+
+const gamesKey = "ws:games" // set of room IDs with a saved game
+
+func gameKey(roomID string) string        { return "ws:game:" + roomID }
+func gameVersionKey(roomID string) string { return "ws:game:" + roomID + ":version" }
+
+var (
+	// saveSnapshot stores a snapshot unless one at least as new is
+	// already stored (or deleted): concurrent saves from the read loop
+	// and the turn timer can reach Redis out of order.
+	saveSnapshot = redis.NewScript(`
+local stored = tonumber(redis.call("get", KEYS[2]) or "0")
+if stored >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("set", KEYS[1], ARGV[2], "px", ARGV[3])
+redis.call("set", KEYS[2], ARGV[1], "px", ARGV[3])
+redis.call("sadd", KEYS[3], ARGV[4])
+return 1`)
+	// deleteSnapshot deletes a snapshot unless a newer one is stored,
+	// leaving the version behind so an older save can't bring it back.
+	deleteSnapshot = redis.NewScript(`
+local stored = tonumber(redis.call("get", KEYS[2]) or "0")
+if stored > tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("del", KEYS[1])
+redis.call("set", KEYS[2], ARGV[1], "px", ARGV[2])
+redis.call("srem", KEYS[3], ARGV[3])
+return 1`)
+)
+
+// gameSnapshot is everything needed to rebuild a room with a game in
+// progress after a restart.
+type gameSnapshot struct {
+	RoomID          string           `json:"room_id"`
+	Name            string           `json:"name"`
+	Password        string           `json:"password"`
+	CreatorID       string           `json:"creator_id"`
+	MaxPlayers      int              `json:"max_players"`
+	Difficulty      string           `json:"difficulty"`
+	GridSeed        int64            `json:"grid_seed"`
+	CustomGridID    int              `json:"custom_grid_id"`
+	GridFilters     grid.GridFilters `json:"grid_filters"`
+	AllowSpectators bool             `json:"allow_spectators"`
+	SpectatorDelay  time.Duration    `json:"spectator_delay"`
+
+	Seats          []seatSnapshot  `json:"seats"` // in turn order
+	Game           json.RawMessage `json:"game"`  // models.GameState
+	GameID         int             `json:"game_id"`
+	GridTemplateID int             `json:"grid_template_id"`
+	TurnDeadline   time.Time       `json:"turn_deadline"` // zero if untimed
+	SavedAt        time.Time       `json:"saved_at"`
+	Version        int64           `json:"version"` // see GameRoom.snapshot
+}
+
+type seatSnapshot struct {
+	ClientID string `json:"client_id"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// GameStore keeps snapshots of the games in progress in Redis, so a
+// restarted server can pick them back up; see Hub.RestoreGames.
+type GameStore struct {
+	redis *redis.Client
+}
+
+func NewGameStore(rdb *redis.Client) *GameStore {
+	return &GameStore{redis: rdb}
+}
+
+func (s *GameStore) save(snap gameSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode game snapshot: %w", err)
+	}
+	keys := []string{gameKey(snap.RoomID), gameVersionKey(snap.RoomID), gamesKey}
+	ttl := gameSnapshotTTL.Milliseconds()
+	if err := saveSnapshot.Run(s.redis, keys, snap.Version, data, ttl, snap.RoomID).Err(); err != nil {
+		return fmt.Errorf("failed to save game snapshot: %w", err)
+	}
+	return nil
+}
+
+// delete drops the room's snapshot as of version; a newer snapshot
+// stays.
+func (s *GameStore) delete(roomID string, version int64) error {
+	keys := []string{gameKey(roomID), gameVersionKey(roomID), gamesKey}
+	ttl := gameSnapshotTTL.Milliseconds()
+	if err := deleteSnapshot.Run(s.redis, keys, version, ttl, roomID).Err(); err != nil {
+		return fmt.Errorf("failed to delete game snapshot: %w", err)
+	}
+	return nil
+}
+
+// loadAll returns every saved game, dropping index entries whose
+// snapshot has expired.
+func (s *GameStore) loadAll() ([]gameSnapshot, error) {
+	roomIDs, err := s.redis.SMembers(gamesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list game snapshots: %w", err)
+	}
+	snaps := make([]gameSnapshot, 0, len(roomIDs))
+	for _, roomID := range roomIDs {
+		data, err := s.redis.Get(gameKey(roomID)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			s.redis.SRem(gamesKey, roomID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load game snapshot %s: %w", roomID, err)
+		}
+		var snap gameSnapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			log.Printf("Dropping unreadable game snapshot %s: %v", roomID, err)
+			s.delete(roomID, time.Now().UnixNano())
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// snapshot captures the room's game. ok is false if no game is being
+// played. Every snapshot gets a version above the room's last one, taken
+// under the same lock moves are made under, so a newer version always
+// holds a newer game; versions come from the clock so a room recreated
+// with the same ID carries on above the old one's.
+func (r *GameRoom) snapshot() (snap gameSnapshot, ok bool) {
+	r.turnTimerMu.Lock()
+	deadline := r.turnDeadline
+	r.turnTimerMu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshotVersion = max(r.snapshotVersion+1, time.Now().UnixNano())
+	version := r.snapshotVersion
+	if r.GameModel == nil || r.GameModel.Game.Status != models.GameStatusActive {
+		return gameSnapshot{Version: version}, false
+	}
+
+	game, err := json.Marshal(r.GameModel)
+	if err != nil {
+		log.Printf("Room %s: failed to encode game: %v", r.ID, err)
+		return gameSnapshot{Version: version}, false
+	}
+
+	seats := make([]seatSnapshot, 0, len(r.playerOrder))
+	for _, id := range r.playerOrder {
+		if p, seated := r.Players[id]; seated {
+			seats = append(seats, seatSnapshot{ClientID: id, UserID: p.userID, Username: p.username})
+		}
+	}
+	return gameSnapshot{
+		RoomID:          r.ID,
+		Name:            r.Name,
+		Password:        r.Password,
+		CreatorID:       r.CreatorID,
+		MaxPlayers:      r.State.MaxPlayers,
+		Difficulty:      r.Difficulty,
+		GridSeed:        r.GridSeed,
+		CustomGridID:    r.CustomGridID,
+		GridFilters:     r.GridFilters,
+		AllowSpectators: r.AllowSpectators,
+		SpectatorDelay:  r.SpectatorDelay,
+
+		Seats:          seats,
+		Game:           game,
+		GameID:         r.GameID,
+		GridTemplateID: r.GridTemplateID,
+		TurnDeadline:   deadline,
+		SavedAt:        time.Now(),
+		Version:        version,
+	}, true
+}
+
+// saveGame records the room's game after a change, or forgets it once
+// no game is being played there.
+func (h *Hub) saveGame(room *GameRoom) {
+	if h.Games == nil {
+		return
+	}
+	snap, ok := room.snapshot()
+	var err error
+	if ok {
+		err = h.Games.save(snap)
+	} else {
+		err = h.Games.delete(room.ID, snap.Version)
+	}
+	if err != nil {
+		log.Printf("Room %s: %v", room.ID, err)
+	}
+}
+
+// forgetGame drops a deleted room's snapshot.
+func (h *Hub) forgetGame(roomID string) {
+	if h.Games == nil {
+		return
+	}
+	if err := h.Games.delete(roomID, time.Now().UnixNano()); err != nil {
+		log.Printf("Room %s: %v", roomID, err)
+	}
+}
+
+// RestoreGames rebuilds the rooms whose games were in progress when the
+// server last stopped. Every seat comes back empty and held, so its
+// player can reconnect and carry on as after any dropped connection; the
+// turn timer runs on from where it was, and a turn that ran out while
+// the server was down times out straight away. Returns how many games
+// were restored.
+func (h *Hub) RestoreGames(gm *GameManager) int {
+	if h.Games == nil {
+		return 0
+	}
+	snaps, err := h.Games.loadAll()
+	if err != nil {
+		log.Printf("Failed to restore games: %v", err)
+		return 0
+	}
+
+	grace := h.ReconnectGrace
+	if grace <= 0 {
+		grace = DefaultReconnectGrace
+	}
+
+	restored := 0
+	for _, snap := range snaps {
+		if _, exists := h.FindRoomByID(snap.RoomID); exists {
+			continue
+		}
+		var gs models.GameState
+		if json.Unmarshal(snap.Game, &gs) != nil || gs.Game.Status != models.GameStatusActive || len(snap.Seats) == 0 {
+			h.forgetGame(snap.RoomID)
+			continue
+		}
+		if err := h.claimRoom(snap.RoomID); err != nil {
+			// Another instance has it
+			log.Printf("Not restoring room %s: %v", snap.RoomID, err)
+			continue
+		}
+
+		room := h.restoreRoom(snap, &gs, gm)
+		h.AddRoom(room)
+		for _, seat := range room.GetOrderedClients() {
+			seat := seat
+			room.HoldSeat(seat, grace, func() { h.releaseSeat(room, seat.ID) })
+		}
+		room.resumeTurnTimer(snap.TurnDeadline, h.onTurnTimeout)
+
+		log.Printf("Restored game in room %s (%d seats, saved %s ago)", room.ID, len(snap.Seats), time.Since(snap.SavedAt).Round(time.Second))
+		restored++
+	}
+	if restored > 0 {
+		h.BroadcastRoomList()
+	}
+	return restored
+}
+
+// restoreRoom rebuilds a room from its snapshot, each seat taken by a
+// stand-in Client with no connection.
+func (h *Hub) restoreRoom(snap gameSnapshot, gs *models.GameState, gm *GameManager) *GameRoom {
+	room := NewGameRoom(snap.RoomID, snap.Name, snap.Password, snap.CreatorID)
+	room.State.MaxPlayers = snap.MaxPlayers
+	room.Difficulty = snap.Difficulty
+	room.State.Difficulty = snap.Difficulty
+	room.GridSeed = snap.GridSeed
+	room.CustomGridID = snap.CustomGridID
+	room.GridFilters = snap.GridFilters
+	room.AllowSpectators = snap.AllowSpectators
+	room.SpectatorDelay = snap.SpectatorDelay
+	room.GridTemplateID = snap.GridTemplateID
+	room.snapshotVersion = snap.Version
+
+	for _, seat := range snap.Seats {
+		room.Players[seat.ClientID] = &Client{
+			hub:         h,
+			ID:          seat.ClientID,
+			userID:      seat.UserID,
+			username:    seat.Username,
+			GameManager: gm,
+		}
+		room.playerOrder = append(room.playerOrder, seat.ClientID)
+		room.readyPlayers[seat.ClientID] = true
+	}
+	room.State.PlayerCount = len(room.Players)
+
+	room.StartGame(gs, snap.GameID, gm)
+	return room
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"trivia-server/models"
+)
+
+func TestSnapshot(t *testing.T) {
+	h := NewHub(nil)
+	room, _, _ := playingRoom(t, h)
+	room.Difficulty = "hard"
+	room.GridSeed = 42
+	room.GameID = 9
+
+	snap, ok := room.snapshot()
+	if !ok {
+		t.Fatal("no snapshot of a game in progress")
+	}
+	if snap.RoomID != "room-1" || snap.Difficulty != "hard" || snap.GridSeed != 42 || snap.GameID != 9 {
+		t.Errorf("snapshot = %+v, missing the room's settings", snap)
+	}
+	if len(snap.Seats) != 2 || snap.Seats[0].UserID != "1" || snap.Seats[1].UserID != "2" {
+		t.Errorf("seats = %+v, want users 1 and 2 in turn order", snap.Seats)
+	}
+
+	next, _ := room.snapshot()
+	if next.Version <= snap.Version {
+		t.Errorf("second snapshot's version %d isn't above %d", next.Version, snap.Version)
+	}
+
+	// A finished game isn't snapshotted, but still moves the version on
+	// so the delete it leads to beats any earlier save
+	room.GameModel.Game.Status = models.GameStatusCompleted
+	done, ok := room.snapshot()
+	if ok {
+		t.Error("snapshotted a finished game")
+	}
+	if done.Version <= next.Version {
+		t.Errorf("finished game's version %d isn't above %d", done.Version, next.Version)
+	}
+}
+
+// Saves and deletes can reach Redis out of order; the newest version
+// wins either way.
+func TestGameStoreKeepsNewest(t *testing.T) {
+	rdb, _ := openFakeRedis(t)
+	store := NewGameStore(rdb)
+
+	load := func() []gameSnapshot {
+		t.Helper()
+		snaps, err := store.loadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return snaps
+	}
+
+	for _, v := range []int64{2, 1} {
+		if err := store.save(gameSnapshot{RoomID: "room-1", Version: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if snaps := load(); len(snaps) != 1 || snaps[0].Version != 2 {
+		t.Fatalf("after saving 2 then 1, loaded %+v, want version 2", snaps)
+	}
+
+	store.delete("room-1", 1)
+	if snaps := load(); len(snaps) != 1 {
+		t.Errorf("an older delete dropped the snapshot: %+v", snaps)
+	}
+	store.delete("room-1", 3)
+	if snaps := load(); len(snaps) != 0 {
+		t.Errorf("after deleting at 3, loaded %+v", snaps)
+	}
+	store.save(gameSnapshot{RoomID: "room-1", Version: 2})
+	if snaps := load(); len(snaps) != 0 {
+		t.Errorf("a save older than the delete brought the game back: %+v", snaps)
+	}
+}
+
+func TestRestoreGames(t *testing.T) {
+	rdb, _ := openFakeRedis(t)
+
+	before := NewHub(nil)
+	before.Games = NewGameStore(rdb)
+	room, _, _ := playingRoom(t, before)
+	room.Difficulty = "easy"
+	before.saveGame(room)
+
+	// A finished game whose delete was lost is dropped, not restored
+	game, _ := json.Marshal(models.GameState{Game: models.Game{Status: models.GameStatusCompleted}})
+	before.Games.save(gameSnapshot{
+		RoomID:  "room-2",
+		Seats:   []seatSnapshot{{ClientID: "client-3", UserID: "3"}},
+		Game:    game,
+		Version: 1,
+	})
+
+	after := NewHub(nil)
+	after.Games = NewGameStore(rdb)
+	after.ReconnectGrace = time.Minute
+	gm := NewGameManager()
+	if n := after.RestoreGames(gm); n != 1 {
+		t.Fatalf("restored %d games, want 1", n)
+	}
+
+	restored, ok := after.FindRoomByID("room-1")
+	if !ok {
+		t.Fatal("room-1 not restored")
+	}
+	if _, ok := after.FindRoomByID("room-2"); ok {
+		t.Error("restored a finished game")
+	}
+	if snaps, _ := after.Games.loadAll(); len(snaps) != 1 {
+		t.Errorf("%d snapshots left, want the finished one forgotten", len(snaps))
+	}
+	if restored.snapshotVersion < room.snapshotVersion {
+		t.Errorf("restored version %d is behind the saved %d", restored.snapshotVersion, room.snapshotVersion)
+	}
+	seats := restored.GetOrderedClients()
+	if len(seats) != 2 || seats[0].userID != "1" || seats[1].userID != "2" {
+		t.Fatalf("restored seats = %v, want users 1 and 2 in order", seats)
+	}
+	restored.mu.RLock()
+	held := len(restored.away)
+	restored.mu.RUnlock()
+	if held != 2 {
+		t.Errorf("%d seats held for reconnection, want 2", held)
+	}
+	if _, ok := gm.GetGameRoom(restored.GameID); !ok {
+		t.Error("restored game not registered with the game manager")
+	}
+
+	// Restoring again doesn't duplicate the room
+	if n := after.RestoreGames(gm); n != 0 {
+		t.Errorf("restoring twice restored %d more", n)
+	}
+}
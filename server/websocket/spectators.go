@@ -0,0 +1,189 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"time"
+	"trivia-server/grid"
+)
+
+// MaxSpectatorDelay caps how far behind the live board a room can keep
+// its spectators.
+const MaxSpectatorDelay = 60 * time.Second
+
+var (
+	ErrSpectatingDisabled = errors.New("spectating is not allowed in this room")
+	ErrSpectatorIsPlayer  = errors.New("players can't spectate their own room")
+)
+
+// AddSpectator lets client watch the room read-only. Spectators don't
+// count toward MaxPlayers and get game_started, game_state, turn_timer
+// and game_ended, the board SpectatorDelay behind the players.
+func (r *GameRoom) AddSpectator(client *Client) error {
+	r.mu.Lock()
+	if !r.AllowSpectators {
+		r.mu.Unlock()
+		return ErrSpectatingDisabled
+	}
+	if r.hasUserLocked(client.userID) {
+		r.mu.Unlock()
+		return ErrSpectatorIsPlayer
+	}
+	r.Spectators[client.ID] = client
+	r.mu.Unlock()
+
+	log.Printf("Client %s spectating room %s", client.ID, r.ID)
+	r.broadcastSpectatorCount()
+	return nil
+}
+
+// RemoveSpectator stops client clientID watching the room. Returns false
+// if it wasn't.
+func (r *GameRoom) RemoveSpectator(clientID string) bool {
+	r.mu.Lock()
+	if _, watching := r.Spectators[clientID]; !watching {
+		r.mu.Unlock()
+		return false
+	}
+	delete(r.Spectators, clientID)
+	r.mu.Unlock()
+
+	r.broadcastSpectatorCount()
+	return true
+}
+
+func (r *GameRoom) SpectatorCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.Spectators)
+}
+
+// IsPlayer reports whether clientID holds or mirrors a seat in the room.
+func (r *GameRoom) IsPlayer(clientID string) bool {
+	return r.SeatID(clientID) != ""
+}
+
+// BroadcastWithSpectators sends message to the players and spectators.
+// Messages that show the board are delayed for spectators so they can't
+// relay answers to a player.
+func (r *GameRoom) BroadcastWithSpectators(message []byte, showsBoard bool) {
+	r.Broadcast(message)
+	delay := time.Duration(0)
+	if showsBoard {
+		delay = r.SpectatorDelay
+	}
+	r.sendSpectators(message, delay)
+}
+
+// sendSpectators sends message to everyone watching after delay.
+func (r *GameRoom) sendSpectators(message []byte, delay time.Duration) {
+	if delay > 0 {
+		time.AfterFunc(delay, func() { r.sendSpectators(message, 0) })
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, client := range r.Spectators {
+		select {
+		case client.send <- message:
+		default:
+		}
+	}
+}
+
+// sendSpectator sends message to one spectator after delay, if they're
+// still watching by then.
+func (r *GameRoom) sendSpectator(clientID string, message []byte, delay time.Duration) {
+	if delay > 0 {
+		time.AfterFunc(delay, func() { r.sendSpectator(clientID, message, 0) })
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if client, watching := r.Spectators[clientID]; watching {
+		select {
+		case client.send <- message:
+		default:
+		}
+	}
+}
+
+func (r *GameRoom) broadcastSpectatorCount() {
+	r.BroadcastWithSpectators(encode(MsgSpectatorsChanged, SpectatorsChangedPayload{
+		RoomID:         r.ID,
+		SpectatorCount: r.SpectatorCount(),
+	}), false)
+}
+
+// handleSpectateRoom starts the client watching a room, catching it up on
+// a game in progress.
+func (c *Client) handleSpectateRoom(p JoinRoomPayload) {
+	if c.currentRoom != "" {
+		c.sendError(CodeAlreadyInRoom, "leave your room before spectating")
+		return
+	}
+	c.stopSpectating()
+
+	room, exists := c.hub.lookupRoom(p.RoomID, p.RoomName)
+	if !exists {
+		c.sendError(CodeRoomNotFound, "room not found")
+		return
+	}
+	if room.Password != "" && room.Password != p.Password {
+		c.sendError(CodeWrongPassword, "incorrect room password")
+		return
+	}
+	if err := room.AddSpectator(c); err != nil {
+		c.sendFailure(err)
+		return
+	}
+	c.spectating = room.ID
+
+	c.sendMessage(MsgSpectating, SpectatingPayload{
+		RoomID:         room.ID,
+		RoomName:       room.Name,
+		SpectatorCount: room.SpectatorCount(),
+		DelaySeconds:   int(room.SpectatorDelay.Seconds()),
+	})
+	c.sendChatHistory(room)
+
+	room.mu.RLock()
+	state := room.GameModel
+	var stateMsg []byte
+	if state != nil {
+		stateMsg = encode(MsgGameState, state)
+	}
+	room.mu.RUnlock()
+
+	if state != nil {
+		if gt := room.currentGrid(c.hub); gt != nil {
+			c.sendMessage(MsgGameStarted, spectatorGamePayload(room, gt))
+		}
+		room.sendSpectator(c.ID, stateMsg, room.SpectatorDelay)
+		c.sendMessage(MsgTurnTimer, room.turnTimerPayload())
+	}
+
+	c.hub.BroadcastRoomList()
+}
+
+// stopSpectating takes the client out of the room it's watching, if any.
+func (c *Client) stopSpectating() {
+	if c.spectating == "" {
+		return
+	}
+	if room, exists := c.hub.FindRoomByID(c.spectating); exists {
+		room.RemoveSpectator(c.ID)
+		c.hub.BroadcastRoomList()
+	}
+	c.spectating = ""
+}
+
+// spectatorGamePayload is game_started for spectators: the grid, with a
+// spectator flag instead of a player_index.
+func spectatorGamePayload(room *GameRoom, gt *grid.GridTemplate) GameStartedPayload {
+	payload := gridPayload(room, gt)
+	payload.Spectator = true
+	return payload
+}
@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAddSpectator(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+
+	if err := room.AddSpectator(alice); !errors.Is(err, ErrSpectatorIsPlayer) {
+		t.Errorf("player spectating their room = %v, want ErrSpectatorIsPlayer", err)
+	}
+	// Nor from another connection
+	if err := room.AddSpectator(testClient(h, "1")); !errors.Is(err, ErrSpectatorIsPlayer) {
+		t.Errorf("player's other tab spectating = %v, want ErrSpectatorIsPlayer", err)
+	}
+
+	watcher := testClient(h, "3")
+	if err := room.AddSpectator(watcher); err != nil {
+		t.Fatal(err)
+	}
+	if room.SpectatorCount() != 1 || room.IsPlayer(watcher.ID) {
+		t.Error("spectator not counted, or counted as a player")
+	}
+	if !room.RemoveSpectator(watcher.ID) || room.RemoveSpectator(watcher.ID) {
+		t.Error("RemoveSpectator didn't remove exactly once")
+	}
+
+	room.AllowSpectators = false
+	if err := room.AddSpectator(testClient(h, "4")); !errors.Is(err, ErrSpectatingDisabled) {
+		t.Errorf("spectating a closed room = %v, want ErrSpectatingDisabled", err)
+	}
+}
+
+func TestSpectatorDelay(t *testing.T) {
+	h := NewHub(nil)
+	room, alice, _ := playingRoom(t, h)
+	watcher := testClient(h, "3")
+	if err := room.AddSpectator(watcher); err != nil {
+		t.Fatal(err)
+	}
+	room.SpectatorDelay = 30 * time.Millisecond
+	drain(alice)
+	drain(watcher)
+
+	room.BroadcastWithSpectators([]byte("chat"), false)
+	room.BroadcastWithSpectators([]byte("board"), true)
+	if got := drain(alice); len(got) != 2 {
+		t.Errorf("player got %q, want both messages right away", got)
+	}
+	if got := drain(watcher); len(got) != 1 || got[0] != "chat" {
+		t.Errorf("spectator got %q right away, want only the message without the board", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := drain(watcher); len(got) != 1 || got[0] != "board" {
+		t.Errorf("spectator got %q after the delay, want the board", got)
+	}
+}
+
+// drain empties the client's send channel.
+func drain(c *Client) []string {
+	var got []string
+	for {
+		select {
+		case msg := <-c.send:
+			got = append(got, string(msg))
+		default:
+			return got
+		}
+	}
+}
@@ -10,7 +10,7 @@ import (
 // being made. It is passed as the callback to GameRoom.StartTurnTimer,
 // so it accepts (room, turnAtStart) and restarts the timer for the next
 // player once the turn has been skipped.
-func onTurnTimeout(room *GameRoom, turnAtStart int) {
+func (h *Hub) onTurnTimeout(room *GameRoom, turnAtStart int) {
 	room.mu.Lock()
 	if room.GameModel == nil || room.GameModel.Game.Status != models.GameStatusActive {
 		room.mu.Unlock()
@@ -25,18 +25,14 @@ func onTurnTimeout(room *GameRoom, turnAtStart int) {
 
 	game.SkipTurn(room.GameModel)
 	log.Printf("Turn timed out in room %s, skipping to turn %d", room.ID, room.GameModel.Game.CurrentTurn)
+	state := encode(MsgGameState, room.GameModel)
 	room.mu.Unlock()
 
-	room.Broadcast(mustMarshal(map[string]interface{}{
-		"type":    "turn_timeout",
-		"payload": map[string]interface{}{"roomId": room.ID},
-	}))
+	room.Broadcast(encode(MsgTurnTimeout, RoomEventPayload{RoomID: room.ID}))
 
-	room.Broadcast(mustMarshal(map[string]interface{}{
-		"type":    "game_state",
-		"payload": room.GameModel,
-	}))
+	room.BroadcastWithSpectators(state, true)
 
 	// Start the timer again for whoever's turn it is now
-	room.StartTurnTimer(onTurnTimeout)
+	room.StartTurnTimer(h.onTurnTimeout)
+	h.saveGame(room)
 }